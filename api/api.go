@@ -7,31 +7,74 @@ import (
 	"github.com/dimkus/okex/api/ws"
 )
 
-// Client is the main api wrapper of okex
-type Client struct {
-	Rest *rest.ClientRest
-	Ws   *ws.ClientWs
-	ctx  context.Context
+type (
+	// Client is the main api wrapper of okex
+	Client struct {
+		Rest *rest.ClientRest
+		Ws   *ws.ClientWs
+		ctx  context.Context
+	}
+
+	// Option customizes a Client created by NewClient
+	Option func(*options)
+
+	options struct {
+		restURL  okex.BaseURL
+		wsPubURL okex.BaseURL
+		wsPriURL okex.BaseURL
+		wsBizURL okex.BaseURL
+	}
+)
+
+// WithURLs overrides all the server urls of the destination, i.e. to run against a local okextest.Server
+func WithURLs(restURL, publicWsURL, privateWsURL, businessWsURL okex.BaseURL) Option {
+	return func(o *options) {
+		o.restURL = restURL
+		o.wsPubURL = publicWsURL
+		o.wsPriURL = privateWsURL
+		o.wsBizURL = businessWsURL
+	}
+}
+
+// WithBusinessURL overrides the url of the business websocket endpoint of the destination
+func WithBusinessURL(businessWsURL okex.BaseURL) Option {
+	return func(o *options) {
+		o.wsBizURL = businessWsURL
+	}
 }
 
 // NewClient returns a pointer to a fresh Client
-func NewClient(ctx context.Context, apiKey, secretKey, passphrase string, destination okex.Destination) (*Client, error) {
-	restURL := okex.RestURL
-	wsPubURL := okex.PublicWsURL
-	wsPriURL := okex.PrivateWsURL
+func NewClient(ctx context.Context, apiKey, secretKey, passphrase string, destination okex.Destination, opts ...Option) (*Client, error) {
+	o := options{
+		restURL:  okex.RestURL,
+		wsPubURL: okex.PublicWsURL,
+		wsPriURL: okex.PrivateWsURL,
+		wsBizURL: okex.BusinessWsURL,
+	}
 	switch destination {
 	case okex.AwsServer:
-		restURL = okex.AwsRestURL
-		wsPubURL = okex.AwsPublicWsURL
-		wsPriURL = okex.AwsPrivateWsURL
+		o.restURL = okex.AwsRestURL
+		o.wsPubURL = okex.AwsPublicWsURL
+		o.wsPriURL = okex.AwsPrivateWsURL
+		o.wsBizURL = okex.AwsBusinessWsURL
 	case okex.DemoServer:
-		restURL = okex.DemoRestURL
-		wsPubURL = okex.DemoPublicWsURL
-		wsPriURL = okex.DemoPrivateWsURL
+		o.restURL = okex.DemoRestURL
+		o.wsPubURL = okex.DemoPublicWsURL
+		o.wsPriURL = okex.DemoPrivateWsURL
+		o.wsBizURL = okex.DemoBusinessWsURL
+	}
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	r := rest.NewClient(apiKey, secretKey, passphrase, restURL, destination)
-	c := ws.NewClient(ctx, apiKey, secretKey, passphrase, map[bool]okex.BaseURL{true: wsPriURL, false: wsPubURL})
+	r := rest.NewClient(apiKey, secretKey, passphrase, o.restURL, destination)
+	c := ws.NewClient(ctx, apiKey, secretKey, passphrase, map[ws.Endpoint]okex.BaseURL{
+		ws.EndpointPublic:   o.wsPubURL,
+		ws.EndpointPrivate:  o.wsPriURL,
+		ws.EndpointBusiness: o.wsBizURL,
+	})
+	// order operations share the same limits on both rest and websocket
+	c.SetRateLimiter(r.RateLimiter())
 
 	return &Client{r, c, ctx}, nil
 }
@@ -2,11 +2,11 @@ package rest
 
 import (
 	"context"
-	"github.com/dimkus/okex"
+	models "github.com/dimkus/okex/models/account"
 	requests "github.com/dimkus/okex/requests/rest/account"
 	responses "github.com/dimkus/okex/responses/account"
 	"net/http"
-	"strings"
+	"time"
 )
 
 // Account
@@ -27,17 +27,13 @@ func NewAccount(c *ClientRest) *Account {
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-balance
 func (c *Account) GetBalance(ctx context.Context, req requests.GetBalance) (response responses.GetBalance, err error) {
 	p := "/api/v5/account/balance"
-	m := okex.S2M(req)
-	if len(req.Ccy) > 0 {
-		m["ccy"] = strings.Join(req.Ccy, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -47,20 +43,13 @@ func (c *Account) GetBalance(ctx context.Context, req requests.GetBalance) (resp
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-positions
 func (c *Account) GetPositions(ctx context.Context, req requests.GetPositions) (response responses.GetPositions, err error) {
 	p := "/api/v5/account/positions"
-	m := okex.S2M(req)
-	if len(req.InstID) > 0 {
-		m["instId"] = strings.Join(req.InstID, ",")
-	}
-	if len(req.PosID) > 0 {
-		m["posId"] = strings.Join(req.PosID, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -70,14 +59,13 @@ func (c *Account) GetPositions(ctx context.Context, req requests.GetPositions) (
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-account-and-position-risk
 func (c *Account) GetAccountAndPositionRisk(ctx context.Context, req requests.GetAccountAndPositionRisk) (response responses.GetAccountAndPositionRisk, err error) {
 	p := "/api/v5/account/positions"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -92,16 +80,15 @@ func (c *Account) GetAccountAndPositionRisk(ctx context.Context, req requests.Ge
 func (c *Account) GetBills(ctx context.Context, req requests.GetBills, arc bool) (response responses.GetBills, err error) {
 	p := "/api/v5/account/bills"
 	if arc {
-		p = "/api/account/bills-archive"
+		p = "/api/v5/account/bills-archive"
 	}
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -117,7 +104,7 @@ func (c *Account) GetConfig(ctx context.Context) (response responses.GetConfig,
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -127,14 +114,13 @@ func (c *Account) GetConfig(ctx context.Context) (response responses.GetConfig,
 // https://www.okex.com/docs-v5/en/#rest-api-account-set-position-mode
 func (c *Account) SetPositionMode(ctx context.Context, req requests.SetPositionMode) (response responses.SetPositionMode, err error) {
 	p := "/api/v5/account/set-position-mode"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -151,14 +137,13 @@ func (c *Account) SetPositionMode(ctx context.Context, req requests.SetPositionM
 // https://www.okex.com/docs-v5/en/#rest-api-account-set-leverage
 func (c *Account) SetLeverage(ctx context.Context, req requests.SetLeverage) (response responses.Leverage, err error) {
 	p := "/api/v5/account/set-leverage"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -167,17 +152,13 @@ func (c *Account) SetLeverage(ctx context.Context, req requests.SetLeverage) (re
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-maximum-buy-sell-amount-or-open-amount
 func (c *Account) GetMaxBuySellAmount(ctx context.Context, req requests.GetMaxBuySellAmount) (response responses.GetMaxBuySellAmount, err error) {
 	p := "/api/v5/account/max-size"
-	m := okex.S2M(req)
-	if len(req.InstID) > 0 {
-		m["instId"] = strings.Join(req.InstID, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -186,14 +167,13 @@ func (c *Account) GetMaxBuySellAmount(ctx context.Context, req requests.GetMaxBu
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-maximum-available-tradable-amount
 func (c *Account) GetMaxAvailableTradeAmount(ctx context.Context, req requests.GetMaxAvailableTradeAmount) (response responses.GetMaxAvailableTradeAmount, err error) {
 	p := "/api/v5/account/max-avail-size"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -203,14 +183,13 @@ func (c *Account) GetMaxAvailableTradeAmount(ctx context.Context, req requests.G
 // https://www.okex.com/docs-v5/en/#rest-api-account-increase-decrease-margin
 func (c *Account) IncreaseDecreaseMargin(ctx context.Context, req requests.IncreaseDecreaseMargin) (response responses.IncreaseDecreaseMargin, err error) {
 	p := "/api/v5/account/position/margin-balance"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -219,17 +198,13 @@ func (c *Account) IncreaseDecreaseMargin(ctx context.Context, req requests.Incre
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-leverage
 func (c *Account) GetLeverage(ctx context.Context, req requests.GetLeverage) (response responses.Leverage, err error) {
 	p := "/api/v5/account/leverage-info"
-	m := okex.S2M(req)
-	if len(req.InstID) > 0 {
-		m["instId"] = strings.Join(req.InstID, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -238,14 +213,13 @@ func (c *Account) GetLeverage(ctx context.Context, req requests.GetLeverage) (re
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-the-maximum-loan-of-instrument
 func (c *Account) GetMaxLoan(ctx context.Context, req requests.GetMaxLoan) (response responses.GetMaxLoan, err error) {
 	p := "/api/v5/account/max-loan"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -254,14 +228,13 @@ func (c *Account) GetMaxLoan(ctx context.Context, req requests.GetMaxLoan) (resp
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-fee-rates
 func (c *Account) GetFeeRates(ctx context.Context, req requests.GetFeeRates) (response responses.GetFeeRates, err error) {
 	p := "/api/v5/account/trade-fee"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -270,14 +243,13 @@ func (c *Account) GetFeeRates(ctx context.Context, req requests.GetFeeRates) (re
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-interest-accrued
 func (c *Account) GetInterestAccrued(ctx context.Context, req requests.GetInterestAccrued) (response responses.GetInterestAccrued, err error) {
 	p := "/api/v5/account/interest-accrued"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -287,17 +259,13 @@ func (c *Account) GetInterestAccrued(ctx context.Context, req requests.GetIntere
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-interest-rate
 func (c *Account) GetInterestRates(ctx context.Context, req requests.GetBalance) (response responses.GetInterestRates, err error) {
 	p := "/api/v5/account/interest-rate"
-	m := okex.S2M(req)
-	if len(req.Ccy) > 0 {
-		m["ccy"] = strings.Join(req.Ccy, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -307,13 +275,12 @@ func (c *Account) GetInterestRates(ctx context.Context, req requests.GetBalance)
 // https://www.okex.com/docs-v5/en/#rest-api-account-set-greeks-m-bs
 func (c *Account) SetGreeks(ctx context.Context, req requests.SetGreeks) (response responses.SetGreeks, err error) {
 	p := "/api/v5/account/set-greeks"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 
 	return
 }
@@ -323,16 +290,25 @@ func (c *Account) SetGreeks(ctx context.Context, req requests.SetGreeks) (respon
 // https://www.okex.com/docs-v5/en/#rest-api-account-get-maximum-withdrawals
 func (c *Account) GetMaxWithdrawals(ctx context.Context, req requests.GetBalance) (response responses.GetMaxWithdrawals, err error) {
 	p := "/api/v5/account/max-withdrawal"
-	m := okex.S2M(req)
-	if len(req.Ccy) > 0 {
-		m["ccy"] = strings.Join(req.Ccy, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 
 	return
 }
+
+// BillsIterator walks GetBills pages following the billId cursor
+func (c *Account) BillsIterator(ctx context.Context, req requests.GetBills, arc bool) *Iterator[*models.Bill] {
+	return newIterator(ctx, afterCursor(req.After), func(ctx context.Context, after string) ([]*models.Bill, error) {
+		req.After = intCursor(after)
+		res, err := c.GetBills(ctx, req, arc)
+		return res.Bills, err
+	}, func(b *models.Bill) string {
+		return b.BillID
+	}, func(b *models.Bill) time.Time {
+		return time.Time(b.TS)
+	})
+}
@@ -6,15 +6,16 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/ratelimit"
 	requests "github.com/dimkus/okex/requests/rest/public"
 	responses2 "github.com/dimkus/okex/responses"
 	responses "github.com/dimkus/okex/responses/public_data"
 	"github.com/goccy/go-json"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -34,6 +35,9 @@ type ClientRest struct {
 	destination    okex.Destination
 	baseURL        okex.BaseURL
 	client         *http.Client
+	limiter        *ratelimit.Registry
+	retry          *RetryPolicy
+	validator      OrderValidator
 	serverTimeDiff time.Duration
 }
 
@@ -46,6 +50,7 @@ func NewClient(apiKey, secretKey, passphrase string, baseURL okex.BaseURL, desti
 		baseURL:     baseURL,
 		destination: destination,
 		client:      http.DefaultClient,
+		limiter:     ratelimit.NewRegistry(ratelimit.PolicyWait),
 	}
 	c.Account = NewAccount(c)
 	c.SubAccount = NewSubAccount(c)
@@ -62,50 +67,103 @@ func (c *ClientRest) WithHTTPClient(httpClient *http.Client) *ClientRest {
 	return c
 }
 
+// WithRateLimiter replaces the client side rate limiter, the same registry can be shared with ws.ClientWs
+func (c *ClientRest) WithRateLimiter(limiter *ratelimit.Registry) *ClientRest {
+	c.limiter = limiter
+	return c
+}
+
+// RateLimiter returns the client side rate limiter
+func (c *ClientRest) RateLimiter() *ratelimit.Registry {
+	return c.limiter
+}
+
+// WithRetryPolicy enables retries of transient failures, a nil policy disables them
+func (c *ClientRest) WithRetryPolicy(policy *RetryPolicy) *ClientRest {
+	c.retry = policy
+	return c
+}
+
+// WithOrderValidator checks every order locally before Trade.PlaceOrder sends it, a nil validator disables the check
+func (c *ClientRest) WithOrderValidator(validator OrderValidator) *ClientRest {
+	c.validator = validator
+	return c
+}
+
 // Do the http request to the server
-func (c *ClientRest) Do(ctx context.Context, method, path string, private bool, params ...map[string]string) (*http.Response, error) {
-	u := fmt.Sprintf("%s%s", c.baseURL, path)
+//
+// The request is any value encoding to a json object, or a list of them for the batch endpoints. It is sent as the
+// body of the POST requests as is, and as the query of the GET requests with its lists joined by commas, see
+// okex.S2M. The signature covers the exact query and body sent.
+//
+// GET requests and the safe paths of the retry policy are repeated on transient failures.
+func (c *ClientRest) Do(ctx context.Context, method, path string, private bool, params ...interface{}) (*http.Response, error) {
+	if c.retry == nil || !c.retry.safe(method, path) {
+		return c.do(ctx, method, path, private, params...)
+	}
+	for attempt := 1; ; attempt++ {
+		res, err := c.do(ctx, method, path, private, params...)
+		if err == nil {
+			err = peek(res)
+		}
+		if !c.retry.Retryable(err) || !c.retry.wait(ctx, attempt) {
+			if res != nil {
+				// the body has been buffered by peek, decode will surface the error
+				return res, nil
+			}
+			return nil, err
+		}
+		if res != nil {
+			_ = res.Body.Close()
+		}
+	}
+}
+
+func (c *ClientRest) do(ctx context.Context, method, path string, private bool, params ...interface{}) (*http.Response, error) {
 	var (
 		r    *http.Request
 		err  error
-		j    []byte
-		body string
+		req  interface{}
+		body []byte
+		uri  = path
 	)
+	if len(params) > 0 {
+		req = params[0]
+	}
 	if method == http.MethodGet {
-		r, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-		if err != nil {
-			return nil, err
-		}
-		if len(params) > 0 {
-			q := r.URL.Query()
-			for k, v := range params[0] {
-				q.Add(k, strings.ReplaceAll(v, "\"", ""))
-			}
-			r.URL.RawQuery = q.Encode()
-			if len(params[0]) > 0 {
-				path += "?" + r.URL.RawQuery
+		if m := okex.S2M(req); len(m) > 0 {
+			q := make(url.Values, len(m))
+			for k, v := range m {
+				q.Set(k, v)
 			}
+			// the lists are sent with their commas as is, like the examples of the docs
+			uri += "?" + strings.ReplaceAll(q.Encode(), "%2C", ",")
 		}
-	} else {
-		j, err = json.Marshal(params[0])
+	} else if req != nil {
+		body, err = json.Marshal(req)
 		if err != nil {
 			return nil, err
 		}
-		body = string(j)
-		if body == "{}" {
-			body = ""
-		}
-		r, err = http.NewRequestWithContext(ctx, method, u, bytes.NewBuffer(j))
-		if err != nil {
+	}
+	if c.limiter != nil {
+		if err = c.limiter.Wait(ctx, path, instIDs(method, req, body)...); err != nil {
 			return nil, err
 		}
-		r.Header.Add("Content-Type", "application/json")
+	}
+	u := fmt.Sprintf("%s%s", c.baseURL, uri)
+	if method == http.MethodGet {
+		r, err = http.NewRequestWithContext(ctx, method, u, nil)
+	} else {
+		r, err = http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
 	}
 	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		r.Header.Add("Content-Type", "application/json")
+	}
 	if private {
-		timestamp, sign := c.sign(method, path, body)
+		timestamp, sign := c.sign(method, uri, string(body))
 		r.Header.Add("OK-ACCESS-KEY", c.apiKey)
 		r.Header.Add("OK-ACCESS-PASSPHRASE", c.passphrase)
 		r.Header.Add("OK-ACCESS-SIGN", sign)
@@ -117,6 +175,38 @@ func (c *ClientRest) Do(ctx context.Context, method, path string, private bool,
 	return c.client.Do(r)
 }
 
+// instIDs returns the instruments of the request, one per order of the batch requests
+func instIDs(method string, req interface{}, body []byte) []string {
+	if method == http.MethodGet {
+		if id := okex.S2M(req)["instId"]; id != "" {
+			return []string{id}
+		}
+		return nil
+	}
+	var one struct {
+		InstID string `json:"instId"`
+	}
+	if json.Unmarshal(body, &one) == nil {
+		if one.InstID == "" {
+			return nil
+		}
+		return []string{one.InstID}
+	}
+	var batch []struct {
+		InstID string `json:"instId"`
+	}
+	if json.Unmarshal(body, &batch) != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(batch))
+	for _, o := range batch {
+		if o.InstID != "" {
+			ids = append(ids, o.InstID)
+		}
+	}
+	return ids
+}
+
 // Status
 // Get event status of system upgrade
 //
@@ -129,8 +219,8 @@ func (c *ClientRest) Status(ctx context.Context, req requests.Status) (response
 		return
 	}
 	defer res.Body.Close()
-	d := json.NewDecoder(res.Body)
-	err = d.Decode(&response)
+
+	err = c.decode(res, &response)
 	return
 }
 
@@ -150,22 +240,59 @@ func (c *ClientRest) sign(method, path, body string) (string, string) {
 	return ts, base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
-func (c *ClientRest) decode(reader io.Reader, v any) error {
-	err := json.NewDecoder(reader).Decode(&v)
+func (c *ClientRest) decode(res *http.Response, v any) error {
+	err := json.NewDecoder(res.Body).Decode(&v)
 
 	if err != nil {
+		if res.StatusCode >= http.StatusBadRequest {
+			return &okex.APIError{Msg: http.StatusText(res.StatusCode), HTTPStatus: res.StatusCode, Endpoint: endpoint(res)}
+		}
 		return err
 	}
 
-	_, ok := v.(responses2.BasicI)
+	vBasic, ok := v.(responses2.BasicI)
 	if !ok {
 		return nil
 	}
 
-	vBasic := v.(responses2.BasicI)
-	if vBasic.GetCode() != 0 {
-		return errors.New(fmt.Sprintf("code: %d, msg: %s", vBasic.GetCode(), vBasic.GetMsg()))
+	var subErrors []*okex.SubError
+	if vBatch, ok := v.(responses2.BatchI); ok {
+		subErrors = vBatch.GetSubErrors()
+	}
+	if vBasic.GetCode() != 0 || len(subErrors) > 0 {
+		return &okex.APIError{
+			Code:       vBasic.GetCode(),
+			Msg:        vBasic.GetMsg(),
+			HTTPStatus: res.StatusCode,
+			Endpoint:   endpoint(res),
+			SubErrors:  subErrors,
+		}
 	}
 
 	return nil
 }
+
+// peek decodes the basic part of the response without consuming its body
+func peek(res *http.Response) error {
+	b, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	var basic responses2.Basic
+	if json.Unmarshal(b, &basic) != nil {
+		basic.Code = 0
+	}
+	if basic.Code == 0 && res.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	return &okex.APIError{Code: basic.Code, Msg: basic.Msg, HTTPStatus: res.StatusCode, Endpoint: endpoint(res)}
+}
+
+func endpoint(res *http.Response) string {
+	if res.Request == nil || res.Request.URL == nil {
+		return ""
+	}
+	return res.Request.URL.Path
+}
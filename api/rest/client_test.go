@@ -0,0 +1,108 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/okextest"
+	requests_account "github.com/dimkus/okex/requests/rest/account"
+	requests_market "github.com/dimkus/okex/requests/rest/market"
+	requests_trade "github.com/dimkus/okex/requests/rest/trade"
+)
+
+// TestSignature goes through the verification of the server, which signs the exact query and body received
+func TestSignature(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c := rest.NewClient("key", "secret", "pass", s.RestURL(), okex.NormalServer)
+	s.Handle(http.MethodGet, "/api/v5/account/balance", okextest.OK())
+	s.Handle(http.MethodPost, "/api/v5/account/set-leverage", okextest.OK(map[string]string{"lever": "5"}))
+	s.Handle(http.MethodGet, "/api/v5/market/ticker", okextest.OK())
+
+	// a query with a list, which is sent with its commas
+	if _, err := c.Account.GetBalance(ctx, requests_account.GetBalance{Ccy: []string{"BTC", "ETH"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Account.SetLeverage(ctx, requests_account.SetLeverage{Lever: 5, InstID: "BTC-USDT-SWAP", MgnMode: okex.MarginCrossMode}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Market.GetTicker(ctx, requests_market.GetTickers{InstType: okex.SpotInstrument}); err != nil {
+		t.Fatal(err)
+	}
+	reqs := s.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("got %d requests, want 3", len(reqs))
+	}
+	if r := reqs[1]; r.Header.Get("Content-Type") != "application/json" || string(r.Body) == "" {
+		t.Fatalf("the POST body wasn't sent as json: %q %q", r.Header.Get("Content-Type"), r.Body)
+	}
+	if r := reqs[2]; r.Header.Get("OK-ACCESS-SIGN") != "" {
+		t.Fatal("a public request was signed")
+	}
+	for _, r := range reqs {
+		if r.Header.Get("x-simulated-trading") != "" {
+			t.Fatal("a request to the normal server was marked as simulated")
+		}
+	}
+
+	demo := rest.NewClient("key", "secret", "pass", s.RestURL(), okex.DemoServer)
+	if _, err := demo.Account.GetBalance(ctx, requests_account.GetBalance{}); err != nil {
+		t.Fatal(err)
+	}
+	if reqs = s.Requests(); reqs[len(reqs)-1].Header.Get("x-simulated-trading") != "1" {
+		t.Fatal("a request to the demo server wasn't marked as simulated")
+	}
+
+	for _, wrong := range []*rest.ClientRest{
+		rest.NewClient("key", "other", "pass", s.RestURL(), okex.NormalServer),
+		rest.NewClient("other", "secret", "pass", s.RestURL(), okex.NormalServer),
+		rest.NewClient("key", "secret", "other", s.RestURL(), okex.NormalServer),
+	} {
+		if _, err := wrong.Account.SetLeverage(ctx, requests_account.SetLeverage{Lever: 5, InstID: "BTC-USDT-SWAP", MgnMode: okex.MarginCrossMode}); !okex.IsAuthError(err) {
+			t.Fatalf("got %v, want an authentication error", err)
+		}
+	}
+}
+
+func TestBatchSubErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c := rest.NewClient("key", "secret", "pass", s.RestURL(), okex.NormalServer)
+	s.Handle(http.MethodPost, "/api/v5/trade/batch-orders", func(*okextest.Request) (int, any) {
+		return http.StatusOK, map[string]any{"code": "2", "msg": "Bulk operation partially succeeded", "data": []map[string]string{
+			{"clOrdId": "a", "ordId": "1", "sCode": "0", "sMsg": ""},
+			{"clOrdId": "b", "ordId": "", "sCode": "51008", "sMsg": "Order failed. Insufficient balance"},
+		}}
+	})
+
+	res, err := c.Trade.PlaceMultipleOrders(ctx, []requests_trade.PlaceOrder{
+		{InstID: "BTC-USDT", ClOrdID: "a", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+		{InstID: "BTC-USDT", ClOrdID: "b", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+	})
+	var e *okex.APIError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want an APIError", err)
+	}
+	if e.Code != 2 || e.HTTPStatus != http.StatusOK || e.Endpoint != "/api/v5/trade/batch-orders" {
+		t.Fatalf("got %+v", e)
+	}
+	if len(e.SubErrors) != 1 || e.SubErrors[0].Index != 1 || e.SubErrors[0].ClOrdID != "b" || e.SubErrors[0].Code != 51008 {
+		t.Fatalf("got %+v, want the failed order only", e.SubErrors)
+	}
+	if !okex.IsInsufficientBalance(err) || okex.IsRateLimited(err) {
+		t.Fatalf("%v misclassified", err)
+	}
+	// the orders placed are returned along with the error
+	if len(res.PlaceOrders) != 2 || res.PlaceOrders[0].OrdID != "1" {
+		t.Fatalf("got %+v", res.PlaceOrders)
+	}
+}
@@ -0,0 +1,130 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/okextest"
+	requests_account "github.com/dimkus/okex/requests/rest/account"
+	requests_subaccount "github.com/dimkus/okex/requests/rest/subaccount"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	"github.com/goccy/go-json"
+)
+
+// body decodes the json body of a request as sent, numbers are kept as their literals
+func body(t *testing.T, r *okextest.Request) any {
+	t.Helper()
+	var v any
+	d := json.NewDecoder(bytes.NewReader(r.Body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		t.Fatalf("%s %s: %v in %q", r.Method, r.Path, err, r.Body)
+	}
+	return v
+}
+
+// TestWireFormat pins the bodies and the queries as the exchange expects them
+func TestWireFormat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/api/v5/trade/order", "/api/v5/trade/batch-orders", "/api/v5/trade/cancel-batch-orders",
+		"/api/v5/trade/order-algo", "/api/v5/users/subaccount/apikey"} {
+		s.Handle(http.MethodPost, p, okextest.OK())
+	}
+	s.Handle(http.MethodGet, "/api/v5/account/positions", okextest.OK())
+	s.Handle(http.MethodGet, "/api/v5/account/max-size", okextest.OK())
+
+	order := requests.PlaceOrder{ID: "local", InstID: "BTC-USDT-SWAP", ClOrdID: "a", ReduceOnly: true, Sz: "1.50", Px: "100",
+		TdMode: okex.TradeCrossMode, Side: okex.OrderSell, PosSide: okex.PositionShortSide, OrdType: okex.OrderLimit}
+	wantOrder := map[string]any{"instId": "BTC-USDT-SWAP", "clOrdId": "a", "reduceOnly": true, "sz": "1.50", "px": "100",
+		"tdMode": "cross", "side": "sell", "posSide": "short", "ordType": "limit"}
+	market := requests.PlaceOrder{InstID: "BTC-USDT", Sz: "2", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderMarket}
+	wantMarket := map[string]any{"instId": "BTC-USDT", "sz": "2", "tdMode": "cash", "side": "buy", "ordType": "market"}
+
+	calls := []struct {
+		name   string
+		call   func() error
+		method string
+		path   string
+		query  map[string]string
+		body   any
+	}{
+		{"a single order is an object", func() error {
+			_, err := c.Rest.Trade.PlaceOrder(ctx, []requests.PlaceOrder{order})
+			return err
+		}, http.MethodPost, "/api/v5/trade/order", nil, wantOrder},
+		{"a batch is an array", func() error {
+			_, err := c.Rest.Trade.PlaceOrder(ctx, []requests.PlaceOrder{order, market})
+			return err
+		}, http.MethodPost, "/api/v5/trade/batch-orders", nil, []any{wantOrder, wantMarket}},
+		{"a batch of cancellations is an array", func() error {
+			_, err := c.Rest.Trade.CandleOrder(ctx, []requests.CancelOrder{{InstID: "BTC-USDT", OrdID: "1"}, {InstID: "ETH-USDT", ClOrdID: "b"}})
+			return err
+		}, http.MethodPost, "/api/v5/trade/cancel-batch-orders", nil, []any{
+			map[string]any{"instId": "BTC-USDT", "ordId": "1"},
+			map[string]any{"instId": "ETH-USDT", "clOrdId": "b"},
+		}},
+		{"the embedded parameters are flattened", func() error {
+			_, err := c.Rest.Trade.PlaceAlgoOrder(ctx, requests.PlaceAlgoOrder{InstID: "BTC-USDT", TdMode: okex.TradeCashMode, Side: okex.OrderBuy,
+				OrdType: okex.AlgoOrderConditional, Sz: "1", StopOrder: requests.StopOrder{TpTriggerPx: "110", TpOrdPx: "-1"}})
+			return err
+		}, http.MethodPost, "/api/v5/trade/order-algo", nil, map[string]any{"instId": "BTC-USDT", "tdMode": "cash", "side": "buy",
+			"ordType": "conditional", "sz": "1", "tpTriggerPx": "110", "tpOrdPx": "-1"}},
+		{"a list in a body is comma joined", func() error {
+			_, err := c.Rest.SubAccount.CreateAPIKey(ctx, requests_subaccount.CreateAPIKey{Pwd: "p", SubAcct: "sub", Label: "l", Passphrase: "pp",
+				IP: []string{"1.1.1.1", "2.2.2.2"}, Perm: okex.APIKeyTrade})
+			return err
+		}, http.MethodPost, "/api/v5/users/subaccount/apikey", nil, map[string]any{"pwd": "p", "subAcct": "sub", "label": "l", "Passphrase": "pp",
+			"ip": "1.1.1.1,2.2.2.2", "perm": "trade"}},
+		{"the lists of a query are comma joined", func() error {
+			_, err := c.Rest.Account.GetPositions(ctx, requests_account.GetPositions{InstID: []string{"BTC-USDT-SWAP", "ETH-USDT-SWAP"}, InstType: okex.SwapInstrument})
+			return err
+		}, http.MethodGet, "/api/v5/account/positions", map[string]string{"instId": "BTC-USDT-SWAP,ETH-USDT-SWAP", "instType": "SWAP"}, nil},
+		{"the numbers of a query are sent as is", func() error {
+			_, err := c.Rest.Account.GetMaxBuySellAmount(ctx, requests_account.GetMaxBuySellAmount{Px: 0.5, InstID: []string{"BTC-USDT"}, TdMode: okex.TradeCashMode})
+			return err
+		}, http.MethodGet, "/api/v5/account/max-size", map[string]string{"px": "0.5", "instId": "BTC-USDT", "tdMode": "cash"}, nil},
+	}
+	for _, tt := range calls {
+		n := len(s.Requests())
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		reqs := s.Requests()
+		if len(reqs) != n+1 {
+			t.Fatalf("%s: got %d requests, want 1", tt.name, len(reqs)-n)
+		}
+		r := reqs[n]
+		if r.Method != tt.method || r.Path != tt.path {
+			t.Errorf("%s: got %s %s, want %s %s", tt.name, r.Method, r.Path, tt.method, tt.path)
+			continue
+		}
+		query := make(map[string]string, len(r.Query))
+		for k := range r.Query {
+			query[k] = r.Query.Get(k)
+		}
+		if len(query) != len(tt.query) || (len(query) > 0 && !reflect.DeepEqual(query, tt.query)) {
+			t.Errorf("%s: got the query %v, want %v", tt.name, query, tt.query)
+		}
+		if tt.body == nil {
+			if len(r.Body) != 0 {
+				t.Errorf("%s: got the body %q, want none", tt.name, r.Body)
+			}
+			continue
+		}
+		if got := body(t, r); !reflect.DeepEqual(got, tt.body) {
+			t.Errorf("%s: got the body %s, want %v", tt.name, r.Body, tt.body)
+		}
+	}
+}
@@ -2,11 +2,12 @@ package rest
 
 import (
 	"context"
-	"github.com/dimkus/okex"
+	models "github.com/dimkus/okex/models/funding"
 	requests "github.com/dimkus/okex/requests/rest/funding"
 	responses "github.com/dimkus/okex/responses/funding"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 )
 
 // Funding
@@ -34,7 +35,7 @@ func (c *Funding) GetCurrencies(ctx context.Context) (response responses.GetCurr
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -44,17 +45,13 @@ func (c *Funding) GetCurrencies(ctx context.Context) (response responses.GetCurr
 // https://www.okex.com/docs-v5/en/#rest-api-funding-get-balance
 func (c *Funding) GetBalance(ctx context.Context, req requests.GetBalance) (response responses.GetBalance, err error) {
 	p := "/api/v5/asset/balances"
-	m := okex.S2M(req)
-	if len(req.Ccy) > 0 {
-		m["ccy"] = strings.Join(req.Ccy, ",")
-	}
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -64,14 +61,13 @@ func (c *Funding) GetBalance(ctx context.Context, req requests.GetBalance) (resp
 // https://www.okex.com/docs-v5/en/#rest-api-funding-funds-transfer
 func (c *Funding) FundsTransfer(ctx context.Context, req requests.FundsTransfer) (response responses.FundsTransfer, err error) {
 	p := "/api/v5/asset/transfer"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -81,14 +77,13 @@ func (c *Funding) FundsTransfer(ctx context.Context, req requests.FundsTransfer)
 // https://www.okex.com/docs-v5/en/#rest-api-funding-asset-bills-details
 func (c *Funding) AssetBillsDetails(ctx context.Context, req requests.AssetBillsDetails) (response responses.AssetBillsDetails, err error) {
 	p := "/api/v5/asset/bills"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -98,14 +93,13 @@ func (c *Funding) AssetBillsDetails(ctx context.Context, req requests.AssetBills
 // https://www.okex.com/docs-v5/en/#rest-api-funding-get-deposit-address
 func (c *Funding) GetDepositAddress(ctx context.Context, req requests.GetDepositAddress) (response responses.GetDepositAddress, err error) {
 	p := "/api/v5/asset/deposit-address"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -115,14 +109,13 @@ func (c *Funding) GetDepositAddress(ctx context.Context, req requests.GetDeposit
 // https://www.okex.com/docs-v5/en/#rest-api-funding-get-deposit-history
 func (c *Funding) GetDepositHistory(ctx context.Context, req requests.GetDepositHistory) (response responses.GetDepositHistory, err error) {
 	p := "/api/v5/asset/deposit-history"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -132,14 +125,13 @@ func (c *Funding) GetDepositHistory(ctx context.Context, req requests.GetDeposit
 // https://www.okex.com/docs-v5/en/#rest-api-funding-withdrawal
 func (c *Funding) Withdrawal(ctx context.Context, req requests.Withdrawal) (response responses.Withdrawal, err error) {
 	p := "/api/v5/asset/withdrawal"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -149,14 +141,13 @@ func (c *Funding) Withdrawal(ctx context.Context, req requests.Withdrawal) (resp
 // https://www.okex.com/docs-v5/en/#rest-api-funding-get-withdrawal-history
 func (c *Funding) GetWithdrawalHistory(ctx context.Context, req requests.GetWithdrawalHistory) (response responses.GetWithdrawalHistory, err error) {
 	p := "/api/v5/asset/withdrawal-history"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -165,14 +156,13 @@ func (c *Funding) GetWithdrawalHistory(ctx context.Context, req requests.GetWith
 // https://www.okex.com/docs-v5/en/#rest-api-funding-piggybank-purchase-redemption
 func (c *Funding) PiggyBankPurchaseRedemption(ctx context.Context, req requests.PiggyBankPurchaseRedemption) (response responses.PiggyBankPurchaseRedemption, err error) {
 	p := "/api/v5/asset/purchase_redempt"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -181,13 +171,51 @@ func (c *Funding) PiggyBankPurchaseRedemption(ctx context.Context, req requests.
 // https://www.okex.com/docs-v5/en/#rest-api-funding-get-piggybank-balance
 func (c *Funding) GetPiggyBankBalance(ctx context.Context, req requests.GetPiggyBankBalance) (response responses.GetPiggyBankBalance, err error) {
 	p := "/api/v5/asset/piggy-balance"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
+
+// AssetBillsDetailsIterator walks AssetBillsDetails pages following the ts cursor, a full page of one millisecond stops it with ErrFullMillisecond
+func (c *Funding) AssetBillsDetailsIterator(ctx context.Context, req requests.AssetBillsDetails) *Iterator[*models.Bill] {
+	return newTsIterator(ctx, afterCursor(req.After), req.Limit, func(ctx context.Context, after string) ([]*models.Bill, error) {
+		req.After = intCursor(after)
+		res, err := c.AssetBillsDetails(ctx, req)
+		return res.Bills, err
+	}, func(b *models.Bill) string {
+		return b.BillID
+	}, func(b *models.Bill) time.Time {
+		return time.Time(b.TS)
+	})
+}
+
+// DepositHistoryIterator walks GetDepositHistory pages following the ts cursor, a full page of one millisecond stops it with ErrFullMillisecond
+func (c *Funding) DepositHistoryIterator(ctx context.Context, req requests.GetDepositHistory) *Iterator[*models.DepositHistory] {
+	return newTsIterator(ctx, afterCursor(req.After), req.Limit, func(ctx context.Context, after string) ([]*models.DepositHistory, error) {
+		req.After = intCursor(after)
+		res, err := c.GetDepositHistory(ctx, req)
+		return res.DepositHistories, err
+	}, func(d *models.DepositHistory) string {
+		return d.DepId
+	}, func(d *models.DepositHistory) time.Time {
+		return time.Time(d.TS)
+	})
+}
+
+// WithdrawalHistoryIterator walks GetWithdrawalHistory pages following the ts cursor, a full page of one millisecond stops it with ErrFullMillisecond
+func (c *Funding) WithdrawalHistoryIterator(ctx context.Context, req requests.GetWithdrawalHistory) *Iterator[*models.WithdrawalHistory] {
+	return newTsIterator(ctx, afterCursor(req.After), req.Limit, func(ctx context.Context, after string) ([]*models.WithdrawalHistory, error) {
+		req.After = intCursor(after)
+		res, err := c.GetWithdrawalHistory(ctx, req)
+		return res.WithdrawalHistories, err
+	}, func(w *models.WithdrawalHistory) string {
+		return strconv.FormatInt(int64(w.WdID), 10)
+	}, func(w *models.WithdrawalHistory) time.Time {
+		return time.Time(w.TS)
+	})
+}
@@ -2,10 +2,11 @@ package rest
 
 import (
 	"context"
-	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/market"
 	requests "github.com/dimkus/okex/requests/rest/market"
 	responses "github.com/dimkus/okex/responses/market"
 	"net/http"
+	"time"
 )
 
 // Market
@@ -26,14 +27,13 @@ func NewMarket(c *ClientRest) *Market {
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-tickers
 func (c *Market) GetTickers(ctx context.Context, req requests.GetTickers) (response responses.Ticker, err error) {
 	p := "/api/v5/market/tickers"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -43,14 +43,13 @@ func (c *Market) GetTickers(ctx context.Context, req requests.GetTickers) (respo
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-ticker
 func (c *Market) GetTicker(ctx context.Context, req requests.GetTickers) (response responses.Ticker, err error) {
 	p := "/api/v5/market/ticker"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -60,14 +59,13 @@ func (c *Market) GetTicker(ctx context.Context, req requests.GetTickers) (respon
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-index-tickers
 func (c *Market) GetIndexTickers(ctx context.Context, req requests.GetIndexTickers) (response responses.Ticker, err error) {
 	p := "/api/v5/market/ticker"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -77,14 +75,13 @@ func (c *Market) GetIndexTickers(ctx context.Context, req requests.GetIndexTicke
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-order-book
 func (c *Market) GetOrderBook(ctx context.Context, req requests.GetOrderBook) (response responses.OrderBook, err error) {
 	p := "/api/v5/market/books"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -94,14 +91,13 @@ func (c *Market) GetOrderBook(ctx context.Context, req requests.GetOrderBook) (r
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-candlesticks
 func (c *Market) GetCandlesticks(ctx context.Context, req requests.GetCandlesticks) (response responses.Candle, err error) {
 	p := "/api/v5/market/candles"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -111,14 +107,13 @@ func (c *Market) GetCandlesticks(ctx context.Context, req requests.GetCandlestic
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-candlesticks
 func (c *Market) GetCandlesticksHistory(ctx context.Context, req requests.GetCandlesticks) (response responses.Candle, err error) {
 	p := "/api/v5/market/history-candles"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -128,14 +123,13 @@ func (c *Market) GetCandlesticksHistory(ctx context.Context, req requests.GetCan
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-index-candlesticks
 func (c *Market) GetIndexCandlesticks(ctx context.Context, req requests.GetCandlesticks) (response responses.IndexCandle, err error) {
 	p := "/api/v5/market/index-candles"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -145,14 +139,13 @@ func (c *Market) GetIndexCandlesticks(ctx context.Context, req requests.GetCandl
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-mark-price-candlesticks
 func (c *Market) GetMarkPriceCandlesticks(ctx context.Context, req requests.GetCandlesticks) (response responses.CandleMarket, err error) {
 	p := "/api/v5/market/mark-price-candles"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -162,14 +155,13 @@ func (c *Market) GetMarkPriceCandlesticks(ctx context.Context, req requests.GetC
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-trades
 func (c *Market) GetTrades(ctx context.Context, req requests.GetTrades) (response responses.Trade, err error) {
 	p := "/api/v5/market/trades"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -185,7 +177,7 @@ func (c *Market) Get24HTotalVolume(ctx context.Context) (response responses.Tota
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -195,13 +187,25 @@ func (c *Market) Get24HTotalVolume(ctx context.Context) (response responses.Tota
 // https://www.okex.com/docs-v5/en/#rest-api-market-data-get-index-components
 func (c *Market) GetIndexComponents(ctx context.Context, req requests.GetIndexComponents) (response responses.IndexComponent, err error) {
 	p := "/api/v5/market/index-components"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
+
+// CandlesticksHistoryIterator walks GetCandlesticksHistory pages following the ts cursor
+func (c *Market) CandlesticksHistoryIterator(ctx context.Context, req requests.GetCandlesticks) *Iterator[*market.Candle] {
+	return newIterator(ctx, afterCursor(req.After), func(ctx context.Context, after string) ([]*market.Candle, error) {
+		req.After = intCursor(after)
+		res, err := c.GetCandlesticksHistory(ctx, req)
+		return res.Candles, err
+	}, func(candle *market.Candle) string {
+		return msCursor(time.Time(candle.TS))
+	}, func(candle *market.Candle) time.Time {
+		return time.Time(candle.TS)
+	})
+}
@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrFullMillisecond stops a ts cursor iteration when a whole page shares one millisecond, the records of it beyond
+// the page can't be reached with the cursor. The iteration can be started again with a larger limit.
+var ErrFullMillisecond = errors.New("a whole page shares one millisecond")
+
+// Iterator walks a paginated endpoint following its after cursor, from the most recent records to the oldest ones.
+//
+//	it := client.Trade.TransactionDetailsIterator(ctx, req, true).Until(time.Now().AddDate(0, -1, 0))
+//	for it.Next() {
+//		log.Println(it.Item().FillPx)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatalln(err)
+//	}
+//
+// Every page goes through ClientRest.Do, so the client side rate limiter and retry policy apply.
+type Iterator[T any] struct {
+	ctx    context.Context
+	fetch  func(ctx context.Context, after string) ([]T, error)
+	cursor func(T) string
+	id     func(T) string
+	ts     func(T) time.Time
+	until  time.Time
+	after  string
+	limit  int
+	edge   int64
+	seen   map[string]bool
+	page   []T
+	item   T
+	err    error
+	done   bool
+}
+
+func newIterator[T any](ctx context.Context, after string, fetch func(context.Context, string) ([]T, error), cursor func(T) string, ts func(T) time.Time) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, after: after, fetch: fetch, cursor: cursor, ts: ts}
+}
+
+// newTsIterator walks an endpoint whose after cursor is a timestamp in milliseconds. Several records can share the
+// millisecond a page ends on, so the next page is requested from that millisecond inclusive and the records already
+// returned are told apart by id. A full page holding nothing but records already returned stops the iteration with
+// ErrFullMillisecond rather than skipping the rest of the millisecond, limit is the page size of the requests and
+// defaults to the 100 records of the endpoints.
+func newTsIterator[T any](ctx context.Context, after string, limit int64, fetch func(context.Context, string) ([]T, error), id func(T) string, ts func(T) time.Time) *Iterator[T] {
+	if limit <= 0 {
+		limit = 100
+	}
+	return &Iterator[T]{ctx: ctx, after: after, limit: int(limit), fetch: fetch, id: id, ts: ts}
+}
+
+// Until stops the iteration at the first record older than t
+func (it *Iterator[T]) Until(t time.Time) *Iterator[T] {
+	it.until = t
+	return it
+}
+
+// Next advances to the next record, fetching the next page when needed
+func (it *Iterator[T]) Next() bool {
+	if it.done {
+		return false
+	}
+	for len(it.page) == 0 {
+		if err := it.ctx.Err(); err != nil {
+			return it.stop(err)
+		}
+		page, err := it.fetch(it.ctx, it.after)
+		if err != nil || len(page) == 0 {
+			return it.stop(err)
+		}
+		if it.id != nil {
+			if it.page, err = it.fresh(page); err != nil || len(it.page) == 0 {
+				return it.stop(err)
+			}
+			continue
+		}
+		next := it.cursor(page[len(page)-1])
+		if next == it.after {
+			return it.stop(nil)
+		}
+		it.after = next
+		it.page = page
+	}
+	it.item, it.page = it.page[0], it.page[1:]
+	if !it.until.IsZero() && it.ts(it.item).Before(it.until) {
+		return it.stop(nil)
+	}
+	return true
+}
+
+// Item returns the current record
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// fresh drops the records of page already returned and moves the ts cursor to the millisecond of its last record.
+// Nothing is left of a page short of the limit once the records of its millisecond have all been returned.
+func (it *Iterator[T]) fresh(page []T) ([]T, error) {
+	var res []T
+	for _, item := range page {
+		if !it.seen[it.id(item)] {
+			res = append(res, item)
+		}
+	}
+	last := it.ts(page[len(page)-1]).UnixMilli()
+	switch {
+	case len(res) == 0 && len(page) < it.limit:
+		return nil, nil
+	case len(res) == 0:
+		return nil, fmt.Errorf("%w: %d", ErrFullMillisecond, last)
+	}
+	if last != it.edge || it.seen == nil {
+		it.edge, it.seen = last, make(map[string]bool)
+	}
+	for _, item := range res {
+		if it.ts(item).UnixMilli() == last {
+			it.seen[it.id(item)] = true
+		}
+	}
+	it.after = strconv.FormatInt(last+1, 10)
+	return res, nil
+}
+
+func (it *Iterator[T]) stop(err error) bool {
+	it.done = true
+	it.err = err
+	it.page = nil
+	return false
+}
+
+func msCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func intCursor(after string) int64 {
+	n, _ := strconv.ParseInt(after, 10, 64)
+	return n
+}
+
+func afterCursor(after int64) string {
+	if after == 0 {
+		return ""
+	}
+	return strconv.FormatInt(after, 10)
+}
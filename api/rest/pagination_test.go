@@ -0,0 +1,117 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/okextest"
+	requests "github.com/dimkus/okex/requests/rest/funding"
+)
+
+// tsPages serves bills from the most recent to the oldest, the records strictly older than the after ms cursor
+func tsPages(bills [][2]string) okextest.Handler {
+	return func(r *okextest.Request) (int, any) {
+		limit, _ := strconv.Atoi(r.Query.Get("limit"))
+		after, _ := strconv.ParseInt(r.Query.Get("after"), 10, 64)
+		var page []any
+		for _, b := range bills {
+			ts, _ := strconv.ParseInt(b[1], 10, 64)
+			if after != 0 && ts >= after {
+				continue
+			}
+			if len(page) == limit {
+				break
+			}
+			page = append(page, map[string]string{"billId": b[0], "ts": b[1], "ccy": "USDT"})
+		}
+		return okextest.OK(page...)(r)
+	}
+}
+
+func TestTsIteratorKeepsRecordsSharingTheBoundary(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(http.MethodGet, "/api/v5/asset/bills", tsPages([][2]string{
+		{"1", "1700000000006"},
+		{"2", "1700000000005"},
+		{"3", "1700000000004"},
+		{"4", "1700000000004"},
+		{"5", "1700000000003"},
+		{"6", "1700000000002"},
+		{"7", "1700000000002"},
+		{"8", "1700000000001"},
+	}))
+
+	// every millisecond holds fewer records than a page, the first page ends between the two of 1700000000004
+	it := c.Rest.Funding.AssetBillsDetailsIterator(ctx, requests.AssetBillsDetails{Limit: 3})
+	var got []string
+	for it.Next() {
+		got = append(got, it.Item().BillID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTsIteratorStopsOnAFullPageOfOneMillisecond(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(http.MethodGet, "/api/v5/asset/bills", tsPages([][2]string{
+		{"1", "1700000000004"},
+		{"2", "1700000000004"},
+		{"3", "1700000000004"},
+		{"4", "1700000000003"},
+	}))
+
+	// the third record of the millisecond can't be reached with a page of two, it must not be skipped silently
+	it := c.Rest.Funding.AssetBillsDetailsIterator(ctx, requests.AssetBillsDetails{Limit: 2})
+	var got []string
+	for it.Next() {
+		got = append(got, it.Item().BillID)
+	}
+	if err := it.Err(); !errors.Is(err, rest.ErrFullMillisecond) {
+		t.Fatalf("got %v, want ErrFullMillisecond", err)
+	}
+	if len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+
+	// a page reaching past the millisecond gets the whole of it
+	it = c.Rest.Funding.AssetBillsDetailsIterator(ctx, requests.AssetBillsDetails{Limit: 4})
+	got = nil
+	for it.Next() {
+		got = append(got, it.Item().BillID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %v, want [1 2 3 4]", got)
+	}
+}
@@ -3,7 +3,6 @@ package rest
 import (
 	"context"
 	"errors"
-	"github.com/dimkus/okex"
 	requests "github.com/dimkus/okex/requests/rest/public"
 	responses "github.com/dimkus/okex/responses/public_data"
 	"net/http"
@@ -28,14 +27,13 @@ func NewPublicData(c *ClientRest) *PublicData {
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-instruments
 func (c *PublicData) GetInstruments(ctx context.Context, req requests.GetInstruments) (response responses.GetInstruments, err error) {
 	p := "/api/v5/public/instruments"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -45,14 +43,13 @@ func (c *PublicData) GetInstruments(ctx context.Context, req requests.GetInstrum
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-instruments
 func (c *PublicData) GetDeliveryExerciseHistory(ctx context.Context, req requests.GetDeliveryExerciseHistory) (response responses.GetDeliveryExerciseHistory, err error) {
 	p := "/api/v5/public/delivery-exercise-history"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -62,14 +59,13 @@ func (c *PublicData) GetDeliveryExerciseHistory(ctx context.Context, req request
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-open-interest
 func (c *PublicData) GetOpenInterest(ctx context.Context, req requests.GetOpenInterest) (response responses.GetOpenInterest, err error) {
 	p := "/api/v5/public/open-interest"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -79,14 +75,13 @@ func (c *PublicData) GetOpenInterest(ctx context.Context, req requests.GetOpenIn
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-limit-price
 func (c *PublicData) GetLimitPrice(ctx context.Context, req requests.GetLimitPrice) (response responses.GetLimitPrice, err error) {
 	p := "/api/v5/public/price-limit"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -96,14 +91,13 @@ func (c *PublicData) GetLimitPrice(ctx context.Context, req requests.GetLimitPri
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-option-market-data
 func (c *PublicData) GetOptionMarketData(ctx context.Context, req requests.GetOptionMarketData) (response responses.GetOptionMarketData, err error) {
 	p := "/api/v5/public/opt-summary"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -113,14 +107,13 @@ func (c *PublicData) GetOptionMarketData(ctx context.Context, req requests.GetOp
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-estimated-delivery-Exercise-price
 func (c *PublicData) GetEstimatedDeliveryExercisePrice(ctx context.Context, req requests.GetEstimatedDeliveryExercisePrice) (response responses.GetEstimatedDeliveryExercisePrice, err error) {
 	p := "/api/v5/public/estimated-price"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -130,14 +123,13 @@ func (c *PublicData) GetEstimatedDeliveryExercisePrice(ctx context.Context, req
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-discount-rate-and-interest-free-quota
 func (c *PublicData) GetDiscountRateAndInterestFreeQuota(ctx context.Context, req requests.GetDiscountRateAndInterestFreeQuota) (response responses.GetDiscountRateAndInterestFreeQuota, err error) {
 	p := "/api/v5/public/discount-rate-interest-free-quota"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -153,7 +145,7 @@ func (c *PublicData) GetSystemTime(ctx context.Context) (response responses.GetS
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -178,14 +170,13 @@ func (c *PublicData) SetSystemTime(ctx context.Context) (timeDiff time.Duration,
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-liquidation-orders
 func (c *PublicData) GetLiquidationOrders(ctx context.Context, req requests.GetLiquidationOrders) (response responses.GetLiquidationOrders, err error) {
 	p := "/api/v5/public/liquidation-orders"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -197,14 +188,13 @@ func (c *PublicData) GetLiquidationOrders(ctx context.Context, req requests.GetL
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-mark-price
 func (c *PublicData) GetMarkPrice(ctx context.Context, req requests.GetMarkPrice) (response responses.GetMarkPrice, err error) {
 	p := "/api/v5/public/mark-price"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -214,14 +204,13 @@ func (c *PublicData) GetMarkPrice(ctx context.Context, req requests.GetMarkPrice
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-position-tiers
 func (c *PublicData) GetPositionTiers(ctx context.Context, req requests.GetPositionTiers) (response responses.GetPositionTiers, err error) {
 	p := "/api/v5/public/position-tiers"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -237,7 +226,7 @@ func (c *PublicData) GetInterestRateAndLoanQuota(ctx context.Context) (response
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -246,13 +235,12 @@ func (c *PublicData) GetInterestRateAndLoanQuota(ctx context.Context) (response
 // https://www.okex.com/docs-v5/en/#rest-api-public-data-get-underlying
 func (c *PublicData) GetUnderlying(ctx context.Context, req requests.GetUnderlying) (response responses.GetUnderlying, err error) {
 	p := "/api/v5/public/underlying"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
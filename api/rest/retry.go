@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/dimkus/okex"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides which failed requests are repeated and how often
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// Budget is the maximum number of retries per BudgetWindow shared by all requests, zero means unlimited
+	Budget       int
+	BudgetWindow time.Duration
+	// RetryableCodes are the error codes that are worth another attempt
+	RetryableCodes map[int]bool
+	// SafePaths are the non GET endpoints that can be repeated without side effects
+	SafePaths map[string]bool
+
+	spent       int
+	windowStart time.Time
+	mu          sync.Mutex
+}
+
+// NewRetryPolicy returns a pointer to a RetryPolicy with sane defaults
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:  3,
+		MinBackoff:   200 * time.Millisecond,
+		MaxBackoff:   5 * time.Second,
+		Budget:       20,
+		BudgetWindow: time.Minute,
+		RetryableCodes: map[int]bool{
+			50001: true, // Service temporarily unavailable
+			50004: true, // Endpoint request timeout
+			50011: true, // Rate limit reached
+			50013: true, // Systems are busy
+			50026: true, // System error
+			50040: true, // Too frequent operations
+		},
+		SafePaths: map[string]bool{
+			"/api/v5/account/set-leverage":      true,
+			"/api/v5/account/set-position-mode": true,
+			"/api/v5/account/set-greeks":        true,
+		},
+	}
+}
+
+// Retryable reports whether the request failed with a transient error
+func (p *RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *okex.APIError
+	if !errors.As(err, &apiErr) {
+		// transport errors such as connection resets
+		return true
+	}
+	if p.RetryableCodes[apiErr.Code] {
+		return true
+	}
+	if apiErr.Code == 0 && (apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= http.StatusInternalServerError) {
+		return true
+	}
+	if len(apiErr.SubErrors) == 0 {
+		return false
+	}
+	for _, s := range apiErr.SubErrors {
+		if !p.RetryableCodes[s.Code] {
+			return false
+		}
+	}
+	return true
+}
+
+// safe reports whether the request can be repeated blindly
+func (p *RetryPolicy) safe(method, path string) bool {
+	return method == http.MethodGet || p.SafePaths[path]
+}
+
+// wait sleeps before the given retry attempt, it returns false if no more attempts are allowed
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	if attempt >= p.MaxAttempts || !p.spend() {
+		return false
+	}
+	d := p.MaxBackoff
+	if attempt < 16 {
+		d = min(p.MinBackoff<<(attempt-1), p.MaxBackoff)
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *RetryPolicy) spend() bool {
+	if p.Budget <= 0 {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now := time.Now(); now.Sub(p.windowStart) > p.BudgetWindow {
+		p.windowStart = now
+		p.spent = 0
+	}
+	if p.spent >= p.Budget {
+		return false
+	}
+	p.spent++
+	return true
+}
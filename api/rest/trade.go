@@ -3,9 +3,11 @@ package rest
 import (
 	"context"
 	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/trade"
 	requests "github.com/dimkus/okex/requests/rest/trade"
 	responses "github.com/dimkus/okex/responses/trade"
 	"net/http"
+	"time"
 )
 
 // Trade
@@ -15,6 +17,24 @@ type Trade struct {
 	client *ClientRest
 }
 
+// TradeI is the order surface of Trade, which paper.Trade implements as well
+type TradeI interface {
+	PlaceOrder(ctx context.Context, req []requests.PlaceOrder) (responses.PlaceOrder, error)
+	PlaceMultipleOrders(ctx context.Context, req []requests.PlaceOrder) (responses.PlaceOrder, error)
+	CandleOrder(ctx context.Context, req []requests.CancelOrder) (responses.PlaceOrder, error)
+	AmendOrder(ctx context.Context, req []requests.AmendOrder) (responses.AmendOrder, error)
+	ClosePosition(ctx context.Context, req requests.ClosePosition) (responses.ClosePosition, error)
+	GetOrderDetail(ctx context.Context, req requests.OrderDetails) (responses.OrderList, error)
+	GetOrderList(ctx context.Context, req requests.OrderList) (responses.OrderList, error)
+	GetOrderHistory(ctx context.Context, req requests.OrderList, arch bool) (responses.OrderList, error)
+	GetTransactionDetails(ctx context.Context, req requests.TransactionDetails, arch bool) (responses.TransactionDetail, error)
+}
+
+// OrderValidator rejects orders that the server would reject anyway, such as orders below the minimum size
+type OrderValidator interface {
+	Validate(req requests.PlaceOrder) error
+}
+
 // NewTrade returns a pointer to a fresh Trade
 func NewTrade(c *ClientRest) *Trade {
 	return &Trade{c}
@@ -23,40 +43,92 @@ func NewTrade(c *ClientRest) *Trade {
 // PlaceOrder
 // You can place an order only if you have sufficient funds.
 //
+// When an order validator is set, the orders are checked locally and none is sent if any of them is rejected.
+// When a retry policy is set and every order has a ClOrdID, transient failures are retried.
+// Before each retry the orders are looked up by ClOrdID so an order that reached the server is never placed twice.
+//
 // https://www.okex.com/docs-v5/en/#rest-api-trade-get-positions
 func (c *Trade) PlaceOrder(ctx context.Context, req []requests.PlaceOrder) (response responses.PlaceOrder, err error) {
+	if err = c.validate(req); err != nil {
+		return
+	}
+	response, err = c.placeOrder(ctx, req)
+	policy := c.client.retry
+	if policy == nil || !withClOrdID(req) {
+		return
+	}
+	// placed are the orders found on the server by the lookups of all the rounds so far
+	var placed []*trade.PlaceOrder
+	for attempt := 1; policy.Retryable(err) && policy.wait(ctx, attempt); attempt++ {
+		var pending []requests.PlaceOrder
+		for _, order := range req {
+			d, dErr := c.GetOrderDetail(ctx, requests.OrderDetails{InstID: order.InstID, ClOrdID: order.ClOrdID})
+			switch {
+			case dErr == nil && len(d.Orders) > 0:
+				placed = append(placed, &trade.PlaceOrder{ClOrdID: order.ClOrdID, Tag: order.Tag, OrdID: d.Orders[0].OrdID})
+			case dErr == nil || okex.IsOrderNotFound(dErr):
+				pending = append(pending, order)
+			default:
+				// the state of the order is unknown, placing it again could duplicate it
+				return responses.PlaceOrder{PlaceOrders: placed}, err
+			}
+		}
+		req = pending
+		if len(req) == 0 {
+			return responses.PlaceOrder{PlaceOrders: placed}, nil
+		}
+		response, err = c.placeOrder(ctx, req)
+		response.PlaceOrders = append(append([]*trade.PlaceOrder(nil), placed...), response.PlaceOrders...)
+	}
+	return
+}
+
+func (c *Trade) validate(req []requests.PlaceOrder) error {
+	if c.client.validator == nil {
+		return nil
+	}
+	for _, order := range req {
+		if err := c.client.validator.Validate(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Trade) placeOrder(ctx context.Context, req []requests.PlaceOrder) (response responses.PlaceOrder, err error) {
 	p := "/api/v5/trade/order"
 	var tmp interface{}
 	tmp = req[0]
 	if len(req) > 1 {
 		tmp = req
-		p = "/api/trade/batch-orders"
+		p = "/api/v5/trade/batch-orders"
 	}
-	m := okex.S2M(tmp)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, tmp)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
 // PlaceMultipleOrders
-// Cancel an incomplete order.
+// Place orders in batches. Maximum 20 orders can be placed at a time.
 //
 // https://www.okex.com/docs-v5/en/#rest-api-trade-place-multiple-orders
 func (c *Trade) PlaceMultipleOrders(ctx context.Context, req []requests.PlaceOrder) (response responses.PlaceOrder, err error) {
-	p := "/api/v5/trade/batch-order"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	if err = c.validate(req); err != nil {
+		return
+	}
+	p := "/api/v5/trade/batch-orders"
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -74,16 +146,15 @@ func (c *Trade) CandleOrder(ctx context.Context, req []requests.CancelOrder) (re
 	tmp = req[0]
 	if len(req) > 1 {
 		tmp = req
-		p = "/api/trade/cancel-batch-orders"
+		p = "/api/v5/trade/cancel-batch-orders"
 	}
-	m := okex.S2M(tmp)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, tmp)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -95,22 +166,21 @@ func (c *Trade) CandleOrder(ctx context.Context, req []requests.CancelOrder) (re
 // Amend incomplete orders in batches. Maximum 20 orders can be amended at a time. Request parameters should be passed in the form of an array.
 //
 // https://www.okex.com/docs-v5/en/#rest-api-trade-amend-multiple-orders
-func (c *Trade) AmendOrder(ctx context.Context, req []requests.OrderList) (response responses.AmendOrder, err error) {
+func (c *Trade) AmendOrder(ctx context.Context, req []requests.AmendOrder) (response responses.AmendOrder, err error) {
 	p := "/api/v5/trade/amend-order"
 	var tmp interface{}
 	tmp = req[0]
 	if len(req) > 1 {
 		tmp = req
-		p = "/api/trade/amend-batch-orders"
+		p = "/api/v5/trade/amend-batch-orders"
 	}
-	m := okex.S2M(tmp)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, tmp)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -120,14 +190,63 @@ func (c *Trade) AmendOrder(ctx context.Context, req []requests.OrderList) (respo
 // https://www.okex.com/docs-v5/en/#rest-api-trade-close-positions
 func (c *Trade) ClosePosition(ctx context.Context, req requests.ClosePosition) (response responses.ClosePosition, err error) {
 	p := "/api/v5/trade/close-position"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	err = c.client.decode(res, &response)
+	return
+}
+
+// MassCancel
+// Cancel all the MMP pending orders of an instrument family. Only applicable to Option in Portfolio Margin mode, and MMP privilege is required.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-post-mass-cancel-order
+func (c *Trade) MassCancel(ctx context.Context, req requests.MassCancel) (response responses.MassCancel, err error) {
+	p := "/api/v5/trade/mass-cancel"
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
+	return
+}
+
+// OrderPrecheck
+// Check the account information before and after placing a potential order, such as the margin ratio and the
+// liquidation price. Nothing is placed.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-post-order-precheck
+func (c *Trade) OrderPrecheck(ctx context.Context, req requests.OrderPrecheck) (response responses.OrderPrecheck, err error) {
+	p := "/api/v5/trade/order-precheck"
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	err = c.client.decode(res, &response)
+	return
+}
+
+// CancelAllAfter
+// Cancel all pending orders after the countdown timeout, unless the countdown is set again before it elapses.
+// A TimeOut of 0 disables the countdown.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-post-cancel-all-after
+func (c *Trade) CancelAllAfter(ctx context.Context, req requests.CancelAllAfter) (response responses.CancelAllAfter, err error) {
+	p := "/api/v5/trade/cancel-all-after"
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -137,14 +256,13 @@ func (c *Trade) ClosePosition(ctx context.Context, req requests.ClosePosition) (
 // https://www.okex.com/docs-v5/en/#rest-api-trade-get-order-details
 func (c *Trade) GetOrderDetail(ctx context.Context, req requests.OrderDetails) (response responses.OrderList, err error) {
 	p := "/api/v5/trade/order"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -154,14 +272,13 @@ func (c *Trade) GetOrderDetail(ctx context.Context, req requests.OrderDetails) (
 // https://www.okex.com/docs-v5/en/#rest-api-trade-get-order-list
 func (c *Trade) GetOrderList(ctx context.Context, req requests.OrderList) (response responses.OrderList, err error) {
 	p := "/api/v5/trade/orders-pending"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -175,16 +292,15 @@ func (c *Trade) GetOrderList(ctx context.Context, req requests.OrderList) (respo
 func (c *Trade) GetOrderHistory(ctx context.Context, req requests.OrderList, arch bool) (response responses.OrderList, err error) {
 	p := "/api/v5/trade/orders-history"
 	if arch {
-		p = "/api/trade/orders-history-archive"
+		p = "/api/v5/trade/orders-history-archive"
 	}
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -199,19 +315,27 @@ func (c *Trade) GetOrderHistory(ctx context.Context, req requests.OrderList, arc
 func (c *Trade) GetTransactionDetails(ctx context.Context, req requests.TransactionDetails, arch bool) (response responses.TransactionDetail, err error) {
 	p := "/api/v5/trade/fills"
 	if arch {
-		p = "/api/trade/fills-history"
+		p = "/api/v5/trade/fills-history"
 	}
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
+// GetFillsHistory
+// Retrieve recently-filled transaction details in the last 3 months, InstType is required.
+// It is GetTransactionDetails with arch set.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-get-transaction-details-last-3-months
+func (c *Trade) GetFillsHistory(ctx context.Context, req requests.TransactionDetails) (response responses.TransactionDetail, err error) {
+	return c.GetTransactionDetails(ctx, req, true)
+}
+
 // PlaceAlgoOrder
 // The algo order includes trigger order, oco order, conditional order,iceberg order and twap order.
 //
@@ -220,14 +344,13 @@ func (c *Trade) GetTransactionDetails(ctx context.Context, req requests.Transact
 // https://www.okex.com/docs-v5/en/#rest-api-trade-place-algo-order
 func (c *Trade) PlaceAlgoOrder(ctx context.Context, req requests.PlaceAlgoOrder) (response responses.PlaceAlgoOrder, err error) {
 	p := "/api/v5/trade/order-algo"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -237,14 +360,13 @@ func (c *Trade) PlaceAlgoOrder(ctx context.Context, req requests.PlaceAlgoOrder)
 // https://www.okex.com/docs-v5/en/#rest-api-trade-cancel-algo-order
 func (c *Trade) CancelAlgoOrder(ctx context.Context, req requests.CancelAlgoOrder) (response responses.CancelAlgoOrder, err error) {
 	p := "/api/v5/trade/cancel-algos"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -256,14 +378,13 @@ func (c *Trade) CancelAlgoOrder(ctx context.Context, req requests.CancelAlgoOrde
 // https://www.okex.com/docs-v5/en/#rest-api-trade-cancel-advance-algo-order
 func (c *Trade) CancelAdvanceAlgoOrder(ctx context.Context, req requests.CancelAlgoOrder) (response responses.CancelAlgoOrder, err error) {
 	p := "/api/v5/trade/cancel-advance-algos"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodPost, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodPost, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -282,15 +403,49 @@ func (c *Trade) CancelAdvanceAlgoOrder(ctx context.Context, req requests.CancelA
 func (c *Trade) GetAlgoOrderList(ctx context.Context, req requests.AlgoOrderList, arch bool) (response responses.AlgoOrderList, err error) {
 	p := "/api/v5/trade/orders-algo-pending"
 	if arch {
-		p = "/api/trade/orders-algo-history"
+		p = "/api/v5/trade/orders-algo-history"
 	}
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, true, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, true, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
+
+func withClOrdID(req []requests.PlaceOrder) bool {
+	for _, order := range req {
+		if order.ClOrdID == "" {
+			return false
+		}
+	}
+	return len(req) > 0
+}
+
+// OrderHistoryIterator walks GetOrderHistory pages following the ordId cursor
+func (c *Trade) OrderHistoryIterator(ctx context.Context, req requests.OrderList, arch bool) *Iterator[*trade.Order] {
+	return newIterator(ctx, req.After, func(ctx context.Context, after string) ([]*trade.Order, error) {
+		req.After = after
+		res, err := c.GetOrderHistory(ctx, req, arch)
+		return res.Orders, err
+	}, func(o *trade.Order) string {
+		return o.OrdID
+	}, func(o *trade.Order) time.Time {
+		return time.Time(o.CTime)
+	})
+}
+
+// TransactionDetailsIterator walks GetTransactionDetails pages following the billId cursor
+func (c *Trade) TransactionDetailsIterator(ctx context.Context, req requests.TransactionDetails, arch bool) *Iterator[*trade.TransactionDetail] {
+	return newIterator(ctx, req.After, func(ctx context.Context, after string) ([]*trade.TransactionDetail, error) {
+		req.After = after
+		res, err := c.GetTransactionDetails(ctx, req, arch)
+		return res.TransactionDetails, err
+	}, func(d *trade.TransactionDetail) string {
+		return d.BillID
+	}, func(d *trade.TransactionDetail) time.Time {
+		return time.Time(d.TS)
+	})
+}
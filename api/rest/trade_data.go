@@ -2,7 +2,6 @@ package rest
 
 import (
 	"context"
-	"github.com/dimkus/okex"
 	requests "github.com/dimkus/okex/requests/rest/tradedata"
 	responses "github.com/dimkus/okex/responses/trade_data"
 	"net/http"
@@ -32,7 +31,7 @@ func (c *TradeData) GetSupportCoin(ctx context.Context) (response responses.GetS
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -42,14 +41,13 @@ func (c *TradeData) GetSupportCoin(ctx context.Context) (response responses.GetS
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-support-coin
 func (c *TradeData) GetTakerVolume(ctx context.Context, req requests.GetTakerVolume) (response responses.GetTakerVolume, err error) {
 	p := "/api/v5/rubik/stat/taker-volume"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -59,14 +57,13 @@ func (c *TradeData) GetTakerVolume(ctx context.Context, req requests.GetTakerVol
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-margin-lending-ratio
 func (c *TradeData) GetMarginLendingRatio(ctx context.Context, req requests.GetRatio) (response responses.GetRatio, err error) {
 	p := "/api/v5/rubik/stat/margin/loan-ratio"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -76,14 +73,13 @@ func (c *TradeData) GetMarginLendingRatio(ctx context.Context, req requests.GetR
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-long-short-ratio
 func (c *TradeData) GetLongShortRatio(ctx context.Context, req requests.GetRatio) (response responses.GetRatio, err error) {
 	p := "/api/v5/rubik/stat/contracts/long-short-account-ratio"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -93,14 +89,13 @@ func (c *TradeData) GetLongShortRatio(ctx context.Context, req requests.GetRatio
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-contracts-open-interest-and-volume
 func (c *TradeData) GetContractsOpenInterestAndVolume(ctx context.Context, req requests.GetRatio) (response responses.GetOpenInterestAndVolume, err error) {
 	p := "/api/v5/rubik/stat/contracts/open-interest-volume"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -110,14 +105,13 @@ func (c *TradeData) GetContractsOpenInterestAndVolume(ctx context.Context, req r
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-options-open-interest-and-volume
 func (c *TradeData) GetOptionsOpenInterestAndVolume(ctx context.Context, req requests.GetRatio) (response responses.GetOpenInterestAndVolume, err error) {
 	p := "/api/v5/rubik/stat/option/open-interest-volume"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -127,14 +121,13 @@ func (c *TradeData) GetOptionsOpenInterestAndVolume(ctx context.Context, req req
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-put-call-ratio
 func (c *TradeData) GetPutCallRatio(ctx context.Context, req requests.GetRatio) (response responses.GetPutCallRatio, err error) {
 	p := "/api/v5/rubik/stat/option/open-interest-volume-ratio"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -144,14 +137,13 @@ func (c *TradeData) GetPutCallRatio(ctx context.Context, req requests.GetRatio)
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-open-interest-and-volume-expiry
 func (c *TradeData) GetOpenInterestAndVolumeExpiry(ctx context.Context, req requests.GetRatio) (response responses.GetOpenInterestAndVolumeExpiry, err error) {
 	p := "/api/v5/rubik/stat/option/open-interest-volume-expiry"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -161,14 +153,13 @@ func (c *TradeData) GetOpenInterestAndVolumeExpiry(ctx context.Context, req requ
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-open-interest-and-volume-strike
 func (c *TradeData) GetOpenInterestAndVolumeStrike(ctx context.Context, req requests.GetOpenInterestAndVolumeStrike) (response responses.GetOpenInterestAndVolumeStrike, err error) {
 	p := "/api/v5/rubik/stat/option/open-interest-volume-strike"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
 
@@ -178,13 +169,12 @@ func (c *TradeData) GetOpenInterestAndVolumeStrike(ctx context.Context, req requ
 // https://www.okex.com/docs-v5/en/#rest-api-trading-data-get-taker-flow
 func (c *TradeData) GetTakerFlow(ctx context.Context, req requests.GetRatio) (response responses.GetTakerFlow, err error) {
 	p := "/api/v5/rubik/stat/option/taker-block-volume"
-	m := okex.S2M(req)
-	res, err := c.client.Do(ctx, http.MethodGet, p, false, m)
+	res, err := c.client.Do(ctx, http.MethodGet, p, false, req)
 	if err != nil {
 		return
 	}
 	defer res.Body.Close()
 
-	err = c.client.decode(res.Body, &response)
+	err = c.client.decode(res, &response)
 	return
 }
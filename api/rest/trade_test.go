@@ -0,0 +1,160 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/okextest"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+)
+
+func retryPolicy() *rest.RetryPolicy {
+	p := rest.NewRetryPolicy()
+	p.MaxAttempts = 5
+	p.MinBackoff, p.MaxBackoff = time.Millisecond, time.Millisecond
+	return p
+}
+
+// orderLookup answers the order details by ClOrdID, the orders of live are found and the others are not
+func orderLookup(live map[string]string) okextest.Handler {
+	return func(r *okextest.Request) (int, any) {
+		clOrdID := r.Query.Get("clOrdId")
+		ordID, ok := live[clOrdID]
+		if !ok {
+			return okextest.Fail(51603, "Order does not exist")(r)
+		}
+		return okextest.OK(map[string]string{"instId": r.Query.Get("instId"), "clOrdId": clOrdID, "ordId": ordID, "state": "live"})(r)
+	}
+}
+
+func TestPlaceOrderKeepsOrdersFoundInEarlierRounds(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Rest.WithRetryPolicy(retryPolicy())
+
+	// the batch reaches the server for A only, then B fails once more on its own before going through
+	s.FailNext(http.MethodPost, "/api/v5/trade/batch-orders", 50001, "Service temporarily unavailable", 1)
+	s.FailNext(http.MethodPost, "/api/v5/trade/order", 50001, "Service temporarily unavailable", 1)
+	s.Handle(http.MethodGet, "/api/v5/trade/order", orderLookup(map[string]string{"a": "1"}))
+	s.Handle(http.MethodPost, "/api/v5/trade/order", okextest.OK(map[string]string{"clOrdId": "b", "ordId": "2", "sCode": "0"}))
+
+	res, err := c.Rest.Trade.PlaceOrder(ctx, []requests.PlaceOrder{
+		{InstID: "BTC-USDT", ClOrdID: "a", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+		{InstID: "BTC-USDT", ClOrdID: "b", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range res.PlaceOrders {
+		got = append(got, o.ClOrdID+"="+o.OrdID)
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "a=1" || got[1] != "b=2" {
+		t.Fatalf("got %v, want [a=1 b=2]", got)
+	}
+}
+
+func TestPlaceOrderReturnsFoundOrdersWhenLookupFails(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Rest.WithRetryPolicy(retryPolicy())
+
+	s.FailNext(http.MethodPost, "/api/v5/trade/batch-orders", 50001, "Service temporarily unavailable", 1)
+	lookup := orderLookup(map[string]string{"a": "1"})
+	s.Handle(http.MethodGet, "/api/v5/trade/order",
+		lookup,
+		// the state of B can't be told, it must not be placed again
+		okextest.Fail(50011, "Rate limit reached"),
+	)
+
+	res, err := c.Rest.Trade.PlaceOrder(ctx, []requests.PlaceOrder{
+		{InstID: "BTC-USDT", ClOrdID: "a", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+		{InstID: "BTC-USDT", ClOrdID: "b", Sz: "1", Px: "100", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit},
+	})
+	if !okex.IsSystemBusy(err) {
+		t.Fatalf("got %v, want the placement error", err)
+	}
+	if len(res.PlaceOrders) != 1 || res.PlaceOrders[0].ClOrdID != "a" {
+		t.Fatalf("got %+v, want A", res.PlaceOrders)
+	}
+	for _, r := range s.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/api/v5/trade/order" {
+			t.Fatal("B was placed again while its state was unknown")
+		}
+	}
+}
+
+func TestMassCancelAndOrderPrecheck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(http.MethodPost, "/api/v5/trade/mass-cancel", okextest.OK(map[string]bool{"result": true}))
+	s.Handle(http.MethodPost, "/api/v5/trade/order-precheck", okextest.OK(map[string]string{"adjEq": "1000", "adjEqChg": "-5", "liqPx": "90"}))
+	s.Handle(http.MethodGet, "/api/v5/trade/fills-history", okextest.OK(map[string]string{"instId": "BTC-USDT", "tradeId": "3"}))
+
+	mc, err := c.Rest.Trade.MassCancel(ctx, requests.MassCancel{InstType: okex.OptionsInstrument, InstFamily: "BTC-USD", LockInterval: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mc.MassCancels) != 1 || !mc.MassCancels[0].Result {
+		t.Fatalf("got %+v", mc.MassCancels)
+	}
+	pc, err := c.Rest.Trade.OrderPrecheck(ctx, requests.OrderPrecheck{InstID: "BTC-USDT-SWAP", TdMode: okex.TradeCrossMode, Side: okex.OrderBuy,
+		OrdType: okex.OrderLimit, Sz: "1", Px: "100", ReduceOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pc.OrderPrechecks) != 1 || pc.OrderPrechecks[0].AdjEqChg != -5 || pc.OrderPrechecks[0].LiqPx != 90 {
+		t.Fatalf("got %+v", pc.OrderPrechecks)
+	}
+	fills, err := c.Rest.Trade.GetFillsHistory(ctx, requests.TransactionDetails{InstType: okex.SpotInstrument, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills.TransactionDetails) != 1 || fills.TransactionDetails[0].TradeID != "3" {
+		t.Fatalf("got %+v", fills.TransactionDetails)
+	}
+
+	reqs := s.Requests()
+	want := []string{
+		`{"instType":"OPTION","instFamily":"BTC-USD","lockInterval":"5000"}`,
+		`{"instId":"BTC-USDT-SWAP","tdMode":"cross","side":"buy","ordType":"limit","sz":"1","px":"100","reduceOnly":true}`,
+	}
+	for i, body := range want {
+		if got := string(reqs[i].Body); got != body {
+			t.Errorf("%s: got %s, want %s", reqs[i].Path, got, body)
+		}
+	}
+	if q := reqs[2].Query; q.Get("instType") != "SPOT" || q.Get("limit") != "10" {
+		t.Fatalf("got the query %v", q)
+	}
+
+	// the rejections are classified like the ones of any other endpoint
+	s.FailNext(http.MethodPost, "/api/v5/trade/mass-cancel", 50011, "Rate limit reached", 1)
+	if _, err := c.Rest.Trade.MassCancel(ctx, requests.MassCancel{InstType: okex.OptionsInstrument, InstFamily: "BTC-USD"}); !okex.IsRateLimited(err) {
+		t.Fatalf("got %v, want the rate limit", err)
+	}
+}
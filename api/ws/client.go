@@ -8,16 +8,26 @@ import (
 	"fmt"
 	"github.com/dimkus/okex"
 	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/ratelimit"
 	"github.com/goccy/go-json"
 	"github.com/gorilla/websocket"
 	"io"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // ClientWs is the websocket api client
 //
+// The client talks to the public, the private and the business endpoints of OKX, the subscriptions go to the endpoint
+// serving their channel, see EndpointOf. Each endpoint has a pool of one connection by default, see SetPoolSize to
+// spread large subscription sets across more connections. The events of every connection are delivered through the
+// same channels, subscriptions and handlers.
+//
 // https://www.okex.com/docs-v5/en/#websocket-api
 type ClientWs struct {
 	Cancel        context.CancelFunc
@@ -27,46 +37,57 @@ type ClientWs struct {
 	UnsubscribeCh chan *events.Unsubscribe
 	LoginChan     chan *events.Login
 	SuccessChan   chan *events.Success
-	sendChan      map[bool]chan []byte
-	url           map[bool]okex.BaseURL
-	conn          map[bool]*websocket.Conn
+	StatusChan    chan *events.Status
+	conns         map[Endpoint][]*connection
+	poolMu        sync.Mutex
+	routes        map[*route]struct{}
+	refs          map[bool]map[string]int
+	policies      map[string]backpressure
+	handlers      *handlers
+	routeMu       sync.RWMutex
+	url           map[Endpoint]okex.BaseURL
 	dialer        *websocket.Dialer
+	limiter       *ratelimit.Registry
 	apiKey        string
 	secretKey     []byte
 	passphrase    string
-	lastTransmit  map[bool]*time.Time
-	mu            map[bool]*sync.RWMutex
-	AuthRequested *time.Time
-	Authorized    bool
 	Private       *Private
 	Public        *Public
 	Trade         *Trade
 	ctx           context.Context
+	dropped       uint64
 }
 
 const (
-	redialTick = 2 * time.Second
-	writeWait  = 3 * time.Second
-	pongWait   = 30 * time.Second
-	PingPeriod = (pongWait * 8) / 10
+	redialTick    = 2 * time.Second
+	loginWait     = 30 * time.Second
+	maxRedialTick = time.Minute
+	writeWait     = 3 * time.Second
+	pongWait      = 30 * time.Second
+	PingPeriod    = (pongWait * 8) / 10
 )
 
-// NewClient returns a pointer to a fresh ClientWs
-func NewClient(ctx context.Context, apiKey, secretKey, passphrase string, url map[bool]okex.BaseURL) *ClientWs {
+// NewClient returns a pointer to a fresh ClientWs, url holds the address of each endpoint
+func NewClient(ctx context.Context, apiKey, secretKey, passphrase string, url map[Endpoint]okex.BaseURL) *ClientWs {
 	ctx, cancel := context.WithCancel(ctx)
 	c := &ClientWs{
-		apiKey:       apiKey,
-		secretKey:    []byte(secretKey),
-		passphrase:   passphrase,
-		ctx:          ctx,
-		Cancel:       cancel,
-		url:          url,
-		sendChan:     map[bool]chan []byte{true: make(chan []byte, 3), false: make(chan []byte, 3)},
-		DoneChan:     make(chan interface{}),
-		conn:         make(map[bool]*websocket.Conn),
-		dialer:       websocket.DefaultDialer,
-		lastTransmit: make(map[bool]*time.Time),
-		mu:           map[bool]*sync.RWMutex{true: {}, false: {}},
+		apiKey:     apiKey,
+		secretKey:  []byte(secretKey),
+		passphrase: passphrase,
+		ctx:        ctx,
+		Cancel:     cancel,
+		url:        url,
+		conns:      make(map[Endpoint][]*connection),
+		routes:     make(map[*route]struct{}),
+		refs:       map[bool]map[string]int{true: {}, false: {}},
+		policies:   make(map[string]backpressure),
+		handlers:   newHandlers(ctx),
+		DoneChan:   make(chan interface{}),
+		dialer:     websocket.DefaultDialer,
+		limiter:    ratelimit.NewRegistry(ratelimit.PolicyWait),
+	}
+	for _, e := range []Endpoint{EndpointPublic, EndpointPrivate, EndpointBusiness} {
+		c.conns[e] = []*connection{newConnection(e, 0)}
 	}
 	c.Private = NewPrivate(c)
 	c.Public = NewPublic(c)
@@ -74,61 +95,37 @@ func NewClient(ctx context.Context, apiKey, secretKey, passphrase string, url ma
 	return c
 }
 
-// Connect into the server
+// Connect into the public or the private endpoint, every connection of its pool is dialed. The business endpoint is
+// dialed on its first subscription, see ConnectEndpoint to dial it ahead.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-connect
 func (c *ClientWs) Connect(p bool) error {
-	if c.conn[p] != nil {
-		return nil
-	}
-	err := c.dial(p)
-	if err == nil {
-		return nil
-	}
-	ticker := time.NewTicker(redialTick)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			err = c.dial(p)
-			if err == nil {
-				return nil
-			}
-		case <-c.ctx.Done():
-			return c.handleCancel("connect")
+	return c.ConnectEndpoint(side(p))
+}
+
+// ConnectEndpoint dials every connection of the pool of the endpoint
+func (c *ClientWs) ConnectEndpoint(e Endpoint) error {
+	for _, cn := range c.pool(e) {
+		if err := c.connect(cn); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-// Login
+// Login through the first private connection, the other ones log in when they are dialed
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-login
 func (c *ClientWs) Login() error {
-	if c.Authorized {
-		return nil
-	}
-	if c.AuthRequested != nil && time.Since(*c.AuthRequested).Seconds() < 30 {
-		return nil
-	}
-	now := time.Now()
-	c.AuthRequested = &now
-	method := http.MethodGet
-	path := "/users/self/verify"
-	ts, sign := c.sign(method, path)
-	args := []map[string]string{
-		{
-			"apiKey":     c.apiKey,
-			"passphrase": c.passphrase,
-			"timestamp":  ts,
-			"sign":       sign,
-		},
-	}
-	return c.Send(true, okex.LoginOperation, args)
+	return c.login(c.primary(EndpointPrivate))
 }
 
 // Subscribe
 // Users can choose to subscribe to one or more channels, and the total length of multiple channels cannot exceed 4096 bytes.
 //
+// p tells the public channels from the private ones, each argument goes to the endpoint serving its channel where it is
+// spread across the connections of the pool and sent in as many requests as the length limit requires.
+//
 // https://www.okex.com/docs-v5/en/#websocket-api-subscribe
 func (c *ClientWs) Subscribe(p bool, ch []okex.ChannelName, args ...map[string]string) error {
 	chCount := max(len(ch), 1)
@@ -147,8 +144,7 @@ func (c *ClientWs) Subscribe(p bool, ch []okex.ChannelName, args ...map[string]s
 			n++
 		}
 	}
-
-	return c.Send(p, okex.SubscribeOperation, tmpArgs)
+	return c.place(p, tmpArgs)
 }
 
 // Unsubscribe into channel(s)
@@ -163,43 +159,18 @@ func (c *ClientWs) Unsubscribe(p bool, ch []okex.ChannelName, args map[string]st
 			tmpArgs[i][k] = v
 		}
 	}
-	return c.Send(p, okex.UnsubscribeOperation, tmpArgs)
+	return c.unsubscribe(p, tmpArgs)
 }
 
-// Send message through either connections
+// Send message through the first connection of the public or the private endpoint
 func (c *ClientWs) Send(p bool, op okex.Operation, args []map[string]string, extras ...map[string]string) error {
-	if op != okex.LoginOperation {
-		err := c.Connect(p)
-		if err == nil {
-			if p {
-				err = c.WaitForAuthorization()
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			return err
-		}
-	}
-
-	data := map[string]interface{}{
-		"op":   op,
-		"args": args,
-	}
-	for _, extra := range extras {
-		for k, v := range extra {
-			data[k] = v
-		}
-	}
-	j, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	c.sendChan[p] <- j
-	return nil
+	return c.send(c.primary(side(p)), op, args, extras...)
 }
 
-// SetChannels to receive certain events on separate channel
+// SetChannels to receive certain events on separate channel.
+//
+// The receiver never waits on the channels, an event is dropped when its channel is full, see Dropped. Give them a
+// buffer and keep draining them.
 func (c *ClientWs) SetChannels(errCh chan *events.Error, subCh chan *events.Subscribe, unSub chan *events.Unsubscribe, lCh chan *events.Login, sCh chan *events.Success) {
 	c.ErrChan = errCh
 	c.SubscribeChan = subCh
@@ -208,6 +179,22 @@ func (c *ClientWs) SetChannels(errCh chan *events.Error, subCh chan *events.Subs
 	c.SuccessChan = sCh
 }
 
+// SetStatusChannel to receive connection lifecycle events such as reconnects and subscription replays, the events
+// are dropped while ch is full like the ones of SetChannels
+func (c *ClientWs) SetStatusChannel(ch chan *events.Status) {
+	c.StatusChan = ch
+}
+
+// Dropped returns the number of events dropped because their channel set by SetChannels or SetStatusChannel was full
+func (c *ClientWs) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// SetRateLimiter replaces the client side rate limiter of order operations, the same registry can be shared with rest.ClientRest
+func (c *ClientWs) SetRateLimiter(limiter *ratelimit.Registry) {
+	c.limiter = limiter
+}
+
 // SetDialer sets a custom dialer for the WebSocket connection.
 func (c *ClientWs) SetDialer(dialer *websocket.Dialer) {
 	c.dialer = dialer
@@ -215,25 +202,128 @@ func (c *ClientWs) SetDialer(dialer *websocket.Dialer) {
 
 // WaitForAuthorization waits for the auth response and try to log in if it was needed
 func (c *ClientWs) WaitForAuthorization() error {
-	if c.Authorized {
+	return c.waitForAuthorization(c.primary(EndpointPrivate))
+}
+
+// Authorized reports whether the first private connection is logged in
+func (c *ClientWs) Authorized() bool {
+	return c.primary(EndpointPrivate).isAuthorized()
+}
+
+func (c *ClientWs) connect(cn *connection) error {
+	cn.dialMu.Lock()
+	defer cn.dialMu.Unlock()
+	if cn.connected() {
+		return nil
+	}
+	err := c.dial(cn)
+	if err == nil {
+		return nil
+	}
+	ticker := time.NewTicker(redialTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err = c.dial(cn)
+			if err == nil {
+				return nil
+			}
+		case <-c.ctx.Done():
+			return c.handleCancel("connect")
+		}
+	}
+}
+
+func (c *ClientWs) login(cn *connection) error {
+	cn.mu.Lock()
+	if cn.authorized || (cn.authRequested != nil && time.Since(*cn.authRequested) < loginWait) {
+		cn.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	cn.authRequested = &now
+	cn.authErr = nil
+	cn.mu.Unlock()
+	method := http.MethodGet
+	path := "/users/self/verify"
+	ts, sign := c.sign(method, path)
+	args := []map[string]string{
+		{
+			"apiKey":     c.apiKey,
+			"passphrase": c.passphrase,
+			"timestamp":  ts,
+			"sign":       sign,
+		},
+	}
+	return c.send(cn, okex.LoginOperation, args)
+}
+
+// waitForAuthorization logs the connection in if needed and waits until the login is answered, a rejected login or one
+// left unanswered for loginWait is returned as an error
+func (c *ClientWs) waitForAuthorization(cn *connection) error {
+	if cn.isAuthorized() {
 		return nil
 	}
-	if err := c.Login(); err != nil {
+	if err := c.login(cn); err != nil {
 		return err
 	}
 	ticker := time.NewTicker(time.Millisecond * 300)
 	defer ticker.Stop()
-	for range ticker.C {
-		if c.Authorized {
+	for {
+		cn.mu.RLock()
+		authorized, err, requested := cn.authorized, cn.authErr, cn.authRequested
+		cn.mu.RUnlock()
+		switch {
+		case authorized:
 			return nil
+		case err != nil:
+			return err
+		case requested == nil || time.Since(*requested) > loginWait:
+			return fmt.Errorf("login of the %s connection %d was not answered", cn.endpoint, cn.index)
+		}
+		select {
+		case <-ticker.C:
+		case <-c.ctx.Done():
+			return c.handleCancel("login")
+		}
+	}
+}
+
+// send the message through the connection, dialing it and logging in first if its endpoint needs to
+func (c *ClientWs) send(cn *connection, op okex.Operation, args []map[string]string, extras ...map[string]string) error {
+	if op != okex.LoginOperation {
+		if err := c.connect(cn); err != nil {
+			return err
+		}
+		if c.auth(cn.endpoint) {
+			if err := c.waitForAuthorization(cn); err != nil {
+				return err
+			}
 		}
 	}
+
+	data := map[string]interface{}{
+		"op":   op,
+		"args": args,
+	}
+	for _, extra := range extras {
+		for k, v := range extra {
+			data[k] = v
+		}
+	}
+	j, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	cn.sendChan <- j
 	return nil
 }
 
-func (c *ClientWs) dial(p bool) error {
-	c.mu[p].Lock()
-	conn, res, err := c.dialer.Dial(string(c.url[p]), nil)
+// dial opens the websocket connection of cn and starts its receiver and sender, dialMu must be held. cn.mu is only
+// taken to set the connection, so that the pool isn't held up by a slow dial.
+func (c *ClientWs) dial(cn *connection) error {
+	conn, res, err := c.dialer.Dial(string(c.url[cn.endpoint]), nil)
 	if err != nil {
 		var statusCode int
 		if res != nil {
@@ -241,8 +331,10 @@ func (c *ClientWs) dial(p bool) error {
 		}
 		return fmt.Errorf("error %d: %w", statusCode, err)
 	}
-	c.conn[p] = conn
-	c.mu[p].Unlock()
+	cn.mu.Lock()
+	cn.conn = conn
+	cn.mu.Unlock()
+	done := make(chan struct{})
 
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -251,13 +343,13 @@ func (c *ClientWs) dial(p bool) error {
 		}
 	}(res.Body)
 	go func() {
-		err := c.receiver(p)
+		err := c.receiver(cn, conn, done)
 		if err != nil {
 			fmt.Printf("receiver error: %v\n", err)
 		}
 	}()
 	go func() {
-		err := c.sender(p)
+		err := c.sender(cn, conn, done)
 		if err != nil {
 			fmt.Printf("sender error: %v\n", err)
 		}
@@ -266,66 +358,150 @@ func (c *ClientWs) dial(p bool) error {
 	return nil
 }
 
-func (c *ClientWs) sender(p bool) error {
+// reconnect drops the broken connection, redials it with exponential backoff and replays login and subscriptions,
+// then rebalances the pool of its endpoint.
+//
+// The subscriptions stay with the connection while it is redialed, moving them to the other connections would race
+// with their own reconnects when the whole side went down.
+func (c *ClientWs) reconnect(cn *connection, cause error) {
+	e := cn.endpoint
+	cn.dialMu.Lock()
+	cn.mu.Lock()
+	if cn.conn != nil {
+		_ = cn.conn.Close()
+		cn.conn = nil
+	}
+	cn.lastTransmit = nil
+	cn.broken = true
+	cn.authorized = false
+	cn.authRequested = nil
+	cn.authErr = nil
+	cn.mu.Unlock()
+	c.onStatus(cn.status(events.StatusDisconnected, 0, cause))
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-time.After(backoff(attempt)):
+		case <-c.ctx.Done():
+			cn.dialMu.Unlock()
+			return
+		}
+		c.onStatus(cn.status(events.StatusReconnecting, attempt, nil))
+		if err := c.dial(cn); err != nil {
+			c.onStatus(cn.status(events.StatusDisconnected, attempt, err))
+			continue
+		}
+		// a failed login is retried like a failed dial, the connection is dropped and redialed after the backoff
+		if c.auth(e) {
+			if err := c.waitForAuthorization(cn); err != nil {
+				c.hangUp(cn)
+				c.onStatus(cn.status(events.StatusDisconnected, attempt, err))
+				continue
+			}
+		}
+		c.onStatus(cn.status(events.StatusReconnected, attempt, nil))
+		break
+	}
+	cn.mu.Lock()
+	cn.broken = false
+	cn.mu.Unlock()
+	cn.dialMu.Unlock()
+
+	if args := c.carried(cn); len(args) > 0 {
+		err := c.sendChunks(cn, okex.SubscribeOperation, args)
+		c.onStatus(cn.status(events.StatusResubscribed, 0, err))
+	}
+	_ = c.rebalance(e)
+}
+
+// hangUp closes the websocket connection of cn without redialing it, its receiver sees it is no longer the current one
+func (c *ClientWs) hangUp(cn *connection) {
+	cn.mu.Lock()
+	conn := cn.conn
+	cn.conn = nil
+	cn.authorized = false
+	cn.authRequested = nil
+	cn.authErr = nil
+	cn.mu.Unlock()
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// sender writes the queued messages to conn, the websocket connection it was started with, so that it never writes
+// to the one a reconnect replaces it with
+func (c *ClientWs) sender(cn *connection, conn *websocket.Conn, done chan struct{}) error {
 	ticker := time.NewTicker(time.Millisecond * 300)
 	defer ticker.Stop()
 	for {
 		select {
-		case data := <-c.sendChan[p]:
-			c.mu[p].RLock()
-			err := c.conn[p].SetWriteDeadline(time.Now().Add(writeWait))
-			if err != nil {
-				c.mu[p].RUnlock()
+		case data := <-cn.sendChan:
+			select {
+			case <-done:
+				// the connection broke meanwhile, the message is left to the sender of the next one, the pings are
+				// not worth it
+				if string(data) != "ping" {
+					go func() {
+						cn.sendChan <- data
+					}()
+				}
+				return nil
+			default:
+			}
+			if err := conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
 				return err
 			}
-			w, err := c.conn[p].NextWriter(websocket.TextMessage)
+			w, err := conn.NextWriter(websocket.TextMessage)
 			if err != nil {
-				c.mu[p].RUnlock()
 				return err
 			}
 			if _, err = w.Write(data); err != nil {
-				c.mu[p].RUnlock()
 				return err
 			}
-			now := time.Now()
-			c.lastTransmit[p] = &now
-			c.mu[p].RUnlock()
 			if err := w.Close(); err != nil {
 				return err
 			}
+			now := time.Now()
+			cn.mu.Lock()
+			cn.lastTransmit = &now
+			cn.mu.Unlock()
 		case <-ticker.C:
-			c.mu[p].RLock()
-			conn := c.conn[p]
-			lastTransmit := c.lastTransmit[p]
-			c.mu[p].RUnlock()
+			cn.mu.RLock()
+			conn := cn.conn
+			lastTransmit := cn.lastTransmit
+			cn.mu.RUnlock()
 			if conn != nil && (lastTransmit == nil || (lastTransmit != nil && time.Since(*lastTransmit) > PingPeriod)) {
 				go func() {
-					c.sendChan[p] <- []byte("ping")
+					cn.sendChan <- []byte("ping")
 				}()
 			}
+		case <-done:
+			return nil
 		case <-c.ctx.Done():
 			return c.handleCancel("sender")
 		}
 	}
 }
 
-func (c *ClientWs) receiver(p bool) error {
+// receiver reads conn, the websocket connection it was started with, the lock of the connection is not held while
+// reading, a blocked read would otherwise starve the writers of the connection
+func (c *ClientWs) receiver(cn *connection, conn *websocket.Conn, done chan struct{}) error {
+	defer close(done)
 	for {
 		select {
 		case <-c.ctx.Done():
 			return c.handleCancel("receiver")
 		default:
-			c.mu[p].RLock()
-			err := c.conn[p].SetReadDeadline(time.Now().Add(pongWait))
+			err := conn.SetReadDeadline(time.Now().Add(pongWait))
 			if err != nil {
 				c.onErr(&events.Error{
 					Msg: err.Error(),
 					Op:  "ws SetReadDeadline",
 				})
-				c.mu[p].RUnlock()
+				go c.reconnect(cn, err)
 				return err
 			}
-			mt, data, err := c.conn[p].ReadMessage()
+			mt, data, err := conn.ReadMessage()
 			if err != nil {
 
 				c.onErr(&events.Error{
@@ -333,24 +509,31 @@ func (c *ClientWs) receiver(p bool) error {
 					Op:  "ws ReadMessage",
 				})
 
-				c.mu[p].RUnlock()
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					return c.conn[p].Close()
+				if c.ctx.Err() != nil {
+					return c.handleCancel("receiver")
+				}
+				// the connection was dropped on purpose by the reconnect in progress
+				if !cn.current(conn) {
+					return err
 				}
+				go c.reconnect(cn, err)
 				return err
 			}
-			c.mu[p].RUnlock()
 			now := time.Now()
-			c.mu[p].Lock()
-			c.lastTransmit[p] = &now
-			c.mu[p].Unlock()
+			cn.mu.Lock()
+			cn.lastTransmit = &now
+			cn.mu.Unlock()
 
 			if mt == websocket.TextMessage && string(data) != "pong" {
 				e := new(events.Basic)
 				if err := json.Unmarshal(data, e); err != nil {
-					return err
+					c.onErr(&events.Error{
+						Msg: fmt.Sprintf("%s: %s", err, data),
+						Op:  "ws Unmarshal",
+					})
+					continue
 				}
-				c.process(data, e)
+				c.process(cn, data, e)
 			}
 		}
 	}
@@ -373,11 +556,12 @@ func (c *ClientWs) handleCancel(msg string) error {
 }
 
 // TODO: break each case into a separate function
-func (c *ClientWs) process(data []byte, e *events.Basic) bool {
+func (c *ClientWs) process(cn *connection, data []byte, e *events.Basic) bool {
 	switch e.Event {
 	case "error":
 		e := new(events.Error)
 		_ = json.Unmarshal(data, e)
+		c.failLogin(cn, e)
 		c.onErr(e)
 		return true
 	case "subscribe":
@@ -386,7 +570,7 @@ func (c *ClientWs) process(data []byte, e *events.Basic) bool {
 		}
 		e := new(events.Subscribe)
 		_ = json.Unmarshal(data, e)
-		c.SubscribeChan <- e
+		deliver(c, c.SubscribeChan, e)
 		return true
 	case "unsubscribe":
 		if c.UnsubscribeCh == nil {
@@ -394,29 +578,33 @@ func (c *ClientWs) process(data []byte, e *events.Basic) bool {
 		}
 		e := new(events.Unsubscribe)
 		_ = json.Unmarshal(data, e)
-		c.UnsubscribeCh <- e
+		deliver(c, c.UnsubscribeCh, e)
 		return true
 	case "login":
-		if time.Since(*c.AuthRequested).Seconds() > 30 {
-			c.AuthRequested = nil
-			_ = c.Login()
+		cn.mu.Lock()
+		if cn.authRequested == nil || time.Since(*cn.authRequested) > loginWait {
+			cn.authRequested = nil
+			cn.mu.Unlock()
+			_ = c.login(cn)
 			break
 		}
-		c.Authorized = true
+		cn.authorized = true
+		cn.mu.Unlock()
 		if c.LoginChan == nil {
 			return false
 		}
 		e := new(events.Login)
 		_ = json.Unmarshal(data, e)
-		c.LoginChan <- e
+		deliver(c, c.LoginChan, e)
 
 		return true
 	}
 
-	if c.Private.Process(data, e) {
+	handled := c.handlers.event(data, e)
+	if c.dispatch(data, e) || handled {
 		return true
 	}
-	if c.Public.Process(data, e) {
+	if c.Trade.Process(data, e) {
 		return true
 	}
 
@@ -424,22 +612,81 @@ func (c *ClientWs) process(data []byte, e *events.Basic) bool {
 		if e.Code != 0 {
 			ee := *e
 			ee.Event = "error"
-			return c.process(data, &ee)
+			return c.process(cn, data, &ee)
 		}
 		e := new(events.Success)
 		_ = json.Unmarshal(data, e)
 		if c.SuccessChan != nil {
-			c.SuccessChan <- e
+			deliver(c, c.SuccessChan, e)
 		}
 		return true
 	}
 	return false
 }
 
+// failLogin fails the pending login of the connection when the error rejects the credentials, so that its waiters stop
+func (c *ClientWs) failLogin(cn *connection, e *events.Error) {
+	if okex.ClassOf(int(e.Code)) != okex.ErrorClassAuth {
+		return
+	}
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	if cn.authorized || cn.authRequested == nil {
+		return
+	}
+	cn.authRequested = nil
+	cn.authErr = &okex.APIError{Code: int(e.Code), Msg: e.Msg, Endpoint: string(okex.LoginOperation)}
+}
+
+func (c *ClientWs) onStatus(status *events.Status) {
+	c.handlers.onStatus(status)
+	if c.StatusChan == nil {
+		return
+	}
+
+	deliver(c, c.StatusChan, status)
+}
+
 func (c *ClientWs) onErr(errEvent *events.Error) {
+	c.handlers.onErr(errEvent)
 	if c.ErrChan == nil {
 		return
 	}
 
-	c.ErrChan <- errEvent
+	deliver(c, c.ErrChan, errEvent)
+}
+
+// deliver sends v to ch unless ch is full, so that a slow reader never blocks the receiver of a connection
+func deliver[T any](c *ClientWs, ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+	}
+}
+
+// backoff returns the exponential redial delay of the given attempt with a random jitter
+func backoff(attempt int) time.Duration {
+	d := maxRedialTick
+	if attempt < 16 {
+		d = min(redialTick<<(attempt-1), maxRedialTick)
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// argKey returns a stable identifier of the subscription argument
+func argKey(arg map[string]string) string {
+	keys := make([]string, 0, len(arg))
+	for k := range arg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(arg[k])
+		b.WriteByte(';')
+	}
+	return b.String()
 }
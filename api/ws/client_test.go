@@ -0,0 +1,403 @@
+package ws_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/okextest"
+	requests_private "github.com/dimkus/okex/requests/ws/private"
+	requests_public "github.com/dimkus/okex/requests/ws/public"
+)
+
+func TestReconnectReplaysLoginAndSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(chan *events.Status, 64)
+	c.Ws.OnStatus(func(e *events.Status) {
+		select {
+		case statuses <- e:
+		default:
+		}
+	})
+
+	tickers, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := c.Ws.Private.Account(requests_private.Account{Ccy: "USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tArg := map[string]string{"channel": "tickers", "instId": "BTC-USDT"}
+	aArg := map[string]string{"channel": "account", "ccy": "USDT"}
+
+	// the connections are dropped a few times in a row while the pings are flowing, the sender of a dropped
+	// connection must never write to the one replacing it
+	for i := 0; i < 3; i++ {
+		for _, arg := range []map[string]string{tArg, aArg} {
+			if err := s.WaitSubscribed(ctx, arg); err != nil {
+				t.Fatalf("round %d: %v not replayed: %v", i, arg, err)
+			}
+		}
+		s.Disconnect(false)
+		s.Disconnect(true)
+		waitResubscribed(t, ctx, statuses, false, true)
+	}
+	for _, arg := range []map[string]string{tArg, aArg} {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Push(tArg, map[string]string{"instId": "BTC-USDT", "last": "42000"})
+	s.Push(aArg, map[string]string{"totalEq": "100"})
+	select {
+	case e := <-tickers.C:
+		if len(e.Tickers) != 1 || e.Tickers[0].Last != 42000 {
+			t.Fatalf("got %+v", e.Tickers)
+		}
+	case <-ctx.Done():
+		t.Fatal("no ticker after the reconnects")
+	}
+	select {
+	case e := <-account.C:
+		if len(e.Balances) != 1 {
+			t.Fatalf("got %+v", e.Balances)
+		}
+	case <-ctx.Done():
+		t.Fatal("no account push after the reconnects, the login was not replayed")
+	}
+}
+
+func TestReconnectKeepsPoolSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ws.SetPoolSize(ws.EndpointPublic, 3); err != nil {
+		t.Fatal(err)
+	}
+	var args []map[string]string
+	for _, instID := range []string{"BTC-USDT", "ETH-USDT", "SOL-USDT", "XRP-USDT", "DOGE-USDT", "LTC-USDT"} {
+		if _, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: instID}); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, map[string]string{"channel": "tickers", "instId": instID})
+	}
+	for _, arg := range args {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Disconnect(false)
+	for _, arg := range args {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatalf("%v not replayed: %v", arg, err)
+		}
+	}
+}
+
+func TestPoolSpreadsChunkedSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ws.SetPoolSize(ws.EndpointPublic, 3); err != nil {
+		t.Fatal(err)
+	}
+	// 600 arguments of about 45 bytes, the third of them is more than a request of 4096 bytes can carry
+	args := make([]map[string]string, 600)
+	for i := range args {
+		args[i] = map[string]string{"channel": "tickers", "instId": fmt.Sprintf("COIN%04d-USDT", i)}
+	}
+	if err := c.Ws.Subscribe(false, nil, args...); err != nil {
+		t.Fatal(err)
+	}
+	spread := func(want ...int) {
+		t.Helper()
+		for _, arg := range args {
+			if err := s.WaitSubscribed(ctx, arg); err != nil {
+				t.Fatalf("%v not subscribed: %v", arg, err)
+			}
+		}
+		if got := c.Ws.Carried(ws.EndpointPublic); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v subscriptions per connection, want %v", got, want)
+		}
+		// each argument is carried by a single connection
+		for _, arg := range args {
+			if n := s.Push(arg, map[string]string{"instId": arg["instId"], "last": "1"}); n != 1 {
+				t.Fatalf("%v pushed to %d connections", arg, n)
+			}
+		}
+	}
+	spread(200, 200, 200)
+
+	// the new connections take a half of the subscriptions, moved in chunks as well
+	if err := c.Ws.SetPoolSize(ws.EndpointPublic, 6); err != nil {
+		t.Fatal(err)
+	}
+	spread(100, 100, 100, 100, 100, 100)
+}
+
+func TestBusinessChannels(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(chan *events.Status, 64)
+	c.Ws.OnStatus(func(e *events.Status) {
+		select {
+		case statuses <- e:
+		default:
+		}
+	})
+	candles, err := c.Ws.Public.Candlesticks(requests_public.Candlesticks{InstID: "BTC-USDT", Channel: okex.CandleStick1m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits, err := c.Ws.Private.DepositInfo(requests_private.DepositInfo{Ccy: "USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cArg := map[string]string{"channel": "candle1m", "instId": "BTC-USDT"}
+	dArg := map[string]string{"channel": "deposit-info", "ccy": "USDT"}
+
+	// both channels go through the business endpoint, dropping it replays them while the others are left alone
+	for _, arg := range []map[string]string{cArg, dArg} {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+		if !s.SubscribedAt(okextest.BusinessWsPath, arg) {
+			t.Fatalf("%v subscribed off the business endpoint", arg)
+		}
+	}
+	s.DisconnectPath(okextest.BusinessWsPath)
+	for resubscribed := false; !resubscribed; {
+		select {
+		case e := <-statuses:
+			if e.Endpoint != string(ws.EndpointBusiness) {
+				t.Fatalf("got %+v, want the business endpoint only", e)
+			}
+			resubscribed = e.State == events.StatusResubscribed && e.Err == nil
+		case <-ctx.Done():
+			t.Fatal("the business connection wasn't resubscribed")
+		}
+	}
+	for _, arg := range []map[string]string{cArg, dArg} {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatalf("%v not replayed: %v", arg, err)
+		}
+	}
+
+	s.Push(cArg, []string{"1700000000000", "100", "101", "99", "100.5", "10", "1000"})
+	s.Push(dArg, map[string]string{"ccy": "USDT", "amt": "5"})
+	select {
+	case e := <-candles.C:
+		if len(e.Candles) != 1 || e.Candles[0].C != 100.5 {
+			t.Fatalf("got %+v", e.Candles)
+		}
+	case <-ctx.Done():
+		t.Fatal("no candle")
+	}
+	select {
+	case e := <-deposits.C:
+		if len(e.Deposits) != 1 || e.Deposits[0].Ccy != "USDT" {
+			t.Fatalf("got %+v", e.Deposits)
+		}
+	case <-ctx.Done():
+		t.Fatal("no deposit")
+	}
+}
+
+// waitResubscribed blocks until the connections of both sides have been resubscribed
+func waitResubscribed(t *testing.T, ctx context.Context, statuses chan *events.Status, sides ...bool) {
+	t.Helper()
+	pending := make(map[bool]bool)
+	for _, private := range sides {
+		pending[private] = true
+	}
+	for len(pending) > 0 {
+		select {
+		case e := <-statuses:
+			if e.State == events.StatusResubscribed && e.Err == nil {
+				delete(pending, e.Private)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no resubscribed status for %v", pending)
+		}
+	}
+}
+
+func TestFullChannelsDoNotBlockReceiver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nobody reads the channels
+	c.Ws.SetChannels(make(chan *events.Error), make(chan *events.Subscribe), make(chan *events.Unsubscribe), make(chan *events.Login), make(chan *events.Success))
+	c.Ws.SetStatusChannel(make(chan *events.Status))
+
+	var args []map[string]string
+	for _, instID := range []string{"BTC-USDT", "ETH-USDT", "SOL-USDT"} {
+		if _, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: instID}); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, map[string]string{"channel": "tickers", "instId": instID})
+	}
+	for _, arg := range args {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tickers, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Push(args[0], map[string]string{"instId": "BTC-USDT", "last": "42000"})
+	select {
+	case <-tickers.C:
+	case <-ctx.Done():
+		t.Fatal("the receiver is blocked by the channels nobody reads")
+	}
+	if c.Ws.Dropped() == 0 {
+		t.Fatal("the dropped events weren't counted")
+	}
+}
+
+func TestFailedLoginIsReturned(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.FailOp(okex.LoginOperation, 60009, "Login failed", 1)
+	if _, err := c.Ws.Private.Account(requests_private.Account{Ccy: "USDT"}); !okex.IsAuthError(err) {
+		t.Fatalf("got %v, want the rejected login", err)
+	}
+	if c.Ws.Authorized() {
+		t.Fatal("authorized after a rejected login")
+	}
+	// the next subscription logs in again
+	if _, err := c.Ws.Private.Account(requests_private.Account{Ccy: "USDT"}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Ws.Authorized() {
+		t.Fatal("not authorized after the login")
+	}
+}
+
+func TestReconnectRetriesFailedLogin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(chan *events.Status, 64)
+	c.Ws.OnStatus(func(e *events.Status) {
+		select {
+		case statuses <- e:
+		default:
+		}
+	})
+	if _, err := c.Ws.Private.Account(requests_private.Account{Ccy: "USDT"}); err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "account", "ccy": "USDT"}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.FailOp(okex.LoginOperation, 60009, "Login failed", 1)
+	s.Disconnect(true)
+	rejected := false
+	for done := false; !done; {
+		select {
+		case e := <-statuses:
+			switch {
+			case e.State == events.StatusDisconnected && okex.IsAuthError(e.Err):
+				rejected = true
+			case e.State == events.StatusResubscribed:
+				done = true
+			}
+		case <-ctx.Done():
+			t.Fatal("the failed login was not retried")
+		}
+	}
+	if !rejected {
+		t.Fatal("the rejected login was not reported")
+	}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMalformedFrameKeepsReceiver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan *events.Error, 16)
+	c.Ws.SetChannels(errs, nil, nil, nil, nil)
+	tickers, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "tickers", "instId": "BTC-USDT"}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.PushRaw(false, []byte("{not json"))
+	select {
+	case e := <-errs:
+		if e.Op != "ws Unmarshal" {
+			t.Fatalf("got %+v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("the malformed frame wasn't reported")
+	}
+	s.Push(arg, map[string]string{"instId": "BTC-USDT", "last": "42000"})
+	select {
+	case <-tickers.C:
+	case <-ctx.Done():
+		t.Fatal("the receiver stopped on the malformed frame")
+	}
+}
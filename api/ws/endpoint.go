@@ -0,0 +1,68 @@
+package ws
+
+import "strings"
+
+// Endpoint names a websocket endpoint of OKX, each one has a pool of connections of its own
+type Endpoint string
+
+const (
+	EndpointPublic  = Endpoint("public")
+	EndpointPrivate = Endpoint("private")
+	// EndpointBusiness serves both public and private channels, its connections log in when the client has credentials
+	EndpointBusiness = Endpoint("business")
+)
+
+var (
+	// businessChannels are the channels OKX serves on the business endpoint only
+	businessChannels = map[string]bool{
+		"trades-all":                true,
+		"economic-calendar":         true,
+		"orders-algo":               true,
+		"algo-advance":              true,
+		"grid-positions":            true,
+		"grid-sub-orders":           true,
+		"deposit-info":              true,
+		"withdrawal-info":           true,
+		"rfqs":                      true,
+		"quotes":                    true,
+		"struc-block-trades":        true,
+		"public-struc-block-trades": true,
+		"public-block-trades":       true,
+		"block-tickers":             true,
+	}
+	// businessPrefixes are the prefixes of the channel families served on the business endpoint, i.e. candle1m
+	businessPrefixes = []string{"candle", "mark-price-candle", "index-candle", "grid-orders-", "sprd-"}
+)
+
+// EndpointOf returns the endpoint serving the channel, private tells the public channels from the private ones
+// among those the business endpoint doesn't serve
+func EndpointOf(private bool, channel string) Endpoint {
+	if businessChannels[channel] {
+		return EndpointBusiness
+	}
+	for _, prefix := range businessPrefixes {
+		if strings.HasPrefix(channel, prefix) {
+			return EndpointBusiness
+		}
+	}
+	return side(private)
+}
+
+// side returns the public or the private endpoint
+func side(private bool) Endpoint {
+	if private {
+		return EndpointPrivate
+	}
+	return EndpointPublic
+}
+
+// auth reports whether the connections of the endpoint log in before sending anything
+func (c *ClientWs) auth(e Endpoint) bool {
+	switch e {
+	case EndpointPrivate:
+		return true
+	case EndpointBusiness:
+		return c.apiKey != ""
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package ws
+
+import "time"
+
+// SetReplyTTL shortens the time the replies are awaited for the tests and returns a func restoring it
+func SetReplyTTL(d time.Duration) func() {
+	old := replyTTL
+	replyTTL = d
+	return func() { replyTTL = old }
+}
+
+// PendingReplies returns the number of operations whose reply is still routed to a future
+func (c *Trade) PendingReplies() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Carried returns the number of subscriptions each connection of the endpoint carries
+func (c *ClientWs) Carried(e Endpoint) []int {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	n := make([]int, len(c.conns[e]))
+	for i, cn := range c.conns[e] {
+		n[i] = len(cn.args)
+	}
+	return n
+}
@@ -0,0 +1,202 @@
+package ws
+
+import (
+	"context"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/events/public"
+	"github.com/goccy/go-json"
+	"strings"
+	"sync"
+)
+
+type (
+	// Handler receives the events of ClientWs as callbacks instead of channels.
+	//
+	//	type strategy struct{ ws.NopHandler }
+	//
+	//	func (s *strategy) OnTicker(e *public.Tickers) { ... }
+	//
+	//	client.Ws.SetHandler(&strategy{})
+	//	_ = client.Ws.Subscribe(false, []okex.ChannelName{"tickers"}, map[string]string{"instId": "BTC-USDT"})
+	//
+	// The callbacks are called one at a time in the order the events arrived, on a goroutine of their own, so a slow
+	// callback delays the next ones but never the connection. The handler receives every event of the subscribed
+	// channels, whether they were subscribed through ClientWs.Subscribe or through a Subscription.
+	Handler interface {
+		OnTicker(e *public.Tickers)
+		OnTrade(e *public.Trades)
+		OnCandle(e *public.Candlesticks)
+		OnBookUpdate(e *public.OrderBook)
+		OnMarkPrice(e *public.MarkPrice)
+		OnFundingRate(e *public.FundingRate)
+		OnAccount(e *private.Account)
+		OnPosition(e *private.Position)
+		OnOrder(e *private.Order)
+		OnError(e *events.Error)
+		// OnReconnect receives the lifecycle of a connection while it is redialed and its subscriptions replayed
+		OnReconnect(e *events.Status)
+	}
+
+	// NopHandler implements Handler doing nothing, embed it to implement only the callbacks needed
+	NopHandler struct{}
+
+	// handlers keeps the callbacks registered on ClientWs and the queue they are called from
+	handlers struct {
+		h      Handler
+		raw    map[string][]func(raw []byte)
+		status []func(e *events.Status)
+		calls  *Subscription[func()]
+		ctx    context.Context
+		mu     sync.RWMutex
+	}
+)
+
+func (NopHandler) OnTicker(*public.Tickers)          {}
+func (NopHandler) OnTrade(*public.Trades)            {}
+func (NopHandler) OnCandle(*public.Candlesticks)     {}
+func (NopHandler) OnBookUpdate(*public.OrderBook)    {}
+func (NopHandler) OnMarkPrice(*public.MarkPrice)     {}
+func (NopHandler) OnFundingRate(*public.FundingRate) {}
+func (NopHandler) OnAccount(*private.Account)        {}
+func (NopHandler) OnPosition(*private.Position)      {}
+func (NopHandler) OnOrder(*private.Order)            {}
+func (NopHandler) OnError(*events.Error)             {}
+func (NopHandler) OnReconnect(*events.Status)        {}
+
+// SetHandler registers h to receive the events alongside the channels, nil removes it
+func (c *ClientWs) SetHandler(h Handler) {
+	c.handlers.mu.Lock()
+	defer c.handlers.mu.Unlock()
+	c.handlers.h = h
+	c.handlers.start()
+}
+
+// On registers fn to receive the raw events of the channel, i.e. for the channels the package doesn't model yet.
+// fn is called from the same goroutine as the Handler callbacks.
+func (c *ClientWs) On(channel string, fn func(raw []byte)) {
+	c.handlers.mu.Lock()
+	defer c.handlers.mu.Unlock()
+	c.handlers.raw[channel] = append(c.handlers.raw[channel], fn)
+	c.handlers.start()
+}
+
+// OnStatus registers fn to receive the lifecycle of the connections, i.e. to reconcile a local state after reconnects.
+// fn is called from the same goroutine as the Handler callbacks.
+func (c *ClientWs) OnStatus(fn func(e *events.Status)) {
+	c.handlers.mu.Lock()
+	defer c.handlers.mu.Unlock()
+	c.handlers.status = append(c.handlers.status, fn)
+	c.handlers.start()
+}
+
+func newHandlers(ctx context.Context) *handlers {
+	return &handlers{ctx: ctx, raw: make(map[string][]func(raw []byte))}
+}
+
+// start runs the callbacks goroutine once a callback is registered, mu must be held
+func (hs *handlers) start() {
+	if hs.calls != nil {
+		return
+	}
+	hs.calls = NewSubscription[func()](nil, nil)
+	go func() {
+		for {
+			select {
+			case call := <-hs.calls.C:
+				call()
+			case <-hs.ctx.Done():
+				_ = hs.calls.Close()
+				return
+			}
+		}
+	}()
+}
+
+// event queues the callbacks of a channel event and reports whether there were any
+func (hs *handlers) event(data []byte, e *events.Basic) bool {
+	if e.Event != "" || e.Arg == nil || len(e.Data) == 0 {
+		return false
+	}
+	v, _ := e.Arg.Get("channel")
+	channel, _ := v.(string)
+	hs.mu.RLock()
+	h, raw, calls := hs.h, hs.raw[channel], hs.calls
+	hs.mu.RUnlock()
+	if calls == nil {
+		return false
+	}
+	handled := false
+	if h != nil {
+		if call := typed(h, channel, data); call != nil {
+			calls.Publish(call)
+			handled = true
+		}
+	}
+	for _, fn := range raw {
+		fn := fn
+		calls.Publish(func() { fn(data) })
+		handled = true
+	}
+	return handled
+}
+
+// onStatus queues the OnReconnect and the status callbacks
+func (hs *handlers) onStatus(s *events.Status) {
+	hs.mu.RLock()
+	h, status, calls := hs.h, hs.status, hs.calls
+	hs.mu.RUnlock()
+	if calls == nil {
+		return
+	}
+	if h != nil {
+		calls.Publish(func() { h.OnReconnect(s) })
+	}
+	for _, fn := range status {
+		fn := fn
+		calls.Publish(func() { fn(s) })
+	}
+}
+
+// onErr queues the OnError callback
+func (hs *handlers) onErr(e *events.Error) {
+	hs.mu.RLock()
+	h, calls := hs.h, hs.calls
+	hs.mu.RUnlock()
+	if h != nil && calls != nil {
+		calls.Publish(func() { h.OnError(e) })
+	}
+}
+
+// typed decodes the event of a modeled channel and returns the call of its callback, nil for the other channels
+func typed(h Handler, channel string, data []byte) func() {
+	switch {
+	case channel == "tickers":
+		return decode(data, h.OnTicker)
+	case channel == "trades":
+		return decode(data, h.OnTrade)
+	case channel == "mark-price":
+		return decode(data, h.OnMarkPrice)
+	case channel == "funding-rate":
+		return decode(data, h.OnFundingRate)
+	case channel == "account":
+		return decode(data, h.OnAccount)
+	case channel == "positions":
+		return decode(data, h.OnPosition)
+	case channel == "orders":
+		return decode(data, h.OnOrder)
+	case channel == "bbo-tbt" || strings.HasPrefix(channel, "books"):
+		return decode(data, h.OnBookUpdate)
+	case strings.HasPrefix(channel, "candle"):
+		return decode(data, h.OnCandle)
+	}
+	return nil
+}
+
+func decode[T any](data []byte, fn func(e *T)) func() {
+	e := new(T)
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil
+	}
+	return func() { fn(e) }
+}
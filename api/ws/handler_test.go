@@ -0,0 +1,114 @@
+package ws_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/events/public"
+	"github.com/dimkus/okex/okextest"
+	"github.com/goccy/go-json"
+)
+
+// recorder forwards the callbacks it implements to channels
+type recorder struct {
+	ws.NopHandler
+	tickers    chan *public.Tickers
+	reconnects chan *events.Status
+}
+
+func (r *recorder) OnTicker(e *public.Tickers) { r.tickers <- e }
+
+func (r *recorder) OnReconnect(e *events.Status) {
+	select {
+	case r.reconnects <- e:
+	default:
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{tickers: make(chan *public.Tickers, 8), reconnects: make(chan *events.Status, 64)}
+	c.Ws.SetHandler(r)
+	raw := make(chan string, 8)
+	c.Ws.On("tickers", func(b []byte) { raw <- string(b) })
+	if err := c.Ws.Subscribe(false, []okex.ChannelName{"tickers"}, map[string]string{"instId": "BTC-USDT"}); err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "tickers", "instId": "BTC-USDT"}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	// the callbacks get the events in the order they arrived, the raw ones as they were received
+	s.Push(arg, map[string]string{"instId": "BTC-USDT", "last": "1"})
+	s.Push(arg, map[string]string{"instId": "BTC-USDT", "last": "2"})
+	for _, want := range []float64{1, 2} {
+		if e := receive(t, ctx, r.tickers); len(e.Tickers) != 1 || float64(e.Tickers[0].Last) != want {
+			t.Fatalf("got %+v, want the ticker at %v", e.Tickers, want)
+		}
+		if e := receive(t, ctx, raw); !strings.Contains(e, `"tickers"`) || !strings.Contains(e, `"last":"`) {
+			t.Fatalf("got %s", e)
+		}
+	}
+
+	s.Disconnect(false)
+	for {
+		e := receive(t, ctx, r.reconnects)
+		if e.Endpoint != string(ws.EndpointPublic) {
+			t.Fatalf("got %+v", e)
+		}
+		if e.State == events.StatusResubscribed {
+			break
+		}
+	}
+}
+
+func TestOnUnmodeledChannel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adl, other := make(chan []byte, 8), make(chan []byte, 8)
+	c.Ws.On("adl-warning", func(b []byte) { adl <- b })
+	c.Ws.On("index-components", func(b []byte) { other <- b })
+	arg := map[string]string{"channel": "adl-warning", "instType": "SWAP", "instFamily": "BTC-USDT"}
+	if err := c.Ws.Subscribe(false, nil, arg); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Push(arg, map[string]string{"instFamily": "BTC-USDT", "state": "warning", "adlBal": "100"})
+	var e struct {
+		Arg  map[string]string   `json:"arg"`
+		Data []map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(receive(t, ctx, adl), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Arg["channel"] != "adl-warning" || len(e.Data) != 1 || e.Data[0]["state"] != "warning" {
+		t.Fatalf("got %+v", e)
+	}
+	select {
+	case b := <-other:
+		t.Fatalf("got %s on the callback of another channel", b)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,334 @@
+package ws
+
+import (
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/events"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRequestBytes is the limit of the length of a subscribe or unsubscribe request
+const maxRequestBytes = 4096
+
+type (
+	// connection is one of the websocket connections of an endpoint of ClientWs, the subscriptions of the endpoint
+	// are spread across its connections while the other operations go through the first one
+	connection struct {
+		endpoint      Endpoint
+		index         int
+		conn          *websocket.Conn
+		sendChan      chan []byte
+		lastTransmit  *time.Time
+		authorized    bool
+		authRequested *time.Time
+		// authErr is the rejection of the last login
+		authErr error
+		// broken is set while the connection is redialed
+		broken bool
+		// args are the subscriptions the connection carries, they are guarded by ClientWs.poolMu
+		args   map[string]map[string]string
+		mu     sync.RWMutex
+		dialMu sync.Mutex
+	}
+
+	// move of a subscription argument from a connection to another one
+	move struct {
+		key  string
+		arg  map[string]string
+		from *connection
+		to   *connection
+	}
+)
+
+func newConnection(e Endpoint, index int) *connection {
+	return &connection{
+		endpoint: e,
+		index:    index,
+		sendChan: make(chan []byte, 3),
+		args:     make(map[string]map[string]string),
+	}
+}
+
+// SetPoolSize sets the number of connections the subscriptions of the endpoint are spread across.
+//
+// The pool only grows, the subscriptions are rebalanced onto the new connections right away.
+func (c *ClientWs) SetPoolSize(e Endpoint, n int) error {
+	c.poolMu.Lock()
+	grown := false
+	for len(c.conns[e]) < n {
+		c.conns[e] = append(c.conns[e], newConnection(e, len(c.conns[e])))
+		grown = true
+	}
+	c.poolMu.Unlock()
+	if !grown {
+		return nil
+	}
+	return c.rebalance(e)
+}
+
+// PoolSize returns the number of connections of the endpoint
+func (c *ClientWs) PoolSize(e Endpoint) int {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	return len(c.conns[e])
+}
+
+// pool returns the connections of an endpoint
+func (c *ClientWs) pool(e Endpoint) []*connection {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	return append([]*connection(nil), c.conns[e]...)
+}
+
+// primary returns the connection of an endpoint the operations other than the subscriptions go through
+func (c *ClientWs) primary(e Endpoint) *connection {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	return c.conns[e][0]
+}
+
+// place assigns the arguments to the connections of the endpoints serving their channels, the ones already carried
+// stay where they are and the new ones go to the least loaded connections, then subscribes them
+func (c *ClientWs) place(p bool, args []map[string]string) error {
+	c.poolMu.Lock()
+	groups := make(map[*connection][]map[string]string)
+	var order []*connection
+	for _, arg := range args {
+		key := argKey(arg)
+		e := EndpointOf(p, arg["channel"])
+		cn := c.owner(e, key)
+		if cn == nil {
+			cn = c.leastLoaded(c.conns[e])
+		}
+		cn.args[key] = arg
+		if _, ok := groups[cn]; !ok {
+			order = append(order, cn)
+		}
+		groups[cn] = append(groups[cn], arg)
+	}
+	c.poolMu.Unlock()
+
+	for _, cn := range order {
+		if err := c.sendChunks(cn, okex.SubscribeOperation, groups[cn]); err != nil {
+			c.poolMu.Lock()
+			for _, arg := range groups[cn] {
+				delete(cn.args, argKey(arg))
+			}
+			c.poolMu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribe sends the arguments as they are through the connections carrying them and forgets them, so that they
+// are not replayed on reconnect
+func (c *ClientWs) unsubscribe(p bool, args []map[string]string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	c.poolMu.Lock()
+	groups := make(map[*connection][]map[string]string)
+	var order []*connection
+	for _, arg := range args {
+		key := argKey(arg)
+		e := EndpointOf(p, arg["channel"])
+		cn := c.owner(e, key)
+		if cn == nil {
+			cn = c.conns[e][0]
+		}
+		delete(cn.args, key)
+		if _, ok := groups[cn]; !ok {
+			order = append(order, cn)
+		}
+		groups[cn] = append(groups[cn], arg)
+	}
+	c.poolMu.Unlock()
+
+	for _, cn := range order {
+		if err := c.sendChunks(cn, okex.UnsubscribeOperation, groups[cn]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebalance evens out the number of subscriptions the healthy connections of an endpoint carry, the broken ones
+// keep theirs until they are back
+func (c *ClientWs) rebalance(e Endpoint) error {
+	c.poolMu.Lock()
+	var healthy []*connection
+	total := 0
+	for _, cn := range c.conns[e] {
+		if !cn.isBroken() {
+			total += len(cn.args)
+			healthy = append(healthy, cn)
+		}
+	}
+	if len(healthy) == 0 {
+		c.poolMu.Unlock()
+		return nil
+	}
+	target := (total + len(healthy) - 1) / len(healthy)
+	var moves []*move
+	for _, cn := range healthy {
+		excess := len(cn.args) - target
+		if excess <= 0 {
+			continue
+		}
+		keys := make([]string, 0, len(cn.args))
+		for key := range cn.args {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys[len(keys)-excess:] {
+			moves = append(moves, &move{key: key, arg: cn.args[key], from: cn})
+			delete(cn.args, key)
+		}
+	}
+	for _, m := range moves {
+		m.to = c.leastLoaded(healthy)
+		m.to.args[m.key] = m.arg
+	}
+	c.poolMu.Unlock()
+	if len(moves) == 0 {
+		return nil
+	}
+
+	unsubs := make(map[*connection][]map[string]string)
+	subs := make(map[*connection][]map[string]string)
+	for _, m := range moves {
+		unsubs[m.from] = append(unsubs[m.from], m.arg)
+		subs[m.to] = append(subs[m.to], m.arg)
+	}
+	var err error
+	for cn, args := range unsubs {
+		if uerr := c.sendChunks(cn, okex.UnsubscribeOperation, args); uerr != nil && err == nil {
+			err = uerr
+		}
+	}
+	for cn, args := range subs {
+		serr := c.sendChunks(cn, okex.SubscribeOperation, args)
+		if serr != nil && err == nil {
+			err = serr
+		}
+		c.onStatus(cn.status(events.StatusResubscribed, 0, serr))
+	}
+	return err
+}
+
+// owner returns the connection of the endpoint carrying the argument, poolMu must be held
+func (c *ClientWs) owner(e Endpoint, key string) *connection {
+	for _, cn := range c.conns[e] {
+		if _, ok := cn.args[key]; ok {
+			return cn
+		}
+	}
+	return nil
+}
+
+// leastLoaded returns the connection carrying the fewest subscriptions, preferring the healthy ones, poolMu must be held
+func (c *ClientWs) leastLoaded(conns []*connection) *connection {
+	var best *connection
+	for _, cn := range conns {
+		switch {
+		case best == nil:
+			best = cn
+		case best.isBroken() != cn.isBroken():
+			if best.isBroken() {
+				best = cn
+			}
+		case len(cn.args) < len(best.args):
+			best = cn
+		}
+	}
+	return best
+}
+
+// carried returns the subscriptions of the connection
+func (c *ClientWs) carried(cn *connection) []map[string]string {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	args := make([]map[string]string, 0, len(cn.args))
+	for _, arg := range cn.args {
+		args = append(args, arg)
+	}
+	return args
+}
+
+// sendChunks sends the operation through the connection in as many requests as the length limit requires
+func (c *ClientWs) sendChunks(cn *connection, op okex.Operation, args []map[string]string) error {
+	for _, chunk := range chunks(op, args) {
+		if err := c.send(cn, op, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunks splits the arguments into requests of at most maxRequestBytes
+func chunks(op okex.Operation, args []map[string]string) [][]map[string]string {
+	empty, _ := json.Marshal(map[string]interface{}{"op": op, "args": []map[string]string{}})
+	var (
+		res  [][]map[string]string
+		cur  []map[string]string
+		size = len(empty)
+	)
+	for _, arg := range args {
+		b, _ := json.Marshal(arg)
+		n := len(b)
+		if len(cur) > 0 {
+			n++
+		}
+		if len(cur) > 0 && size+n > maxRequestBytes {
+			res = append(res, cur)
+			cur, size, n = nil, len(empty), len(b)
+		}
+		cur = append(cur, arg)
+		size += n
+	}
+	if len(cur) > 0 {
+		res = append(res, cur)
+	}
+	return res
+}
+
+func (cn *connection) isBroken() bool {
+	cn.mu.RLock()
+	defer cn.mu.RUnlock()
+	return cn.broken
+}
+
+func (cn *connection) isAuthorized() bool {
+	cn.mu.RLock()
+	defer cn.mu.RUnlock()
+	return cn.authorized
+}
+
+// current reports whether conn is still the websocket connection of cn
+func (cn *connection) current(conn *websocket.Conn) bool {
+	cn.mu.RLock()
+	defer cn.mu.RUnlock()
+	return cn.conn == conn
+}
+
+func (cn *connection) connected() bool {
+	cn.mu.RLock()
+	defer cn.mu.RUnlock()
+	return cn.conn != nil
+}
+
+// status returns a lifecycle event of the connection
+func (cn *connection) status(state events.StatusState, attempt int, err error) *events.Status {
+	return &events.Status{
+		Private:  cn.endpoint == EndpointPrivate,
+		Endpoint: string(cn.endpoint),
+		Conn:     cn.index,
+		State:    state,
+		Attempt:  attempt,
+		Err:      err,
+	}
+}
@@ -2,10 +2,9 @@ package ws
 
 import (
 	"github.com/dimkus/okex"
-	"github.com/dimkus/okex/events"
 	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/models/account"
 	requests "github.com/dimkus/okex/requests/ws/private"
-	"github.com/goccy/go-json"
 )
 
 // Private
@@ -13,10 +12,16 @@ import (
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel
 type Private struct {
 	*ClientWs
-	aCh   chan *private.Account
-	pCh   chan *private.Position
-	bnpCh chan *private.BalanceAndPosition
-	oCh   chan *private.Order
+}
+
+// PrivateI is the account, position and order surface of Private, which paper.Private implements as well
+type PrivateI interface {
+	Account(req requests.Account, ch ...chan *private.Account) (*Subscription[*private.Account], error)
+	UAccount(req requests.Account) error
+	Position(req requests.Position, ch ...chan *private.Position) (*Subscription[*private.Position], error)
+	UPosition(req requests.Position) error
+	Order(req requests.Order, ch ...chan *private.Order) (*Subscription[*private.Order], error)
+	UOrder(req requests.Order) error
 }
 
 // NewPrivate returns a pointer to a fresh Private
@@ -28,146 +33,247 @@ func NewPrivate(c *ClientWs) *Private {
 // Retrieve account information. Data will be pushed when triggered by events such as placing/canceling order, and will also be pushed in regular interval according to subscription granularity.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-account-channel
-func (p *Private) Account(req requests.Account, ch ...chan *private.Account) error {
+func (p *Private) Account(req requests.Account, ch ...chan *private.Account) (*Subscription[*private.Account], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.aCh = ch[0]
-	}
-	return p.Subscribe(true, []okex.ChannelName{"account"}, m)
+	return subscribe(p.ClientWs, true, withChannel("account", m), ch)
 }
 
 // UAccount
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-account-channel
-func (p *Private) UAccount(req requests.Account, rCh ...bool) error {
+func (p *Private) UAccount(req requests.Account) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.aCh = nil
-	}
-	return p.Unsubscribe(true, []okex.ChannelName{"account"}, m)
+	return p.drop(true, withChannel("account", m))
 }
 
 // Position
 // Retrieve position information. Initial snapshot will be pushed according to subscription granularity. Data will be pushed when triggered by events such as placing/canceling order, and will also be pushed in regular interval according to subscription granularity.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-positions-channel
-func (p *Private) Position(req requests.Position, ch ...chan *private.Position) error {
+func (p *Private) Position(req requests.Position, ch ...chan *private.Position) (*Subscription[*private.Position], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.pCh = ch[0]
-	}
-	return p.Subscribe(true, []okex.ChannelName{"positions"}, m)
+	return subscribe(p.ClientWs, true, withChannel("positions", m), ch)
 }
 
 // UPosition
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-positions-channel
-func (p *Private) UPosition(req requests.Position, rCh ...bool) error {
+func (p *Private) UPosition(req requests.Position) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.pCh = nil
-	}
-	return p.Unsubscribe(true, []okex.ChannelName{"positions"}, m)
+	return p.drop(true, withChannel("positions", m))
 }
 
 // BalanceAndPosition
 // Retrieve account balance and position information. Data will be pushed when triggered by events such as filled order, funding transfer.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-balance-and-position-channel
-func (p *Private) BalanceAndPosition(ch ...chan *private.BalanceAndPosition) error {
+func (p *Private) BalanceAndPosition(ch ...chan *private.BalanceAndPosition) (*Subscription[*private.BalanceAndPosition], error) {
 	m := make(map[string]string)
-	if len(ch) > 0 {
-		p.bnpCh = ch[0]
-	}
-	return p.Subscribe(true, []okex.ChannelName{"balance_and_position"}, m)
+	return subscribe(p.ClientWs, true, withChannel("balance_and_position", m), ch)
 }
 
 // UBalanceAndPosition unsubscribes a position channel
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-balance-and-position-channel
-func (p *Private) UBalanceAndPosition(rCh ...bool) error {
+func (p *Private) UBalanceAndPosition() error {
+	m := make(map[string]string)
+	return p.drop(true, withChannel("balance_and_position", m))
+}
+
+// BalanceAndPositionCcy is BalanceAndPosition narrowed to the given currencies, the channel takes no currency so the
+// balances and the positions margined in other currencies are filtered out locally. The events left with neither are
+// not delivered.
+//
+// https://www.okex.com/docs-v5/en/#websocket-api-private-channel-balance-and-position-channel
+func (p *Private) BalanceAndPositionCcy(ccy []string, ch ...chan *private.BalanceAndPosition) (*Subscription[*private.BalanceAndPosition], error) {
 	m := make(map[string]string)
-	if len(rCh) > 0 && rCh[0] {
-		p.bnpCh = nil
+	keep := make(map[string]bool, len(ccy))
+	for _, c := range ccy {
+		keep[c] = true
 	}
-	return p.Unsubscribe(true, []okex.ChannelName{"balance_and_position"}, m)
+	return subscribeFiltered(p.ClientWs, true, withChannel("balance_and_position", m), ch, func(e *private.BalanceAndPosition) (*private.BalanceAndPosition, bool) {
+		var res []*account.BalanceAndPosition
+		for _, bp := range e.BalanceAndPositions {
+			var bal []*account.BalanceDetails
+			for _, b := range bp.BalData {
+				if keep[b.Ccy] {
+					bal = append(bal, b)
+				}
+			}
+			var pos []*account.Position
+			for _, ps := range bp.PosData {
+				if keep[ps.Ccy] {
+					pos = append(pos, ps)
+				}
+			}
+			if len(bal) == 0 && len(pos) == 0 {
+				continue
+			}
+			bp.BalData, bp.PosData = bal, pos
+			res = append(res, bp)
+		}
+		e.BalanceAndPositions = res
+		return e, len(res) > 0
+	})
 }
 
 // Order
 // Retrieve position information. Initial snapshot will be pushed according to subscription granularity. Data will be pushed when triggered by events such as placing/canceling order, and will also be pushed in regular interval according to subscription granularity.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-order-channel
-func (p *Private) Order(req requests.Order, ch ...chan *private.Order) error {
+func (p *Private) Order(req requests.Order, ch ...chan *private.Order) (*Subscription[*private.Order], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.oCh = ch[0]
-	}
-	return p.Subscribe(true, []okex.ChannelName{"orders"}, m)
+	return subscribe(p.ClientWs, true, withChannel("orders", m), ch)
 }
 
 // UOrder
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-private-channel-order-channel
-func (p *Private) UOrder(req requests.Order, rCh ...bool) error {
+func (p *Private) UOrder(req requests.Order) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.oCh = nil
-	}
-	return p.Unsubscribe(true, []okex.ChannelName{"orders"}, m)
+	return p.drop(true, withChannel("orders", m))
 }
 
-func (p *Private) Process(data []byte, e *events.Basic) bool {
-	if e.Event == "" && e.Arg != nil && e.Data != nil && len(e.Data) > 0 {
-		ch, ok := e.Arg.Get("channel")
-		if !ok {
-			return false
-		}
-		switch ch {
-		case "account":
-			if p.aCh == nil {
-				return false
-			}
-			e := new(private.Account)
-			err := json.Unmarshal(data, &e)
-			if err != nil {
-				return false
-			}
-			p.aCh <- e
-			return true
-		case "positions":
-			if p.pCh == nil {
-				return false
-			}
-			e := new(private.Position)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.pCh <- e
-			return true
-		case "balance_and_position":
-			if p.bnpCh == nil {
-				return false
-			}
-			e := new(private.BalanceAndPosition)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.bnpCh <- e
-			return true
-		case "orders":
-			if p.oCh == nil {
-				return false
-			}
-			e := new(private.Order)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.oCh <- e
-			return true
-		}
+// AlgoOrders
+// Retrieve algo orders (includes trigger order, oco order, conditional order). Data will not be pushed when first subscribed. Data will only be pushed when there are order updates.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-algo-trading-ws-algo-orders-channel
+func (p *Private) AlgoOrders(req requests.AlgoOrder, ch ...chan *private.AlgoOrder) (*Subscription[*private.AlgoOrder], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("orders-algo", m), ch)
+}
+
+// UAlgoOrders
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-algo-trading-ws-algo-orders-channel
+func (p *Private) UAlgoOrders(req requests.AlgoOrder) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("orders-algo", m))
+}
+
+// AdvanceAlgoOrders
+// Retrieve advance algo orders (including Iceberg order, TWAP order, Trailing order). Data will be pushed when first subscribed. Data will be pushed when triggered by events such as placing/canceling order.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-algo-trading-ws-advance-algo-orders-channel
+func (p *Private) AdvanceAlgoOrders(req requests.AdvanceAlgoOrder, ch ...chan *private.AdvanceAlgoOrder) (*Subscription[*private.AdvanceAlgoOrder], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("algo-advance", m), ch)
+}
+
+// UAdvanceAlgoOrders
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-algo-trading-ws-advance-algo-orders-channel
+func (p *Private) UAdvanceAlgoOrders(req requests.AdvanceAlgoOrder) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("algo-advance", m))
+}
+
+// LiquidationWarning
+// Retrieve the position risk warnings. Data will be pushed when the positions of the account are close to liquidation, it is only a warning and should not be used as a trading signal.
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-position-risk-warning
+func (p *Private) LiquidationWarning(req requests.LiquidationWarning, ch ...chan *private.LiquidationWarning) (*Subscription[*private.LiquidationWarning], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("liquidation-warning", m), ch)
+}
+
+// ULiquidationWarning
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-position-risk-warning
+func (p *Private) ULiquidationWarning(req requests.LiquidationWarning) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("liquidation-warning", m))
+}
+
+// PositionRiskWarning is LiquidationWarning under the name of the docs, OKX serves the position risk warnings
+// through the liquidation-warning channel and there is no position-risk-warning channel to subscribe to.
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-position-risk-warning
+func (p *Private) PositionRiskWarning(req requests.PositionRiskWarning, ch ...chan *private.PositionRiskWarning) (*Subscription[*private.PositionRiskWarning], error) {
+	return p.LiquidationWarning(req, ch...)
+}
+
+// UPositionRiskWarning
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-position-risk-warning
+func (p *Private) UPositionRiskWarning(req requests.PositionRiskWarning) error {
+	return p.ULiquidationWarning(req)
+}
+
+// AccountGreeks
+// Retrieve account greeks information. Data will be pushed when triggered by events such as increase/decrease positions or cash balance in account, and will also be pushed in regular interval according to subscription granularity.
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-account-greeks-channel
+func (p *Private) AccountGreeks(req requests.AccountGreeks, ch ...chan *private.AccountGreeks) (*Subscription[*private.AccountGreeks], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("account-greeks", m), ch)
+}
+
+// UAccountGreeks
+//
+// https://www.okx.com/docs-v5/en/#trading-account-websocket-account-greeks-channel
+func (p *Private) UAccountGreeks(req requests.AccountGreeks) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("account-greeks", m))
+}
+
+// DepositInfo
+// Retrieve deposit information. Data will be pushed when triggered by events such as a deposit being credited, it is not pushed when first subscribed.
+//
+// https://www.okx.com/docs-v5/en/#funding-account-websocket-deposit-info-channel
+func (p *Private) DepositInfo(req requests.DepositInfo, ch ...chan *private.DepositInfo) (*Subscription[*private.DepositInfo], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("deposit-info", m), ch)
+}
+
+// UDepositInfo
+//
+// https://www.okx.com/docs-v5/en/#funding-account-websocket-deposit-info-channel
+func (p *Private) UDepositInfo(req requests.DepositInfo) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("deposit-info", m))
+}
+
+// WithdrawalInfo
+// Retrieve withdrawal information. Data will be pushed when triggered by events such as withdrawal status changes, it is not pushed when first subscribed.
+//
+// https://www.okx.com/docs-v5/en/#funding-account-websocket-withdrawal-info-channel
+func (p *Private) WithdrawalInfo(req requests.WithdrawalInfo, ch ...chan *private.WithdrawalInfo) (*Subscription[*private.WithdrawalInfo], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel("withdrawal-info", m), ch)
+}
+
+// UWithdrawalInfo
+//
+// https://www.okx.com/docs-v5/en/#funding-account-websocket-withdrawal-info-channel
+func (p *Private) UWithdrawalInfo(req requests.WithdrawalInfo) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel("withdrawal-info", m))
+}
+
+// GridOrders
+// Retrieve spot, contract or moon grid algo orders depending on req.AlgoOrdType. Data will be pushed when first subscribed. Data will be pushed when triggered by events such as placing/canceling order.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-grid-trading-ws-spot-grid-algo-orders-channel
+func (p *Private) GridOrders(req requests.GridOrder, ch ...chan *private.GridOrder) (*Subscription[*private.GridOrder], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, true, withChannel(gridChannel(req.AlgoOrdType), m), ch)
+}
+
+// UGridOrders
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-grid-trading-ws-spot-grid-algo-orders-channel
+func (p *Private) UGridOrders(req requests.GridOrder) error {
+	m := okex.S2M(req)
+	return p.drop(true, withChannel(gridChannel(req.AlgoOrdType), m))
+}
+
+func gridChannel(algoOrdType okex.AlgoOrderType) okex.ChannelName {
+	switch algoOrdType {
+	case okex.AlgoOrderContractGrid:
+		return "grid-orders-contract"
+	case okex.AlgoOrderMoonGrid:
+		return "grid-orders-moon"
 	}
-	return false
+	return "grid-orders-spot"
 }
@@ -0,0 +1,128 @@
+package ws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/okextest"
+	requests "github.com/dimkus/okex/requests/ws/private"
+)
+
+func TestBalanceAndPositionCcy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := c.Ws.Private.BalanceAndPosition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	usdt, err := c.Ws.Private.BalanceAndPositionCcy([]string{"USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "balance_and_position"}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Push(arg, map[string]any{
+		"eventType": "filled",
+		"balData":   []map[string]string{{"ccy": "BTC", "cashBal": "1"}, {"ccy": "USDT", "cashBal": "100"}},
+		"posData":   []map[string]string{{"instId": "BTC-USDT-SWAP", "ccy": "USDT", "pos": "1"}, {"instId": "BTC-USD-SWAP", "ccy": "BTC", "pos": "1"}},
+	})
+	s.Push(arg, map[string]any{"eventType": "transferred", "balData": []map[string]string{{"ccy": "BTC", "cashBal": "2"}}})
+
+	receive := func(sub <-chan *private.BalanceAndPosition) *private.BalanceAndPosition {
+		t.Helper()
+		select {
+		case e := <-sub:
+			return e
+		case <-ctx.Done():
+			t.Fatal("no event")
+			return nil
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if e := receive(all.C); len(e.BalanceAndPositions) != 1 {
+			t.Fatalf("got %+v", e.BalanceAndPositions)
+		}
+	}
+	e := receive(usdt.C)
+	bp := e.BalanceAndPositions[0]
+	if len(bp.BalData) != 1 || bp.BalData[0].Ccy != "USDT" || len(bp.PosData) != 1 || bp.PosData[0].InstID != "BTC-USDT-SWAP" {
+		t.Fatalf("got %+v %+v", bp.BalData, bp.PosData)
+	}
+	// the second event has nothing in USDT
+	select {
+	case e := <-usdt.C:
+		t.Fatalf("got %+v", e.BalanceAndPositions[0])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAlgoOrders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	algo, err := c.Ws.Private.AlgoOrders(requests.AlgoOrder{InstType: okex.SpotInstrument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	advance, err := c.Ws.Private.AdvanceAlgoOrders(requests.AdvanceAlgoOrder{InstType: okex.AnyInstrument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	algoArg := map[string]string{"channel": "orders-algo", "instType": "SPOT"}
+	advanceArg := map[string]string{"channel": "algo-advance", "instType": "ANY"}
+	for _, arg := range []map[string]string{algoArg, advanceArg} {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+		if !s.SubscribedAt(okextest.BusinessWsPath, arg) {
+			t.Fatalf("%v subscribed off the business endpoint", arg)
+		}
+	}
+
+	s.Push(map[string]string{"channel": "orders-algo", "instType": "SPOT", "uid": "1"},
+		map[string]string{"instId": "BTC-USDT", "algoId": "1", "ordType": "conditional", "state": "live", "sz": "1"})
+	// the events echo the argument of the subscription, ANY included
+	s.Push(map[string]string{"channel": "algo-advance", "instType": "ANY", "uid": "1"},
+		map[string]string{"instId": "BTC-USDT-SWAP", "algoId": "2", "ordType": "iceberg", "state": "effective", "actualSz": "0.5"})
+	select {
+	case e := <-algo.C:
+		if len(e.AlgoOrders) != 1 || e.AlgoOrders[0].AlgoID != "1" || e.AlgoOrders[0].State != okex.OrderState("live") {
+			t.Fatalf("got %+v", e.AlgoOrders)
+		}
+	case <-ctx.Done():
+		t.Fatal("no algo order")
+	}
+	select {
+	case e := <-advance.C:
+		if len(e.AlgoOrders) != 1 || e.AlgoOrders[0].AlgoID != "2" || !e.AlgoOrders[0].ActualSz.Equal("0.5") {
+			t.Fatalf("got %+v", e.AlgoOrders)
+		}
+	case <-ctx.Done():
+		t.Fatal("no advance algo order")
+	}
+	// each channel is routed to its own subscription only
+	select {
+	case e := <-algo.C:
+		t.Fatalf("got %+v", e.AlgoOrders[0])
+	case e := <-advance.C:
+		t.Fatalf("got %+v", e.AlgoOrders[0])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -1,13 +1,9 @@
 package ws
 
 import (
-	"fmt"
 	"github.com/dimkus/okex"
-	"github.com/dimkus/okex/events"
 	"github.com/dimkus/okex/events/public"
 	requests "github.com/dimkus/okex/requests/ws/public"
-	"github.com/goccy/go-json"
-	"strings"
 )
 
 // Public
@@ -15,20 +11,6 @@ import (
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels
 type Public struct {
 	*ClientWs
-	iCh    chan *public.Instruments
-	tCh    chan *public.Tickers
-	oiCh   chan *public.OpenInterest
-	cCh    chan *public.Candlesticks
-	trCh   chan *public.Trades
-	edepCh chan *public.EstimatedDeliveryExercisePrice
-	mpCh   chan *public.MarkPrice
-	mpcCh  chan *public.MarkPriceCandlesticks
-	plCh   chan *public.PriceLimit
-	obCh   chan *public.OrderBook
-	osCh   chan *public.OPTIONSummary
-	frCh   chan *public.FundingRate
-	icCh   chan *public.IndexCandlesticks
-	itCh   chan *public.IndexTickers
 }
 
 // NewPublic returns a pointer to a fresh Public
@@ -40,115 +22,85 @@ func NewPublic(c *ClientWs) *Public {
 // The full instrument list will be pushed for the first time after subscription. Subsequently, the instruments will be pushed if there's any change to the instrument’s state (such as delivery of FUTURES, exercise of OPTION, listing of new contracts / trading pairs, trading suspension, etc.).
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-instruments-channel
-func (p *Public) Instruments(req requests.Instruments, ch ...chan *public.Instruments) error {
+func (p *Public) Instruments(req requests.Instruments, ch ...chan *public.Instruments) (*Subscription[*public.Instruments], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.iCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"instruments"}, m)
+	return subscribe(p.ClientWs, false, withChannel("instruments", m), ch)
 }
 
 // UInstruments
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-instruments-channel
-func (p *Public) UInstruments(req requests.Instruments, rCh ...bool) error {
+func (p *Public) UInstruments(req requests.Instruments) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.iCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"instruments"}, m)
+	return p.drop(false, withChannel("instruments", m))
 }
 
 // Tickers
 // Retrieve the last traded price, bid price, ask price and 24-hour trading volume of instruments. Data will be pushed every 100 ms.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-tickers-channel
-func (p *Public) Tickers(req requests.Tickers, ch ...chan *public.Tickers) error {
+func (p *Public) Tickers(req requests.Tickers, ch ...chan *public.Tickers) (*Subscription[*public.Tickers], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.tCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"tickers"}, m)
+	return subscribe(p.ClientWs, false, withChannel("tickers", m), ch)
 }
 
 // UTickers
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-tickers-channel
-func (p *Public) UTickers(req requests.Tickers, rCh ...bool) error {
+func (p *Public) UTickers(req requests.Tickers) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.tCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"tickers"}, m)
+	return p.drop(false, withChannel("tickers", m))
 }
 
 // OpenInterest
 // Retrieve the open interest. Data will by pushed every 3 seconds.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-open-interest-channel
-func (p *Public) OpenInterest(req requests.OpenInterest, ch ...chan *public.OpenInterest) error {
+func (p *Public) OpenInterest(req requests.OpenInterest, ch ...chan *public.OpenInterest) (*Subscription[*public.OpenInterest], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.oiCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"open-interest"}, m)
+	return subscribe(p.ClientWs, false, withChannel("open-interest", m), ch)
 }
 
 // UOpenInterest
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-open-interest-channel
-func (p *Public) UOpenInterest(req requests.OpenInterest, rCh ...bool) error {
+func (p *Public) UOpenInterest(req requests.OpenInterest) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.oiCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"open-interest"}, m)
+	return p.drop(false, withChannel("open-interest", m))
 }
 
 // Candlesticks
 // Retrieve the open interest. Data will by pushed every 3 seconds.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-candlesticks-channel
-func (p *Public) Candlesticks(req requests.Candlesticks, ch ...chan *public.Candlesticks) error {
+func (p *Public) Candlesticks(req requests.Candlesticks, ch ...chan *public.Candlesticks) (*Subscription[*public.Candlesticks], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.cCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{}, m)
+	return subscribe(p.ClientWs, false, []map[string]string{m}, ch)
 }
 
 // UCandlesticks
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-candlesticks-channel
-func (p *Public) UCandlesticks(req requests.Candlesticks, rCh ...bool) error {
+func (p *Public) UCandlesticks(req requests.Candlesticks) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.cCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{}, m)
+	return p.drop(false, []map[string]string{m})
 }
 
 // Trades
 // Retrieve the recent trades data. Data will be pushed whenever there is a trade.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-trades-channel
-func (p *Public) Trades(req requests.Trades, ch ...chan *public.Trades) error {
+func (p *Public) Trades(req requests.Trades, ch ...chan *public.Trades) (*Subscription[*public.Trades], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.trCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"trades"}, m)
+	return subscribe(p.ClientWs, false, withChannel("trades", m), ch)
 }
 
 // UTrades
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-trades-channel
-func (p *Public) UTrades(req requests.Trades, rCh ...bool) error {
+func (p *Public) UTrades(req requests.Trades) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.trCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"trades"}, m)
+	return p.drop(false, withChannel("trades", m))
 }
 
 // EstimatedDeliveryExercisePrice
@@ -157,94 +109,70 @@ func (p *Public) UTrades(req requests.Trades, rCh ...bool) error {
 // Only the estimated delivery/exercise price will be pushed an hour before delivery/exercise, and will be pushed if there is any price change.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-estimated-delivery-exercise-price-channel
-func (p *Public) EstimatedDeliveryExercisePrice(req requests.EstimatedDeliveryExercisePrice, ch ...chan *public.EstimatedDeliveryExercisePrice) error {
+func (p *Public) EstimatedDeliveryExercisePrice(req requests.EstimatedDeliveryExercisePrice, ch ...chan *public.EstimatedDeliveryExercisePrice) (*Subscription[*public.EstimatedDeliveryExercisePrice], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.edepCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"estimated-price"}, m)
+	return subscribe(p.ClientWs, false, withChannel("estimated-price", m), ch)
 }
 
 // UEstimatedDeliveryExercisePrice
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-estimated-delivery-exercise-price-channel
-func (p *Public) UEstimatedDeliveryExercisePrice(req requests.EstimatedDeliveryExercisePrice, rCh ...bool) error {
+func (p *Public) UEstimatedDeliveryExercisePrice(req requests.EstimatedDeliveryExercisePrice) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.edepCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"estimated-price"}, m)
+	return p.drop(false, withChannel("estimated-price", m))
 }
 
 // MarkPrice
 // Retrieve the mark price. Data will be pushed every 200 ms when the mark price changes, and will be pushed every 10 seconds when the mark price does not change.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-mark-price-channel
-func (p *Public) MarkPrice(req requests.MarkPrice, ch ...chan *public.MarkPrice) error {
+func (p *Public) MarkPrice(req requests.MarkPrice, ch ...chan *public.MarkPrice) (*Subscription[*public.MarkPrice], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.mpCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"mark-price"}, m)
+	return subscribe(p.ClientWs, false, withChannel("mark-price", m), ch)
 }
 
 // UMarkPrice
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-mark-price-channel
-func (p *Public) UMarkPrice(req requests.MarkPrice, rCh ...bool) error {
+func (p *Public) UMarkPrice(req requests.MarkPrice) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.mpCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"mark-price"}, m)
+	return p.drop(false, withChannel("mark-price", m))
 }
 
 // MarkPriceCandlesticks
 // Retrieve the candlesticks data of the mark price. Data will be pushed every 500 ms.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-mark-price-candlesticks-channel
-func (p *Public) MarkPriceCandlesticks(req requests.MarkPriceCandlesticks, ch ...chan *public.MarkPriceCandlesticks) error {
+func (p *Public) MarkPriceCandlesticks(req requests.MarkPriceCandlesticks, ch ...chan *public.MarkPriceCandlesticks) (*Subscription[*public.MarkPriceCandlesticks], error) {
 	m := okex.S2M(req)
 	m["channel"] = "mark-price-" + m["channel"]
-	if len(ch) > 0 {
-		p.mpcCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{}, m)
+	return subscribe(p.ClientWs, false, []map[string]string{m}, ch)
 }
 
 // UMarkPriceCandlesticks
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-mark-price-candlesticks-channel
-func (p *Public) UMarkPriceCandlesticks(req requests.MarkPriceCandlesticks, rCh ...bool) error {
+func (p *Public) UMarkPriceCandlesticks(req requests.MarkPriceCandlesticks) error {
 	m := okex.S2M(req)
 	m["channel"] = "mark-price-" + m["channel"]
-	if len(rCh) > 0 && rCh[0] {
-		p.mpcCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{}, m)
+	return p.drop(false, []map[string]string{m})
 }
 
 // PriceLimit
 // Retrieve the maximum buy price and minimum sell price of the instrument. Data will be pushed every 5 seconds when there are changes in limits, and will not be pushed when there is no changes on limit.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-price-limit-channel
-func (p *Public) PriceLimit(req requests.PriceLimit, ch ...chan *public.PriceLimit) error {
+func (p *Public) PriceLimit(req requests.PriceLimit, ch ...chan *public.PriceLimit) (*Subscription[*public.PriceLimit], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.plCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"price-limit"}, m)
+	return subscribe(p.ClientWs, false, withChannel("price-limit", m), ch)
 }
 
 // UPriceLimit
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-price-limit-channel
-func (p *Public) UPriceLimit(req requests.PriceLimit, rCh ...bool) error {
+func (p *Public) UPriceLimit(req requests.PriceLimit) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.plCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"price-limit"}, m)
+	return p.drop(false, withChannel("price-limit", m))
 }
 
 // OrderBook
@@ -253,313 +181,212 @@ func (p *Public) UPriceLimit(req requests.PriceLimit, rCh ...bool) error {
 // Use books for 400 depth levels, books5 for 5 depth levels, books50-l2-tbt tick-by-tick 50 depth levels, and books-l2-tbt for tick-by-tick 400 depth levels.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-order-book-channel
-func (p *Public) OrderBook(reqs []requests.OrderBook, ch ...chan *public.OrderBook) error {
-	if len(ch) > 0 {
-		p.obCh = ch[0]
-	}
+func (p *Public) OrderBook(reqs []requests.OrderBook, ch ...chan *public.OrderBook) (*Subscription[*public.OrderBook], error) {
 	var subscriptions []map[string]string
 	for _, req := range reqs {
 		m := okex.S2M(req)
 		subscriptions = append(subscriptions, m)
 	}
-	return p.Subscribe(false, []okex.ChannelName{}, subscriptions...)
+	return subscribe(p.ClientWs, false, subscriptions, ch)
 }
 
 // UOrderBook
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-order-book-channel
-func (p *Public) UOrderBook(req requests.OrderBook, rCh ...bool) error {
+func (p *Public) UOrderBook(req requests.OrderBook) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.obCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{okex.ChannelName(req.Channel)}, m)
+	return p.drop(false, []map[string]string{m})
 }
 
 // OPTIONSummary
 // Retrieve detailed pricing information of all OPTION contracts. Data will be pushed at once.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-option-summary-channel
-func (p *Public) OPTIONSummary(req requests.OPTIONSummary, ch ...chan *public.OPTIONSummary) error {
+func (p *Public) OPTIONSummary(req requests.OPTIONSummary, ch ...chan *public.OPTIONSummary) (*Subscription[*public.OPTIONSummary], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.osCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"opt-summary"}, m)
+	return subscribe(p.ClientWs, false, withChannel("opt-summary", m), ch)
 }
 
 // UOPTIONSummary
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-option-summary-channel
-func (p *Public) UOPTIONSummary(req requests.OPTIONSummary, rCh ...bool) error {
+func (p *Public) UOPTIONSummary(req requests.OPTIONSummary) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.osCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"opt-summary"}, m)
+	return p.drop(false, withChannel("opt-summary", m))
 }
 
 // FundingRate
 // Retrieve funding rate. Data will be pushed every minute.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-funding-rate-channel
-func (p *Public) FundingRate(reqs []requests.FundingRate, ch ...chan *public.FundingRate) error {
-	if len(ch) > 0 {
-		p.frCh = ch[0]
-	}
-
+func (p *Public) FundingRate(reqs []requests.FundingRate, ch ...chan *public.FundingRate) (*Subscription[*public.FundingRate], error) {
 	var subscriptions []map[string]string
 	for _, req := range reqs {
 		m := okex.S2M(req)
-		subscriptions = append(subscriptions, m)
+		subscriptions = append(subscriptions, withChannel("funding-rate", m)...)
 	}
-
-	return p.Subscribe(false, []okex.ChannelName{"funding-rate"}, subscriptions...)
+	return subscribe(p.ClientWs, false, subscriptions, ch)
 }
 
 // UFundingRate
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-funding-rate-channel
-func (p *Public) UFundingRate(req requests.FundingRate, rCh ...bool) error {
+func (p *Public) UFundingRate(req requests.FundingRate) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.frCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"funding-rate"}, m)
+	return p.drop(false, withChannel("funding-rate", m))
 }
 
 // IndexCandlesticks
 // Retrieve the candlesticks data of the index. Data will be pushed every 500 ms.
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-index-candlesticks-channel
-func (p *Public) IndexCandlesticks(req requests.IndexCandlesticks, ch ...chan *public.IndexCandlesticks) error {
+func (p *Public) IndexCandlesticks(req requests.IndexCandlesticks, ch ...chan *public.IndexCandlesticks) (*Subscription[*public.IndexCandlesticks], error) {
 	m := okex.S2M(req)
 	m["channel"] = req.Channel
-	if len(ch) > 0 {
-		p.icCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{}, m)
+	return subscribe(p.ClientWs, false, []map[string]string{m}, ch)
 }
 
 // UIndexCandlesticks
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-index-candlesticks-channel
-func (p *Public) UIndexCandlesticks(req requests.IndexCandlesticks, rCh ...bool) error {
+func (p *Public) UIndexCandlesticks(req requests.IndexCandlesticks) error {
 	m := okex.S2M(req)
 	m["channel"] = req.Channel
-	if len(rCh) > 0 && rCh[0] {
-		p.icCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{}, m)
+	return p.drop(false, []map[string]string{m})
 }
 
 // IndexTickers
 // Retrieve index tickers data
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-index-tickers-channel
-func (p *Public) IndexTickers(req requests.IndexTickers, ch ...chan *public.IndexTickers) error {
+func (p *Public) IndexTickers(req requests.IndexTickers, ch ...chan *public.IndexTickers) (*Subscription[*public.IndexTickers], error) {
 	m := okex.S2M(req)
-	if len(ch) > 0 {
-		p.itCh = ch[0]
-	}
-	return p.Subscribe(false, []okex.ChannelName{"index-tickers"}, m)
+	return subscribe(p.ClientWs, false, withChannel("index-tickers", m), ch)
 }
 
 // UIndexTickers
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-public-channels-index-tickers-channel
-func (p *Public) UIndexTickers(req requests.IndexTickers, rCh ...bool) error {
+func (p *Public) UIndexTickers(req requests.IndexTickers) error {
 	m := okex.S2M(req)
-	if len(rCh) > 0 && rCh[0] {
-		p.itCh = nil
-	}
-	return p.Unsubscribe(false, []okex.ChannelName{"index-tickers"}, m)
-}
-
-func (p *Public) Process(data []byte, e *events.Basic) bool {
-	if e.Event == "" && e.Arg != nil && e.Data != nil && len(e.Data) > 0 {
-		ch, ok := e.Arg.Get("channel")
-		if !ok {
-			return false
-		}
-		switch ch {
-		case "instruments":
-			if p.iCh == nil {
-				return false
-			}
-			e := new(public.Instruments)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.iCh <- e
-			return true
-		case "tickers":
-			if p.tCh == nil {
-				return false
-			}
-			e := new(public.Tickers)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.tCh <- e
-			return true
-		case "open-interest":
-			if p.oiCh == nil {
-				return false
-			}
-			e := new(public.OpenInterest)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.oiCh <- e
-			return true
-		case "trades":
-			if p.trCh == nil {
-				return false
-			}
-			e := new(public.Trades)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.trCh <- e
-			return true
-		case "estimated-price":
-			if p.edepCh == nil {
-				return false
-			}
-			e := new(public.EstimatedDeliveryExercisePrice)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.edepCh <- e
-			return true
-		case "mark-price":
-			if p.mpCh == nil {
-				return false
-			}
-			e := new(public.MarkPrice)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.mpCh <- e
-			return true
-		case "price-limit":
-			if p.plCh == nil {
-				return false
-			}
-			e := new(public.PriceLimit)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.plCh <- e
-			return true
-		case "opt-summary":
-			if p.osCh == nil {
-				return false
-			}
-			e := new(public.OPTIONSummary)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.osCh <- e
-			return true
-		case "funding-rate":
-			if p.frCh == nil {
-				return false
-			}
-			e := new(public.FundingRate)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.frCh <- e
-			return true
-		case "index-tickers":
-			if p.itCh == nil {
-				return false
-			}
-			e := new(public.IndexTickers)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.itCh <- e
-			return true
-		case "bbo-tbt":
-			// order book
-			// 1 depth level snapshot will be pushed every time. Snapshot data will be pushed every 10 ms when there are changes in the 1 depth level snapshot.
-			if p.obCh == nil {
-				return false
-			}
-			e := new(public.OrderBook)
-			err := json.Unmarshal(data, e)
-			if err != nil {
-				return false
-			}
-			p.obCh <- e
-			return true
-		default:
-			// special cases
-			// market price candlestick channel
-			chName := fmt.Sprint(ch)
-			// market price channels
-			if strings.Contains(chName, "mark-price-candle") {
-				if p.mpcCh == nil {
-					return false
-				}
-				e := new(public.MarkPriceCandlesticks)
-				err := json.Unmarshal(data, e)
-				if err != nil {
-					return false
-				}
-				p.mpcCh <- e
-				return true
-			} else if strings.Contains(chName, "index-candle") {
-				// index chandlestick channels
-				if p.icCh == nil {
-					return false
-				}
-				e := new(public.IndexCandlesticks)
-				err := json.Unmarshal(data, e)
-				if err != nil {
-					return false
-				}
-				p.icCh <- e
-				return true
-			} else if strings.Contains(chName, "candle") {
-				// candlestick channels
-				if p.cCh == nil {
-					return false
-				}
-				e := new(public.Candlesticks)
-				err := json.Unmarshal(data, &e)
-				if err != nil {
-					return false
-				}
-				p.cCh <- e
-				return true
-			} else if strings.Contains(chName, "books") {
-				// order book channels
-				if p.obCh == nil {
-					return false
-				}
-				e := new(public.OrderBook)
-				err := json.Unmarshal(data, e)
-				if err != nil {
-					return false
-				}
-				p.obCh <- e
-				return true
-			}
-		}
-	}
-	return false
+	return p.drop(false, withChannel("index-tickers", m))
+}
+
+// LiquidationOrders
+// Retrieve the recent liquidation orders. For futures and swaps, each contract will only show a maximum of one order per one-second period.
+//
+// https://www.okx.com/docs-v5/en/#public-data-websocket-liquidation-orders-channel
+func (p *Public) LiquidationOrders(req requests.LiquidationOrders, ch ...chan *public.LiquidationOrders) (*Subscription[*public.LiquidationOrders], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, false, withChannel("liquidation-orders", m), ch)
+}
+
+// ULiquidationOrders
+//
+// https://www.okx.com/docs-v5/en/#public-data-websocket-liquidation-orders-channel
+func (p *Public) ULiquidationOrders(req requests.LiquidationOrders) error {
+	m := okex.S2M(req)
+	return p.drop(false, withChannel("liquidation-orders", m))
+}
+
+// BlockTrades
+// Retrieve the recent block trades data by individual legs. Each leg in a block trade is pushed in a separate update. Data will be pushed whenever there is a block trade.
+//
+// https://www.okx.com/docs-v5/en/#block-trading-websocket-public-channel-public-block-trades-channel
+func (p *Public) BlockTrades(req requests.BlockTrades, ch ...chan *public.BlockTrades) (*Subscription[*public.BlockTrades], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, false, withChannel("public-block-trades", m), ch)
+}
+
+// UBlockTrades
+//
+// https://www.okx.com/docs-v5/en/#block-trading-websocket-public-channel-public-block-trades-channel
+func (p *Public) UBlockTrades(req requests.BlockTrades) error {
+	m := okex.S2M(req)
+	return p.drop(false, withChannel("public-block-trades", m))
+}
+
+// BlockTickers
+// Retrieve the latest block trading volume in the last 24 hours. Data will be pushed when triggered by transaction execution event, and every 100 ms when there are changes.
+//
+// https://www.okx.com/docs-v5/en/#block-trading-websocket-public-channel-block-tickers-channel
+func (p *Public) BlockTickers(req requests.BlockTickers, ch ...chan *public.BlockTickers) (*Subscription[*public.BlockTickers], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, false, withChannel("block-tickers", m), ch)
+}
+
+// UBlockTickers
+//
+// https://www.okx.com/docs-v5/en/#block-trading-websocket-public-channel-block-tickers-channel
+func (p *Public) UBlockTickers(req requests.BlockTickers) error {
+	m := okex.S2M(req)
+	return p.drop(false, withChannel("block-tickers", m))
+}
+
+// OptionTrades
+// Retrieve the recent trades data of the options. Data will be pushed whenever there is a trade, every update contains only one trade.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-option-trades-channel
+func (p *Public) OptionTrades(req requests.OptionTrades, ch ...chan *public.OptionTrades) (*Subscription[*public.OptionTrades], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, false, withChannel("option-trades", m), ch)
+}
+
+// UOptionTrades
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-option-trades-channel
+func (p *Public) UOptionTrades(req requests.OptionTrades) error {
+	m := okex.S2M(req)
+	return p.drop(false, withChannel("option-trades", m))
+}
+
+// CallAuctionDetails
+// Retrieve the call auction details. Data will be pushed when the equilibrium price changes during the call auction, and once when it ends.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-call-auction-details-channel
+func (p *Public) CallAuctionDetails(req requests.CallAuctionDetails, ch ...chan *public.CallAuctionDetails) (*Subscription[*public.CallAuctionDetails], error) {
+	m := okex.S2M(req)
+	return subscribe(p.ClientWs, false, withChannel("call-auction-details", m), ch)
+}
+
+// UCallAuctionDetails
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-call-auction-details-channel
+func (p *Public) UCallAuctionDetails(req requests.CallAuctionDetails) error {
+	m := okex.S2M(req)
+	return p.drop(false, withChannel("call-auction-details", m))
+}
+
+// Status
+// Get the status of system maintenance and push when rescheduling and the system maintenance status and end time changes. First subscription: "Push the latest change data"; every time there is a state change, push the changed content.
+//
+// https://www.okx.com/docs-v5/en/#status-websocket-status-channel
+func (p *Public) Status(ch ...chan *public.Status) (*Subscription[*public.Status], error) {
+	m := make(map[string]string)
+	return subscribe(p.ClientWs, false, withChannel("status", m), ch)
+}
+
+// UStatus
+//
+// https://www.okx.com/docs-v5/en/#status-websocket-status-channel
+func (p *Public) UStatus() error {
+	m := make(map[string]string)
+	return p.drop(false, withChannel("status", m))
+}
+
+// EconomicCalendar
+// Retrieve the macro-economic calendar data within 3 months. Data will be pushed when there is any change. The channel is only available to the users with trading fee tier VIP1 and above, and it is served by the business endpoint.
+//
+// https://www.okx.com/docs-v5/en/#public-data-websocket-economic-calendar-channel
+func (p *Public) EconomicCalendar(ch ...chan *public.EconomicCalendar) (*Subscription[*public.EconomicCalendar], error) {
+	m := make(map[string]string)
+	return subscribe(p.ClientWs, false, withChannel("economic-calendar", m), ch)
+}
+
+// UEconomicCalendar
+//
+// https://www.okx.com/docs-v5/en/#public-data-websocket-economic-calendar-channel
+func (p *Public) UEconomicCalendar() error {
+	m := make(map[string]string)
+	return p.drop(false, withChannel("economic-calendar", m))
 }
@@ -0,0 +1,108 @@
+package ws_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/okextest"
+	requests "github.com/dimkus/okex/requests/ws/public"
+)
+
+// receive returns the next event of the subscription
+func receive[T any](t *testing.T, ctx context.Context, ch <-chan T) T {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-ctx.Done():
+		t.Fatal("no event")
+	}
+	var zero T
+	return zero
+}
+
+func TestPublicChannels(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each channel is subscribed on the endpoint serving it, then one item is pushed and read back from its event
+	tests := []struct {
+		arg  map[string]string
+		path string
+		data any
+		// subscribe subscribes the channel and returns a func reading the field checked of the next event
+		subscribe func() (func() string, error)
+		want      string
+	}{
+		{map[string]string{"channel": "liquidation-orders", "instType": "SWAP"}, okextest.PublicWsPath,
+			map[string]any{"instId": "BTC-USDT-SWAP", "instType": "SWAP", "details": []map[string]string{{"side": "sell", "sz": "2", "bkPx": "100"}}},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.LiquidationOrders(requests.LiquidationOrders{InstType: okex.SwapInstrument})
+				return func() string {
+					e := receive(t, ctx, sub.C)
+					return e.LiquidationOrders[0].InstID + " " + fmt.Sprint(e.LiquidationOrders[0].Details[0].Sz)
+				}, err
+			}, "BTC-USDT-SWAP 2"},
+		{map[string]string{"channel": "status"}, okextest.PublicWsPath,
+			map[string]string{"title": "Spot upgrade", "state": "scheduled", "serviceType": "1"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.Status()
+				return func() string { return receive(t, ctx, sub.C).States[0].State }, err
+			}, "scheduled"},
+		{map[string]string{"channel": "option-trades", "instType": "OPTION", "instFamily": "BTC-USD"}, okextest.PublicWsPath,
+			map[string]string{"instId": "BTC-USD-241227-50000-C", "instFamily": "BTC-USD", "tradeId": "7", "optType": "C"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.OptionTrades(requests.OptionTrades{InstFamily: "BTC-USD", InstType: okex.OptionsInstrument})
+				return func() string { return receive(t, ctx, sub.C).Trades[0].TradeID }, err
+			}, "7"},
+		{map[string]string{"channel": "call-auction-details", "instId": "BTC-USDT"}, okextest.PublicWsPath,
+			map[string]string{"instId": "BTC-USDT", "state": "continuous_trading", "eqPx": "100"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.CallAuctionDetails(requests.CallAuctionDetails{InstID: "BTC-USDT"})
+				return func() string { return receive(t, ctx, sub.C).Details[0].State }, err
+			}, "continuous_trading"},
+		{map[string]string{"channel": "public-block-trades", "instId": "BTC-USDT"}, okextest.BusinessWsPath,
+			map[string]string{"instId": "BTC-USDT", "tradeId": "8", "px": "100", "sz": "5", "side": "buy"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.BlockTrades(requests.BlockTrades{InstID: "BTC-USDT"})
+				return func() string { return receive(t, ctx, sub.C).Trades[0].TradeID }, err
+			}, "8"},
+		{map[string]string{"channel": "block-tickers", "instId": "BTC-USDT"}, okextest.BusinessWsPath,
+			map[string]string{"instId": "BTC-USDT", "instType": "SPOT", "vol24h": "12"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.BlockTickers(requests.BlockTickers{InstID: "BTC-USDT"})
+				return func() string { return fmt.Sprint(receive(t, ctx, sub.C).Tickers[0].Vol24h) }, err
+			}, "12"},
+		{map[string]string{"channel": "economic-calendar"}, okextest.BusinessWsPath,
+			map[string]string{"calendarId": "9", "event": "CPI", "importance": "3"},
+			func() (func() string, error) {
+				sub, err := c.Ws.Public.EconomicCalendar()
+				return func() string { return receive(t, ctx, sub.C).Calendar[0].CalendarID }, err
+			}, "9"},
+	}
+	for _, tt := range tests {
+		next, err := tt.subscribe()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.arg["channel"], err)
+		}
+		if err := s.WaitSubscribed(ctx, tt.arg); err != nil {
+			t.Fatalf("%s: %v", tt.arg["channel"], err)
+		}
+		if !s.SubscribedAt(tt.path, tt.arg) {
+			t.Fatalf("%s: not subscribed at %s", tt.arg["channel"], tt.path)
+		}
+		s.Push(tt.arg, tt.data)
+		if got := next(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.arg["channel"], got, tt.want)
+		}
+	}
+}
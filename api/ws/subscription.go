@@ -0,0 +1,478 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/events"
+	"github.com/goccy/go-json"
+	"sync"
+)
+
+// ErrSubscriptionClosed is returned when a closed subscription is used
+var ErrSubscriptionClosed = errors.New("ws: subscription closed")
+
+// defaultBuffer is the size of the queues of the dropping policies when none is given
+const defaultBuffer = 128
+
+const (
+	// PolicyUnbounded delivers every event in order, the queue has no limit and its memory grows without bound for as
+	// long as the consumer falls behind. It is the default, set a dropping policy on the busy channels.
+	PolicyUnbounded Policy = iota
+	// PolicyDropOldest drops the oldest queued event to make room for a new one
+	PolicyDropOldest
+	// PolicyDropNewest drops the new events while the queue is full
+	PolicyDropNewest
+	// PolicyCoalesce keeps the latest event only, it suits the snapshot channels such as tickers and mark-price
+	PolicyCoalesce
+)
+
+type (
+	// Policy decides what happens to the events of a subscription whose consumer falls behind.
+	//
+	// The events are queued by the subscription and delivered by a goroutine of its own, so a slow consumer never
+	// blocks the connection, whatever the policy.
+	Policy uint8
+
+	// backpressure is the policy of a subscription and the size of its queue
+	backpressure struct {
+		policy Policy
+		size   int
+	}
+
+	// Subscription delivers the events of one or more channel arguments to a single consumer.
+	//
+	//	sub, err := client.Ws.Public.Tickers(requests.Tickers{InstID: "BTC-USDT"})
+	//	defer sub.Close()
+	//	for e := range sub.C {
+	//		log.Println(e.Tickers[0].Last)
+	//	}
+	//
+	// Every subscription gets the events of its own arguments only, so several components can watch the same
+	// channel for different instruments. The server side subscription is kept as long as a subscription needs it.
+	// The events are queued and delivered by a goroutine of the subscription, see Policy for what happens when the
+	// consumer falls behind.
+	Subscription[T any] struct {
+		// C receives the events, it is the channel given to the subscribe method if any
+		C       <-chan T
+		ch      chan T
+		args    []map[string]string
+		closer  func() error
+		reset   func() error
+		bp      backpressure
+		queue   []T
+		dropped uint64
+		wake    chan struct{}
+		done    chan struct{}
+		once    sync.Once
+		err     error
+		mu      sync.Mutex
+	}
+
+	// route is the receiving side of a subscription kept by ClientWs
+	route struct {
+		private bool
+		args    []map[string]string
+		publish func(data []byte) bool
+		// close closes the subscription of the route, once the route has been removed
+		close func()
+	}
+)
+
+// NewSubscription returns a subscription fed through Publish instead of a websocket connection, i.e. by a simulated
+// exchange. closer is called once by Close.
+func NewSubscription[T any](args []map[string]string, closer func() error, ch ...chan T) *Subscription[T] {
+	s := &Subscription[T]{
+		args:   args,
+		closer: closer,
+		bp:     backpressure{policy: PolicyUnbounded},
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if len(ch) > 0 && ch[0] != nil {
+		s.ch = ch[0]
+	} else {
+		// the queue is the buffer, a buffered channel would hold on to stale events
+		s.ch = make(chan T)
+	}
+	s.C = s.ch
+	go s.pump()
+	return s
+}
+
+// SetPolicy changes what happens to the events the consumer falls behind on, size bounds the queue of the dropping
+// policies and defaults to 128, PolicyUnbounded and PolicyCoalesce ignore it
+func (s *Subscription[T]) SetPolicy(policy Policy, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bp = newBackpressure(policy, size)
+	if n := s.bp.limit(); n > 0 && len(s.queue) > n {
+		drop := len(s.queue) - n
+		s.dropped += uint64(drop)
+		if policy == PolicyDropNewest {
+			s.queue = s.queue[:n]
+		} else {
+			s.queue = append(s.queue[:0:0], s.queue[drop:]...)
+		}
+	}
+}
+
+// Dropped returns the number of events the policy dropped so far
+func (s *Subscription[T]) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Pending returns the number of events waiting for the consumer
+func (s *Subscription[T]) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Args returns the channel arguments of the subscription
+func (s *Subscription[T]) Args() []map[string]string {
+	return s.args
+}
+
+// Publish queues v for the consumer according to the policy, it never blocks and reports whether v was queued
+func (s *Subscription[T]) Publish(v T) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	s.mu.Lock()
+	queued := true
+	switch n := s.bp.limit(); {
+	case n == 0 || len(s.queue) < n:
+		s.queue = append(s.queue, v)
+	case s.bp.policy == PolicyDropNewest:
+		queued = false
+		s.dropped++
+	default:
+		// drop-oldest and coalesce make room for the latest event
+		var zero T
+		s.queue[0] = zero
+		s.queue = append(s.queue[1:], v)
+		s.dropped++
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return queued
+}
+
+// pump delivers the queued events until the subscription is closed
+func (s *Subscription[T]) pump() {
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			v := s.queue[0]
+			var zero T
+			s.queue[0] = zero
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			select {
+			case s.ch <- v:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// Done is closed when the subscription is closed, C is never closed as it may be shared with other subscriptions
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Resubscribe unsubscribes and subscribes the arguments again at the server, which pushes fresh snapshots to every
+// subscription of the arguments, i.e. after an order book failed its checksum
+func (s *Subscription[T]) Resubscribe() error {
+	select {
+	case <-s.done:
+		return ErrSubscriptionClosed
+	default:
+	}
+	if s.reset == nil {
+		return nil
+	}
+	return s.reset()
+}
+
+// Close stops the delivery and unsubscribes the arguments no other subscription needs, it is safe to call more than once
+func (s *Subscription[T]) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		if s.closer != nil {
+			s.err = s.closer()
+		}
+	})
+	return s.err
+}
+
+func newBackpressure(policy Policy, size int) backpressure {
+	if size <= 0 {
+		size = defaultBuffer
+	}
+	return backpressure{policy: policy, size: size}
+}
+
+// limit returns the size of the queue, zero when it is unbounded
+func (b backpressure) limit() int {
+	switch b.policy {
+	case PolicyUnbounded:
+		return 0
+	case PolicyCoalesce:
+		return 1
+	}
+	return b.size
+}
+
+// subscribe registers a subscription to the arguments and subscribes the ones no other subscription needed yet
+func subscribe[T any](c *ClientWs, p bool, args []map[string]string, ch []chan T) (*Subscription[T], error) {
+	return subscribeFiltered(c, p, args, ch, nil)
+}
+
+// subscribeFiltered is subscribe for the events filter accepts, filter may also trim the event it returns. The server
+// side subscription is shared with the unfiltered subscriptions of the same arguments.
+func subscribeFiltered[T any](c *ClientWs, p bool, args []map[string]string, ch []chan T, filter func(T) (T, bool)) (*Subscription[T], error) {
+	r := &route{private: p, args: args}
+	s := NewSubscription[T](args, func() error {
+		return c.removeRoute(r)
+	}, ch...)
+	s.bp = c.policy(args)
+	s.reset = func() error {
+		return c.resend(r)
+	}
+	r.close = func() {
+		_ = s.Close()
+	}
+	r.publish = func(data []byte) bool {
+		var e T
+		if err := json.Unmarshal(data, &e); err != nil {
+			return false
+		}
+		if filter != nil {
+			var ok bool
+			if e, ok = filter(e); !ok {
+				// the event is meant for the other subscriptions of the arguments
+				return true
+			}
+		}
+		return s.Publish(e)
+	}
+	if err := c.addRoute(r); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetPolicy sets the policy the new subscriptions to the channel start with, see Subscription.SetPolicy
+func (c *ClientWs) SetPolicy(channel okex.ChannelName, policy Policy, size int) {
+	c.routeMu.Lock()
+	defer c.routeMu.Unlock()
+	c.policies[string(channel)] = newBackpressure(policy, size)
+}
+
+// policy returns the policy set for the channel of the arguments
+func (c *ClientWs) policy(args []map[string]string) backpressure {
+	c.routeMu.RLock()
+	defer c.routeMu.RUnlock()
+	for _, arg := range args {
+		if bp, ok := c.policies[arg["channel"]]; ok {
+			return bp
+		}
+	}
+	return backpressure{policy: PolicyUnbounded}
+}
+
+// withChannel returns the argument of the subscribe methods with its channel set
+func withChannel(channel okex.ChannelName, m map[string]string) []map[string]string {
+	m["channel"] = string(channel)
+	return []map[string]string{m}
+}
+
+// addRoute registers r and subscribes the arguments it is the first one to need
+func (c *ClientWs) addRoute(r *route) error {
+	c.routeMu.Lock()
+	c.routes[r] = struct{}{}
+	fresh := c.acquire(r.private, r.args)
+	c.routeMu.Unlock()
+	if len(fresh) == 0 {
+		return nil
+	}
+	if err := c.Subscribe(r.private, nil, fresh...); err != nil {
+		c.routeMu.Lock()
+		delete(c.routes, r)
+		c.release(r.private, r.args)
+		c.routeMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// removeRoute drops r and unsubscribes the arguments no other route needs anymore
+func (c *ClientWs) removeRoute(r *route) error {
+	c.routeMu.Lock()
+	if _, ok := c.routes[r]; !ok {
+		c.routeMu.Unlock()
+		return nil
+	}
+	delete(c.routes, r)
+	stale := c.release(r.private, r.args)
+	c.routeMu.Unlock()
+	return c.unsubscribe(r.private, stale)
+}
+
+// drop removes the arguments from every route, closing the subscriptions left without any, and unsubscribes them
+func (c *ClientWs) drop(p bool, args []map[string]string) error {
+	keys := make(map[string]bool, len(args))
+	for _, arg := range args {
+		keys[argKey(arg)] = true
+	}
+	var closed []*route
+	c.routeMu.Lock()
+	for r := range c.routes {
+		if r.private != p {
+			continue
+		}
+		kept := r.args[:0:0]
+		for _, arg := range r.args {
+			if !keys[argKey(arg)] {
+				kept = append(kept, arg)
+			}
+		}
+		r.args = kept
+		if len(kept) == 0 {
+			delete(c.routes, r)
+			closed = append(closed, r)
+		}
+	}
+	for key := range keys {
+		delete(c.refs[p], key)
+	}
+	c.routeMu.Unlock()
+	// the routes are gone, closing their subscriptions has nothing left to unsubscribe
+	for _, r := range closed {
+		if r.close != nil {
+			r.close()
+		}
+	}
+	return c.unsubscribe(p, args)
+}
+
+// resend unsubscribes and subscribes the arguments of r again, their references are kept
+func (c *ClientWs) resend(r *route) error {
+	p := r.private
+	c.routeMu.RLock()
+	if _, ok := c.routes[r]; !ok {
+		c.routeMu.RUnlock()
+		return nil
+	}
+	var live []map[string]string
+	for _, arg := range r.args {
+		if c.refs[p][argKey(arg)] > 0 {
+			live = append(live, arg)
+		}
+	}
+	c.routeMu.RUnlock()
+	if len(live) == 0 {
+		return nil
+	}
+	if err := c.unsubscribe(p, live); err != nil {
+		return err
+	}
+	return c.Subscribe(p, nil, live...)
+}
+
+// acquire counts a reference to each argument and returns the ones referenced for the first time, routeMu must be held
+func (c *ClientWs) acquire(p bool, args []map[string]string) []map[string]string {
+	var fresh []map[string]string
+	for _, arg := range args {
+		key := argKey(arg)
+		c.refs[p][key]++
+		if c.refs[p][key] == 1 {
+			fresh = append(fresh, arg)
+		}
+	}
+	return fresh
+}
+
+// release drops a reference to each argument and returns the ones left without any, routeMu must be held
+func (c *ClientWs) release(p bool, args []map[string]string) []map[string]string {
+	var stale []map[string]string
+	for _, arg := range args {
+		key := argKey(arg)
+		if c.refs[p][key] == 0 {
+			continue
+		}
+		c.refs[p][key]--
+		if c.refs[p][key] == 0 {
+			delete(c.refs[p], key)
+			stale = append(stale, arg)
+		}
+	}
+	return stale
+}
+
+// dispatch publishes the channel event to the routes whose arguments it matches
+func (c *ClientWs) dispatch(data []byte, e *events.Basic) bool {
+	if e.Event != "" || e.Arg == nil || len(e.Data) == 0 {
+		return false
+	}
+	c.routeMu.RLock()
+	var matched []*route
+	for r := range c.routes {
+		for _, arg := range r.args {
+			if matches(arg, e.Arg) {
+				matched = append(matched, r)
+				break
+			}
+		}
+	}
+	c.routeMu.RUnlock()
+	delivered := false
+	for _, r := range matched {
+		if r.publish(data) {
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+// matches reports whether the argument of an event belongs to the subscription argument, the server echoes the
+// subscription argument in every event. An instType of ANY is matched as is, it is echoed in the events of the
+// subscriptions made with it only, the ones of the same channel made with SPOT or SWAP are theirs.
+func matches(sub map[string]string, arg *events.Argument) bool {
+	for k, v := range sub {
+		if v == "" {
+			continue
+		}
+		got, ok := arg.Get(k)
+		if !ok {
+			return false
+		}
+		if s, ok := got.(string); ok {
+			if s != v {
+				return false
+			}
+		} else if fmt.Sprint(got) != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package ws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/okextest"
+	requests_private "github.com/dimkus/okex/requests/ws/private"
+	requests "github.com/dimkus/okex/requests/ws/public"
+)
+
+func TestUnsubscribeClosesSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc1, err := c.Ws.Public.Tickers(requests.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc2, err := c.Ws.Public.Tickers(requests.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eth, err := c.Ws.Public.Tickers(requests.Tickers{InstID: "ETH-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc := map[string]string{"channel": "tickers", "instId": "BTC-USDT"}
+	if err := s.WaitSubscribed(ctx, btc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Ws.Public.UTickers(requests.Tickers{InstID: "BTC-USDT"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, done := range []<-chan struct{}{btc1.Done(), btc2.Done()} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			t.Fatal("a subscription left without arguments wasn't closed")
+		}
+	}
+	select {
+	case <-eth.Done():
+		t.Fatal("the subscription of another argument was closed")
+	default:
+	}
+	if err := btc1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for s.Subscribed(btc) {
+		select {
+		case <-ctx.Done():
+			t.Fatal("the argument wasn't unsubscribed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestAnyInstTypeIsMatchedExactly(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anyOrders, err := c.Ws.Private.Order(requests_private.Order{InstType: okex.AnyInstrument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spotOrders, err := c.Ws.Private.Order(requests_private.Order{InstType: okex.SpotInstrument})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anyArg := map[string]string{"channel": "orders", "instType": "ANY"}
+	spotArg := map[string]string{"channel": "orders", "instType": "SPOT"}
+	for _, arg := range []map[string]string{anyArg, spotArg} {
+		if err := s.WaitSubscribed(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the server pushes the spot orders to both subscriptions, each echoing its own argument
+	s.Push(spotArg, map[string]string{"instId": "BTC-USDT", "ordId": "1"})
+	s.Push(anyArg, map[string]string{"instId": "BTC-USDT", "ordId": "1"})
+	for _, sub := range []*ws.Subscription[*private.Order]{anyOrders, spotOrders} {
+		select {
+		case <-sub.C:
+		case <-ctx.Done():
+			t.Fatal("the push wasn't received")
+		}
+	}
+	select {
+	case e := <-anyOrders.C:
+		t.Fatalf("got %+v twice on the ANY subscription", e.Orders[0])
+	case e := <-spotOrders.C:
+		t.Fatalf("got %+v twice on the SPOT subscription", e.Orders[0])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -1,20 +1,76 @@
 package ws
 
 import (
+	"context"
+	"errors"
 	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/events"
 	requests "github.com/dimkus/okex/requests/ws/trade"
+	"github.com/dimkus/okex/responses"
+	responses_trade "github.com/dimkus/okex/responses/trade"
+	"github.com/goccy/go-json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// replyTTL is how long the reply of an operation sent by an Async variant is awaited, the futures never waited on are
+// forgotten after it
+var replyTTL = time.Minute
+
+// ErrReplyExpired is returned by Future.Wait when no reply arrived within a minute of the operation
+var ErrReplyExpired = errors.New("ws: no reply to the operation")
+
+// orderEndpoints maps order operations to the rest endpoints they share the rate limit with
+var orderEndpoints = map[okex.Operation]string{
+	okex.OrderOperation:            "/api/v5/trade/order",
+	okex.BatchOrderOperation:       "/api/v5/trade/batch-orders",
+	okex.CancelOrderOperation:      "/api/v5/trade/cancel-order",
+	okex.BatchCancelOrderOperation: "/api/v5/trade/cancel-batch-orders",
+	okex.MassCancelOperation:       "/api/v5/trade/mass-cancel",
+	okex.AmendOrderOperation:       "/api/v5/trade/amend-order",
+	okex.BatchAmendOrderOperation:  "/api/v5/trade/amend-batch-orders",
+}
+
 // Trade
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-trade
 type Trade struct {
 	*ClientWs
+	pending map[string]*waiter
+	seq     uint64
+	mu      sync.Mutex
+}
+
+// TradeI is the order surface of Trade, which paper.WsTrade implements as well
+type TradeI interface {
+	PlaceOrder(req ...requests.PlaceOrder) error
+	PlaceOrderWait(ctx context.Context, req ...requests.PlaceOrder) (responses_trade.PlaceOrder, error)
+	CancelOrder(req ...requests.CancelOrder) error
+	CancelOrderWait(ctx context.Context, req ...requests.CancelOrder) (responses_trade.CancelOrder, error)
+	AmendOrder(req ...requests.AmendOrder) error
+	AmendOrderWait(ctx context.Context, req ...requests.AmendOrder) (responses_trade.AmendOrder, error)
+}
+
+// Future is the pending reply of an order operation
+type Future[T any] struct {
+	ID string
+	op okex.Operation
+	w  *waiter
+	t  *Trade
+}
+
+// waiter receives the reply of an operation, expired is closed once replyTTL elapsed without it
+type waiter struct {
+	ch      chan []byte
+	expired chan struct{}
+	timer   *time.Timer
 }
 
 // NewTrade returns a pointer to a fresh Trade
 func NewTrade(c *ClientWs) *Trade {
-	return &Trade{ClientWs: c}
+	return &Trade{ClientWs: c, pending: make(map[string]*waiter)}
 }
 
 // PlaceOrder
@@ -26,15 +82,26 @@ func NewTrade(c *ClientWs) *Trade {
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-trade-place-multiple-orders
 func (c *Trade) PlaceOrder(req ...requests.PlaceOrder) error {
-	tmpArgs := make([]map[string]string, len(req))
-	op := okex.OrderOperation
-	if len(req) > 1 {
-		op = okex.BatchOrderOperation
+	_, _, err := c.placeOrder(req, false)
+	return err
+}
+
+// PlaceOrderAsync places the orders and returns a Future of the reply
+func (c *Trade) PlaceOrderAsync(req ...requests.PlaceOrder) (*Future[responses_trade.PlaceOrder], error) {
+	op, id, err := c.placeOrder(req, true)
+	if err != nil {
+		return nil, err
 	}
-	for i, order := range req {
-		tmpArgs[i] = okex.S2M(order)
+	return &Future[responses_trade.PlaceOrder]{ID: id, op: op, w: c.reply(id), t: c}, nil
+}
+
+// PlaceOrderWait places the orders and blocks until the reply arrives or the context is done
+func (c *Trade) PlaceOrderWait(ctx context.Context, req ...requests.PlaceOrder) (responses_trade.PlaceOrder, error) {
+	f, err := c.PlaceOrderAsync(req...)
+	if err != nil {
+		return responses_trade.PlaceOrder{}, err
 	}
-	return c.Send(true, op, tmpArgs, map[string]string{"id": req[0].ID})
+	return f.Wait(ctx)
 }
 
 // CancelOrder
@@ -46,15 +113,53 @@ func (c *Trade) PlaceOrder(req ...requests.PlaceOrder) error {
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-trade-cancel-multiple-orders
 func (c *Trade) CancelOrder(req ...requests.CancelOrder) error {
-	tmpArgs := make([]map[string]string, len(req))
-	op := okex.CancelOrderOperation
-	if len(req) > 1 {
-		op = okex.BatchCancelOrderOperation
+	_, _, err := c.cancelOrder(req, false)
+	return err
+}
+
+// CancelOrderAsync cancels the orders and returns a Future of the reply
+func (c *Trade) CancelOrderAsync(req ...requests.CancelOrder) (*Future[responses_trade.CancelOrder], error) {
+	op, id, err := c.cancelOrder(req, true)
+	if err != nil {
+		return nil, err
 	}
-	for i, order := range req {
-		tmpArgs[i] = okex.S2M(order)
+	return &Future[responses_trade.CancelOrder]{ID: id, op: op, w: c.reply(id), t: c}, nil
+}
+
+// CancelOrderWait cancels the orders and blocks until the reply arrives or the context is done
+func (c *Trade) CancelOrderWait(ctx context.Context, req ...requests.CancelOrder) (responses_trade.CancelOrder, error) {
+	f, err := c.CancelOrderAsync(req...)
+	if err != nil {
+		return responses_trade.CancelOrder{}, err
 	}
-	return c.Send(true, op, tmpArgs, map[string]string{"id": req[0].ID})
+	return f.Wait(ctx)
+}
+
+// MassCancel
+// Cancel all the MMP pending orders of an instrument family.
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-ws-mass-cancel-order
+func (c *Trade) MassCancel(req requests.MassCancel) error {
+	_, _, err := c.massCancel(req, false)
+	return err
+}
+
+// MassCancelAsync cancels the orders of the instrument family and returns a Future of the reply
+func (c *Trade) MassCancelAsync(req requests.MassCancel) (*Future[responses_trade.MassCancel], error) {
+	op, id, err := c.massCancel(req, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Future[responses_trade.MassCancel]{ID: id, op: op, w: c.reply(id), t: c}, nil
+}
+
+// MassCancelWait cancels the orders of the instrument family and blocks until the reply arrives or the context is done
+func (c *Trade) MassCancelWait(ctx context.Context, req requests.MassCancel) (responses_trade.MassCancel, error) {
+	f, err := c.MassCancelAsync(req)
+	if err != nil {
+		return responses_trade.MassCancel{}, err
+	}
+	return f.Wait(ctx)
 }
 
 // AmendOrder
@@ -66,13 +171,186 @@ func (c *Trade) CancelOrder(req ...requests.CancelOrder) error {
 //
 // https://www.okex.com/docs-v5/en/#websocket-api-trade-amend-multiple-orders
 func (c *Trade) AmendOrder(req ...requests.AmendOrder) error {
+	_, _, err := c.amendOrder(req, false)
+	return err
+}
+
+// AmendOrderAsync amends the orders and returns a Future of the reply
+func (c *Trade) AmendOrderAsync(req ...requests.AmendOrder) (*Future[responses_trade.AmendOrder], error) {
+	op, id, err := c.amendOrder(req, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Future[responses_trade.AmendOrder]{ID: id, op: op, w: c.reply(id), t: c}, nil
+}
+
+// AmendOrderWait amends the orders and blocks until the reply arrives or the context is done
+func (c *Trade) AmendOrderWait(ctx context.Context, req ...requests.AmendOrder) (responses_trade.AmendOrder, error) {
+	f, err := c.AmendOrderAsync(req...)
+	if err != nil {
+		return responses_trade.AmendOrder{}, err
+	}
+	return f.Wait(ctx)
+}
+
+// Process routes the replies of operations sent by the Async and Wait variants to their futures
+func (c *Trade) Process(data []byte, e *events.Basic) bool {
+	if e.ID == "" || e.Event != "" {
+		return false
+	}
+	c.mu.Lock()
+	w, ok := c.pending[e.ID]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case w.ch <- data:
+	default:
+		// a second reply with the same id is dropped rather than blocking the receiver
+	}
+	return true
+}
+
+// Wait blocks until the reply arrives, the context is done or a minute passed since the operation was sent.
+//
+// Failed orders are reported as *okex.APIError with a sub error per order.
+func (f *Future[T]) Wait(ctx context.Context) (res T, err error) {
+	defer f.t.forget(f.ID, f.w)
+	select {
+	case data := <-f.w.ch:
+		err = decodeReply(data, &res, f.op)
+	case <-f.w.expired:
+		err = ErrReplyExpired
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return
+}
+
+func (c *Trade) placeOrder(req []requests.PlaceOrder, register bool) (okex.Operation, string, error) {
+	op := okex.OrderOperation
+	if len(req) > 1 {
+		op = okex.BatchOrderOperation
+	}
 	tmpArgs := make([]map[string]string, len(req))
+	instIDs := make([]string, len(req))
+	for i, order := range req {
+		tmpArgs[i] = okex.S2M(order)
+		instIDs[i] = order.InstID
+	}
+	id, err := c.send(op, tmpArgs, instIDs, req[0].ID, register)
+	return op, id, err
+}
+
+func (c *Trade) cancelOrder(req []requests.CancelOrder, register bool) (okex.Operation, string, error) {
+	op := okex.CancelOrderOperation
+	if len(req) > 1 {
+		op = okex.BatchCancelOrderOperation
+	}
+	tmpArgs := make([]map[string]string, len(req))
+	instIDs := make([]string, len(req))
+	for i, order := range req {
+		tmpArgs[i] = okex.S2M(order)
+		instIDs[i] = order.InstID
+	}
+	id, err := c.send(op, tmpArgs, instIDs, req[0].ID, register)
+	return op, id, err
+}
+
+func (c *Trade) massCancel(req requests.MassCancel, register bool) (okex.Operation, string, error) {
+	op := okex.MassCancelOperation
+	id, err := c.send(op, []map[string]string{okex.S2M(req)}, nil, req.ID, register)
+	return op, id, err
+}
+
+func (c *Trade) amendOrder(req []requests.AmendOrder, register bool) (okex.Operation, string, error) {
 	op := okex.AmendOrderOperation
 	if len(req) > 1 {
 		op = okex.BatchAmendOrderOperation
 	}
+	tmpArgs := make([]map[string]string, len(req))
+	instIDs := make([]string, len(req))
 	for i, order := range req {
 		tmpArgs[i] = okex.S2M(order)
+		instIDs[i] = order.InstID
+	}
+	id, err := c.send(op, tmpArgs, instIDs, req[0].ID, register)
+	return op, id, err
+}
+
+// send the operation, an empty id is replaced by a generated one.
+// Registered ids get their reply routed to a future instead of the global channels.
+func (c *Trade) send(op okex.Operation, args []map[string]string, instIDs []string, id string, register bool) (string, error) {
+	if id == "" {
+		id = c.nextID()
+	}
+	if err := c.wait(op, instIDs); err != nil {
+		return id, err
+	}
+	var w *waiter
+	if register {
+		w = &waiter{ch: make(chan []byte, 1), expired: make(chan struct{})}
+		c.mu.Lock()
+		c.pending[id] = w
+		w.timer = time.AfterFunc(replyTTL, func() {
+			close(w.expired)
+			c.forget(id, w)
+		})
+		c.mu.Unlock()
+	}
+	if err := c.Send(true, op, args, map[string]string{"id": id}); err != nil {
+		if w != nil {
+			c.forget(id, w)
+		}
+		return id, err
+	}
+	return id, nil
+}
+
+func (c *Trade) wait(op okex.Operation, instIDs []string) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(c.ctx, orderEndpoints[op], instIDs...)
+}
+
+func (c *Trade) reply(id string) *waiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[id]
+}
+
+// forget stops routing the replies of id to w, a later operation reusing the id keeps its own waiter
+func (c *Trade) forget(id string, w *waiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.timer.Stop()
+	if c.pending[id] == w {
+		delete(c.pending, id)
+	}
+}
+
+// nextID returns an alphanumeric id that is unique within the process
+func (c *Trade) nextID() string {
+	n := atomic.AddUint64(&c.seq, 1)
+	return strconv.FormatInt(time.Now().UnixMilli(), 36) + strconv.FormatUint(n, 36)
+}
+
+func decodeReply(data []byte, v any, op okex.Operation) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	vBasic, ok := v.(responses.BasicI)
+	if !ok {
+		return nil
+	}
+	var subErrors []*okex.SubError
+	if vBatch, ok := v.(responses.BatchI); ok {
+		subErrors = vBatch.GetSubErrors()
+	}
+	if vBasic.GetCode() != 0 || len(subErrors) > 0 {
+		return &okex.APIError{Code: vBasic.GetCode(), Msg: vBasic.GetMsg(), Endpoint: string(op), SubErrors: subErrors}
 	}
-	return c.Send(true, op, tmpArgs, map[string]string{"id": req[0].ID})
+	return nil
 }
@@ -0,0 +1,147 @@
+package ws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/okextest"
+	requests_private "github.com/dimkus/okex/requests/ws/private"
+	requests "github.com/dimkus/okex/requests/ws/trade"
+)
+
+func order(id, clOrdID string) requests.PlaceOrder {
+	return requests.PlaceOrder{ID: id, InstID: "BTC-USDT", ClOrdID: clOrdID, Sz: "1", Px: "100",
+		TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit}
+}
+
+func TestFutureReceivesItsReply(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Ws.Trade.PlaceOrderWait(ctx, order("", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.PlaceOrders) != 1 || res.PlaceOrders[0].ClOrdID != "a" {
+		t.Fatalf("got %+v", res.PlaceOrders)
+	}
+	if n := c.Ws.Trade.PendingReplies(); n != 0 {
+		t.Fatalf("%d replies still pending after Wait", n)
+	}
+}
+
+func TestDuplicateReplyDoesNotBlockReceiver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := c.Ws.Private.Account(requests_private.Account{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "account"}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := c.Ws.Trade.PlaceOrderAsync(order("dup", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the server acknowledges the order, then the same reply comes twice more before the future is waited on
+	reply := []byte(`{"id":"dup","op":"order","code":"0","msg":"","data":[{"ordId":"1","clOrdId":"a","sCode":"0","sMsg":""}]}`)
+	s.PushRaw(true, reply)
+	s.PushRaw(true, reply)
+	s.Push(arg, map[string]string{"totalEq": "100"})
+	select {
+	case <-account.C:
+	case <-ctx.Done():
+		t.Fatal("the receiver is blocked by the duplicate replies")
+	}
+	if _, err := f.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnwaitedFutureExpires(t *testing.T) {
+	defer ws.SetReplyTTL(50 * time.Millisecond)()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := c.Ws.Trade.PlaceOrderAsync(order("", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Ws.Trade.PendingReplies() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d replies never expired", c.Ws.Trade.PendingReplies())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a future whose reply never comes fails with ErrReplyExpired instead of waiting for the context
+	s.HandleOp(okex.OrderOperation, func([]map[string]any) (int, string, any) {
+		time.Sleep(time.Second)
+		return 0, "", []any{}
+	})
+	f, err := c.Ws.Trade.PlaceOrderAsync(order("", "late"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Wait(ctx); err != ws.ErrReplyExpired {
+		t.Fatalf("got %v, want ErrReplyExpired", err)
+	}
+}
+
+func TestMassCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := make(chan []map[string]any, 1)
+	s.HandleOp(okex.MassCancelOperation, func(a []map[string]any) (int, string, any) {
+		args <- a
+		return 0, "", []map[string]any{{"result": true}}
+	})
+	res, err := c.Ws.Trade.MassCancelWait(ctx, requests.MassCancel{InstType: okex.OptionsInstrument, InstFamily: "BTC-USD", LockInterval: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.MassCancels) != 1 || !res.MassCancels[0].Result {
+		t.Fatalf("got %+v", res.MassCancels)
+	}
+	a := <-args
+	if len(a) != 1 || a[0]["instType"] != "OPTION" || a[0]["instFamily"] != "BTC-USD" || a[0]["lockInterval"] != "5000" {
+		t.Fatalf("got the arguments %v", a)
+	}
+
+	s.FailOp(okex.MassCancelOperation, 51000, "Parameter instFamily error", 1)
+	var e *okex.APIError
+	if _, err := c.Ws.Trade.MassCancelWait(ctx, requests.MassCancel{InstType: okex.OptionsInstrument}); !errors.As(err, &e) || e.Code != 51000 {
+		t.Fatalf("got %v, want the rejection", err)
+	}
+}
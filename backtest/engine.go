@@ -0,0 +1,169 @@
+// Package backtest replays the market history through strategy code and simulates the fills of its orders.
+//
+//	store := backtest.NewStore(client.Rest.Market, "testdata")
+//	candles, _ := store.Candles(ctx, "BTC-USDT", okex.Bar1H, from, to)
+//	e := backtest.NewEngine(backtest.Config{Quote: "USDT", Exchange: paper.Config{TakerFee: 0.001, Balances: map[string]float64{"USDT": 10000}}})
+//	_ = e.AddCandles("BTC-USDT", okex.Bar1H, candles)
+//	report, _ := e.Run(ctx, strategy)
+//
+// Orders are placed through Engine.Trade with the request types of rest.Trade, so the strategy code is shared with
+// the live and the paper exchanges.
+package backtest
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/events/public"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/paper"
+	"math"
+	"sort"
+	"time"
+)
+
+type (
+	// Strategy receives the replayed events, the clock of the engine is set to the time of the event
+	Strategy interface {
+		OnCandlesticks(e *public.Candlesticks)
+		OnTrades(e *public.Trades)
+	}
+
+	// Config of the backtest
+	Config struct {
+		// Exchange configures the simulated exchange, its Clock is replaced with the virtual clock
+		Exchange paper.Config
+		// Quote is the currency the equity is valued in, i.e. USDT
+		Quote string
+	}
+
+	// Engine replays the events on a virtual clock and matches the orders of the strategy against them
+	Engine struct {
+		// Trade places the orders of the strategy
+		Trade    rest.TradeI
+		Exchange *paper.Exchange
+		cfg      Config
+		events   []*event
+		now      time.Time
+	}
+
+	event struct {
+		at     time.Time
+		instID string
+		bar    okex.BarSize
+		candle *market.Candle
+		trade  *market.Trade
+	}
+)
+
+// NewEngine returns a pointer to a fresh Engine
+func NewEngine(cfg Config) *Engine {
+	e := &Engine{cfg: cfg}
+	cfg.Exchange.Clock = e.Now
+	e.Exchange = paper.NewExchange(cfg.Exchange)
+	e.Trade = e.Exchange.Trade
+	return e
+}
+
+// Now returns the time of the virtual clock
+func (e *Engine) Now() time.Time {
+	return e.now
+}
+
+// AddCandles schedules the candles of the instrument, each one is delivered when it closes
+func (e *Engine) AddCandles(instID string, bar okex.BarSize, candles []*market.Candle) error {
+	for _, c := range candles {
+		end, err := barEnd(time.Time(c.TS), bar)
+		if err != nil {
+			return err
+		}
+		e.events = append(e.events, &event{at: end, instID: instID, bar: bar, candle: c})
+	}
+	return nil
+}
+
+// AddTrades schedules the trades
+func (e *Engine) AddTrades(trades []*market.Trade) {
+	for _, t := range trades {
+		e.events = append(e.events, &event{at: time.Time(t.TS), instID: t.InstID, trade: t})
+	}
+}
+
+// Run replays the scheduled events in chronological order and reports the performance of the strategy.
+//
+// The fills of each event happen before it is delivered, so the strategy reacts to a candle once it closed.
+// Within a candle the price goes from the open to the nearest extreme, then to the other one and to the close, the
+// fills along the path add up to the volume of the candle at most.
+func (e *Engine) Run(ctx context.Context, s Strategy) (*Report, error) {
+	sort.SliceStable(e.events, func(i, j int) bool { return e.events[i].at.Before(e.events[j].at) })
+	r := &Report{Quote: e.cfg.Quote, Fees: make(map[string]float64)}
+	for i, ev := range e.events {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		e.now = ev.at
+		if ev.candle != nil {
+			e.applyCandle(ev)
+		} else {
+			e.Exchange.ApplyTrade(ev.trade)
+		}
+		if i == 0 {
+			r.Start = ev.at
+			r.InitialEquity = e.equity()
+		}
+
+		if ev.candle != nil {
+			s.OnCandlesticks(&public.Candlesticks{Arg: argument("candle"+string(ev.bar), ev.instID), Candles: []*market.Candle{ev.candle}})
+		} else {
+			s.OnTrades(&public.Trades{Arg: argument("trades", ev.instID), Trades: []*market.Trade{ev.trade}})
+		}
+		r.record(ev.at, e.equity())
+	}
+	r.finish(e.Exchange)
+	return r, nil
+}
+
+// applyCandle walks the price path of the candle, each point offers the volume the previous ones left unfilled
+func (e *Engine) applyCandle(ev *event) {
+	c := ev.candle
+	path := []struct {
+		px   float64
+		side okex.TradeSide
+	}{{c.O, ""}, {c.L, okex.TradeSellSide}, {c.H, okex.TradeBuySide}, {c.C, ""}}
+	if c.C < c.O {
+		path[1], path[2] = path[2], path[1]
+	}
+	left := c.Vol
+	for _, p := range path {
+		left -= e.Exchange.ApplyTrade(&market.Trade{InstID: ev.instID, Px: okex.JSONFloat64(p.px), Sz: okex.JSONFloat64(math.Max(left, 0)), Side: p.side, TS: okex.JSONTime(ev.at)})
+	}
+}
+
+// equity values the balances and the positions in the quote currency, the currencies without a price are left out
+func (e *Engine) equity() float64 {
+	var total float64
+	value := func(ccy string, amount float64) {
+		if ccy == e.cfg.Quote {
+			total += amount
+		} else if px, ok := e.Exchange.Mark(ccy + "-" + e.cfg.Quote); ok {
+			total += amount * px
+		}
+	}
+	for ccy, cash := range e.Exchange.Balances() {
+		value(ccy, cash)
+	}
+	for _, p := range e.Exchange.Positions() {
+		value(p.Ccy, float64(p.Upl))
+	}
+	if math.IsNaN(total) {
+		return 0
+	}
+	return total
+}
+
+func argument(channel, instID string) *events.Argument {
+	return events.NewArgument(map[string]interface{}{"channel": channel, "instId": instID})
+}
@@ -0,0 +1,79 @@
+package backtest_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/backtest"
+	"github.com/dimkus/okex/events/public"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/paper"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+)
+
+// bidder places a bid on the first candle it receives
+type bidder struct {
+	e   *backtest.Engine
+	req requests.PlaceOrder
+	err error
+}
+
+func (b *bidder) OnCandlesticks(*public.Candlesticks) {
+	if b.req.InstID == "" {
+		return
+	}
+	_, b.err = b.e.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{b.req})
+	b.req.InstID = ""
+}
+
+func (b *bidder) OnTrades(*public.Trades) {}
+
+func TestRun(t *testing.T) {
+	e := backtest.NewEngine(backtest.Config{Quote: "USDT", Exchange: paper.Config{MakerFee: 0.001, Balances: map[string]float64{"USDT": 10000}}})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(i int, o, h, l, c, vol float64) *market.Candle {
+		return &market.Candle{O: o, H: h, L: l, C: c, Vol: vol, TS: okex.JSONTime(start.Add(time.Duration(i) * time.Minute))}
+	}
+	err := e.AddCandles("BTC-USDT", okex.Bar1m, []*market.Candle{
+		candle(0, 100, 101, 99, 100, 10),
+		// the bid is crossed at the low and at the close, the candle trades 2 only
+		candle(1, 100, 100, 90, 92, 2),
+		candle(2, 96, 98, 96, 98, 10),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &bidder{e: e, req: requests.PlaceOrder{InstID: "BTC-USDT", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit, Sz: "5", Px: "95"}}
+	r, err := e.Run(context.Background(), s)
+	if err != nil || s.err != nil {
+		t.Fatal(err, s.err)
+	}
+
+	if r.Fills != 1 || r.Orders != 1 || r.FilledOrders != 1 {
+		t.Fatalf("got %d fills of %d orders, %d filled, want a single fill", r.Fills, r.Orders, r.FilledOrders)
+	}
+	if o := e.Exchange.Orders()[0]; !o.AccFillSz.Equal("2") {
+		t.Fatalf("got %s filled, want the volume of the candle", o.AccFillSz)
+	}
+	if len(r.Fees) != 1 || !near(r.Fees["BTC"], 0.002) {
+		t.Fatalf("got fees %v, want 0.002 BTC", r.Fees)
+	}
+	// 9810 USDT and 1.998 BTC, valued at the close of 92 then of 98
+	low, final := 9810+1.998*92, 9810+1.998*98
+	if !near(r.InitialEquity, 10000) || !near(r.FinalEquity, final) || !near(r.PnL, final-10000) {
+		t.Fatalf("got equity %v to %v, pnl %v, want 10000 to %v", r.InitialEquity, r.FinalEquity, r.PnL, final)
+	}
+	if !near(r.MaxDrawdown, 10000-low) || !near(r.MaxDrawdownPct, (10000-low)/10000) {
+		t.Fatalf("got drawdown %v (%v), want %v", r.MaxDrawdown, r.MaxDrawdownPct, 10000-low)
+	}
+	if len(r.Equity) != 3 || !r.Start.Equal(start.Add(time.Minute)) || !r.End.Equal(start.Add(3*time.Minute)) {
+		t.Fatalf("got %d points from %s to %s", len(r.Equity), r.Start, r.End)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
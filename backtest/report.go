@@ -0,0 +1,78 @@
+package backtest
+
+import (
+	"github.com/dimkus/okex/paper"
+	"time"
+)
+
+type (
+	// Report is the performance of a backtest, the amounts are in the quote currency unless stated otherwise
+	Report struct {
+		Quote         string
+		Start         time.Time
+		End           time.Time
+		InitialEquity float64
+		FinalEquity   float64
+		PnL           float64
+		// Equity is the PnL curve, a point per event time
+		Equity []Point
+		// MaxDrawdown is the largest fall of the equity from a previous peak, MaxDrawdownPct is relative to that peak
+		MaxDrawdown    float64
+		MaxDrawdownPct float64
+		// Fees are the fees paid keyed by currency, rebates are negative
+		Fees map[string]float64
+		// Orders is the number of orders placed, FilledOrders the number of those filled at least partially
+		Orders       int
+		FilledOrders int
+		FillRatio    float64
+		Fills        int
+	}
+
+	// Point of the equity curve
+	Point struct {
+		Time   time.Time
+		Equity float64
+	}
+)
+
+func (r *Report) record(t time.Time, equity float64) {
+	if n := len(r.Equity); n > 0 && r.Equity[n-1].Time.Equal(t) {
+		r.Equity[n-1].Equity = equity
+		return
+	}
+	r.Equity = append(r.Equity, Point{Time: t, Equity: equity})
+}
+
+func (r *Report) finish(x *paper.Exchange) {
+	peak := r.InitialEquity
+	for _, p := range r.Equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if dd := peak - p.Equity; dd > r.MaxDrawdown {
+			r.MaxDrawdown = dd
+			if peak > 0 {
+				r.MaxDrawdownPct = dd / peak
+			}
+		}
+	}
+	if n := len(r.Equity); n > 0 {
+		r.End = r.Equity[n-1].Time
+		r.FinalEquity = r.Equity[n-1].Equity
+		r.PnL = r.FinalEquity - r.InitialEquity
+	}
+
+	for _, f := range x.Fills() {
+		r.Fills++
+		r.Fees[f.FeeCcy] -= f.Fee.Float64()
+	}
+	for _, o := range x.Orders() {
+		r.Orders++
+		if o.AccFillSz.Sign() > 0 {
+			r.FilledOrders++
+		}
+	}
+	if r.Orders > 0 {
+		r.FillRatio = float64(r.FilledOrders) / float64(r.Orders)
+	}
+}
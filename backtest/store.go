@@ -0,0 +1,266 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/models/market"
+	requests "github.com/dimkus/okex/requests/rest/market"
+	"github.com/goccy/go-json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Store downloads the history from the market data endpoints and caches it as json files in a directory
+	Store struct {
+		market *rest.Market
+		dir    string
+	}
+
+	// candleFile is the cache of the candles of an instrument, it covers every candle opened between From and To
+	candleFile struct {
+		From    int64      `json:"from"`
+		To      int64      `json:"to"`
+		Candles [][]string `json:"candles"`
+	}
+
+	// tradeRecord is a trade in the format of the exchange, so that it decodes into market.Trade
+	tradeRecord struct {
+		InstID  string `json:"instId"`
+		TradeID string `json:"tradeId"`
+		Px      string `json:"px"`
+		Sz      string `json:"sz"`
+		Side    string `json:"side"`
+		TS      string `json:"ts"`
+	}
+)
+
+// NewStore returns a pointer to a fresh Store caching in dir, m may be nil to use the cache only
+func NewStore(m *rest.Market, dir string) *Store {
+	return &Store{market: m, dir: dir}
+}
+
+// Candles returns the candles of the instrument opened between from and to, oldest first.
+//
+// Only the ranges missing from the cache are downloaded, the candle still in progress is never cached.
+func (s *Store) Candles(ctx context.Context, instID string, bar okex.BarSize, from, to time.Time) ([]*market.Candle, error) {
+	now := time.Now()
+	end, err := barEnd(now, bar)
+	if err != nil {
+		return nil, err
+	}
+	// the candle in progress opened less than a bar ago
+	if latest := now.Add(-end.Sub(now)); to.After(latest) {
+		to = latest
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%s.%s.json", instID, barName(bar)))
+	f := new(candleFile)
+	if err := s.load(path, f); err != nil {
+		return nil, err
+	}
+	candles := make(map[int64]*market.Candle)
+	for _, raw := range f.Candles {
+		c, err := decodeCandle(raw)
+		if err != nil {
+			return nil, fmt.Errorf("backtest: corrupted cache %s: %w", path, err)
+		}
+		candles[time.Time(c.TS).UnixMilli()] = c
+	}
+
+	// the cache is kept contiguous, so the missing ranges are before and after it
+	fetch := func(after, until int64) error {
+		it := s.market.CandlesticksHistoryIterator(ctx, requests.GetCandlesticks{InstID: instID, Bar: bar, After: after}).Until(time.UnixMilli(until))
+		for it.Next() {
+			c := it.Item()
+			candles[time.Time(c.TS).UnixMilli()] = c
+		}
+		return it.Err()
+	}
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()
+	covered := len(f.Candles) > 0 || f.From != 0
+	if !covered || fromMs < f.From || toMs > f.To {
+		if s.market == nil {
+			return nil, errors.New("backtest: the cache doesn't cover the requested range and no market client was provided")
+		}
+		switch {
+		case !covered:
+			err = fetch(toMs+1, fromMs)
+			f.From, f.To = fromMs, toMs
+		default:
+			if fromMs < f.From {
+				err = fetch(f.From, fromMs)
+				f.From = fromMs
+			}
+			if err == nil && toMs > f.To {
+				err = fetch(toMs+1, f.To)
+				f.To = toMs
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.Candles = f.Candles[:0]
+		for _, c := range sortCandles(candles) {
+			f.Candles = append(f.Candles, encodeCandle(c))
+		}
+		if err := s.save(path, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []*market.Candle
+	for _, c := range sortCandles(candles) {
+		if ts := time.Time(c.TS).UnixMilli(); ts >= fromMs && ts <= toMs {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// Trades downloads the latest trades of the instrument, merges them into the cache and returns every cached trade, oldest first.
+//
+// The exchange serves the latest trades only, calling Trades regularly builds up the history.
+func (s *Store) Trades(ctx context.Context, instID string) ([]*market.Trade, error) {
+	path := filepath.Join(s.dir, instID+".trades.json")
+	var cached []*market.Trade
+	if err := s.load(path, &cached); err != nil {
+		return nil, err
+	}
+	if s.market == nil {
+		return cached, nil
+	}
+	res, err := s.market.GetTrades(ctx, requests.GetTrades{InstID: instID, Limit: 500})
+	if err != nil {
+		return nil, err
+	}
+	trades := make(map[float64]*market.Trade, len(cached)+len(res.Trades))
+	for _, t := range append(cached, res.Trades...) {
+		trades[float64(t.TradeID)] = t
+	}
+	merged := make([]*market.Trade, 0, len(trades))
+	for _, t := range trades {
+		merged = append(merged, t)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if !time.Time(a.TS).Equal(time.Time(b.TS)) {
+			return time.Time(a.TS).Before(time.Time(b.TS))
+		}
+		return a.TradeID < b.TradeID
+	})
+	records := make([]tradeRecord, len(merged))
+	for i, t := range merged {
+		records[i] = tradeRecord{
+			InstID:  t.InstID,
+			TradeID: formatFloat(float64(t.TradeID)),
+			Px:      formatFloat(float64(t.Px)),
+			Sz:      formatFloat(float64(t.Sz)),
+			Side:    string(t.Side),
+			TS:      strconv.FormatInt(time.Time(t.TS).UnixMilli(), 10),
+		}
+	}
+	return merged, s.save(path, records)
+}
+
+func (s *Store) load(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("backtest: corrupted cache %s: %w", path, err)
+	}
+	return nil
+}
+
+// save writes the cache through a temporary file, so that an interrupted download never corrupts it
+func (s *Store) save(path string, v interface{}) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func decodeCandle(raw []string) (*market.Candle, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	c := new(market.Candle)
+	return c, c.UnmarshalJSON(b)
+}
+
+func encodeCandle(c *market.Candle) []string {
+	return []string{
+		strconv.FormatInt(time.Time(c.TS).UnixMilli(), 10),
+		formatFloat(c.O),
+		formatFloat(c.H),
+		formatFloat(c.L),
+		formatFloat(c.C),
+		formatFloat(c.Vol),
+		formatFloat(c.VolCcy),
+	}
+}
+
+func sortCandles(m map[int64]*market.Candle) []*market.Candle {
+	res := make([]*market.Candle, 0, len(m))
+	for _, c := range m {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return time.Time(res[i].TS).Before(time.Time(res[j].TS)) })
+	return res
+}
+
+// barName keeps the minutes and the months bars apart on case-insensitive file systems
+func barName(bar okex.BarSize) string {
+	return strings.Replace(string(bar), "M", "mo", 1)
+}
+
+// barEnd returns when the candle opened at ts closes
+func barEnd(ts time.Time, bar okex.BarSize) (time.Time, error) {
+	s := strings.TrimSuffix(string(bar), "utc")
+	if s == "" {
+		s = string(okex.Bar1m)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, fmt.Errorf("backtest: unsupported bar size %q", bar)
+	}
+	switch s[len(s)-1] {
+	case 'm':
+		return ts.Add(time.Duration(n) * time.Minute), nil
+	case 'H':
+		return ts.Add(time.Duration(n) * time.Hour), nil
+	case 'D':
+		return ts.AddDate(0, 0, n), nil
+	case 'W':
+		return ts.AddDate(0, 0, 7*n), nil
+	case 'M':
+		return ts.AddDate(0, n, 0), nil
+	case 'Y':
+		return ts.AddDate(n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("backtest: unsupported bar size %q", bar)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
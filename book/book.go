@@ -0,0 +1,220 @@
+// Package book keeps local order books in sync with the websocket order book channels
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-order-book-channel
+package book
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/market"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checksumDepth is the number of levels of each side that take part in the checksum
+const checksumDepth = 25
+
+var (
+	ErrEmptyBook         = errors.New("book: no levels on the requested side")
+	ErrInsufficientDepth = errors.New("book: not enough depth to fill the requested size")
+	ErrInvalidSize       = errors.New("book: the size to fill must be positive")
+)
+
+type (
+	// Level is a single price level of the book
+	Level struct {
+		Px           float64
+		Sz           float64
+		OrderNumbers int
+		rawPx        string
+		rawSz        string
+	}
+
+	// Book is a sorted depth of a single instrument, bids are kept in descending and asks in ascending order
+	Book struct {
+		InstID   string
+		Channel  string
+		bids     []*Level
+		asks     []*Level
+		checksum int32
+		ts       time.Time
+		mu       sync.RWMutex
+	}
+)
+
+// NewBook returns a pointer to a fresh Book
+func NewBook(instID, channel string) *Book {
+	return &Book{InstID: instID, Channel: channel}
+}
+
+// BestBid returns the highest bid level
+func (b *Book) BestBid() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return Level{}, false
+	}
+	return *b.bids[0], true
+}
+
+// BestAsk returns the lowest ask level
+func (b *Book) BestAsk() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return Level{}, false
+	}
+	return *b.asks[0], true
+}
+
+// Depth returns copies of the top n levels of each side, n <= 0 returns the whole book
+func (b *Book) Depth(n int) (bids, asks []Level) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return copyLevels(b.bids, n), copyLevels(b.asks, n)
+}
+
+// VWAP returns the volume weighted average price to fill sz on the given order side.
+// Buying walks the asks, selling walks the bids, a size that isn't positive is rejected with ErrInvalidSize.
+func (b *Book) VWAP(side okex.OrderSide, sz float64) (float64, error) {
+	if !(sz > 0) {
+		return 0, ErrInvalidSize
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := b.asks
+	if side == okex.OrderSell {
+		levels = b.bids
+	}
+	if len(levels) == 0 {
+		return 0, ErrEmptyBook
+	}
+	var filled, notional float64
+	for _, l := range levels {
+		take := l.Sz
+		if filled+take > sz {
+			take = sz - filled
+		}
+		filled += take
+		notional += take * l.Px
+		if filled >= sz {
+			return notional / filled, nil
+		}
+	}
+	return 0, ErrInsufficientDepth
+}
+
+// UpdatedAt returns the timestamp of the last applied push
+func (b *Book) UpdatedAt() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.ts
+}
+
+// Checksum calculates the CRC32 of the top 25 levels in the format OKX defines
+//
+// https://www.okx.com/docs-v5/en/#overview-websocket-checksum
+func (b *Book) Checksum() int32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.calcChecksum()
+}
+
+// Snapshot replaces the whole book with the given levels
+func (b *Book) Snapshot(ob *market.OrderBookWs) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bids = b.bids[:0]
+	b.asks = b.asks[:0]
+	b.apply(ob)
+}
+
+// Update merges the given deltas into the book, a level with zero size is removed
+func (b *Book) Update(ob *market.OrderBookWs) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.apply(ob)
+}
+
+// Verify checks the calculated checksum against the one received with the last push
+func (b *Book) Verify() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if c := b.calcChecksum(); c != b.checksum {
+		return fmt.Errorf("book: checksum mismatch for %s %s: got %d, want %d", b.InstID, b.Channel, c, b.checksum)
+	}
+	return nil
+}
+
+func (b *Book) apply(ob *market.OrderBookWs) {
+	for _, e := range ob.Bids {
+		b.bids = upsert(b.bids, e, func(a, b float64) bool { return a > b })
+	}
+	for _, e := range ob.Asks {
+		b.asks = upsert(b.asks, e, func(a, b float64) bool { return a < b })
+	}
+	b.checksum = int32(ob.Checksum)
+	b.ts = time.Time(ob.TS)
+}
+
+func (b *Book) calcChecksum() int32 {
+	parts := make([]string, 0, checksumDepth*4)
+	for i := 0; i < checksumDepth; i++ {
+		if i < len(b.bids) {
+			parts = append(parts, b.bids[i].rawPx, b.bids[i].rawSz)
+		}
+		if i < len(b.asks) {
+			parts = append(parts, b.asks[i].rawPx, b.asks[i].rawSz)
+		}
+	}
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
+
+func upsert(levels []*Level, e *market.OrderBookEntity, better func(a, b float64) bool) []*Level {
+	i := sort.Search(len(levels), func(i int) bool { return !better(levels[i].Px, e.DepthPrice) })
+	exists := i < len(levels) && levels[i].Px == e.DepthPrice
+	if e.Size == 0 {
+		if exists {
+			levels = append(levels[:i], levels[i+1:]...)
+		}
+		return levels
+	}
+	l := &Level{
+		Px:           e.DepthPrice,
+		Sz:           e.Size,
+		OrderNumbers: e.OrderNumbers,
+		rawPx:        raw(e.RawDepthPrice, e.DepthPrice),
+		rawSz:        raw(e.RawSize, e.Size),
+	}
+	if exists {
+		levels[i] = l
+		return levels
+	}
+	levels = append(levels, nil)
+	copy(levels[i+1:], levels[i:])
+	levels[i] = l
+	return levels
+}
+
+func raw(s string, f float64) string {
+	if s != "" {
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func copyLevels(levels []*Level, n int) []Level {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	res := make([]Level, n)
+	for i := 0; i < n; i++ {
+		res[i] = *levels[i]
+	}
+	return res
+}
@@ -0,0 +1,261 @@
+package book_test
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/book"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/okextest"
+	requests "github.com/dimkus/okex/requests/ws/public"
+)
+
+func entity(px, sz string) *market.OrderBookEntity {
+	e := &market.OrderBookEntity{}
+	if err := e.UnmarshalJSON([]byte(`["` + px + `","` + sz + `","0","1"]`)); err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func levels(ls []book.Level) [][2]float64 {
+	res := make([][2]float64, len(ls))
+	for i, l := range ls {
+		res[i] = [2]float64{l.Px, l.Sz}
+	}
+	return res
+}
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		bids [][2]string
+		asks [][2]string
+		want string
+	}{
+		{
+			name: "docs example, same depth",
+			bids: [][2]string{{"3366.1", "7"}, {"3366", "6"}},
+			asks: [][2]string{{"3366.8", "9"}, {"3368", "8"}},
+			want: "3366.1:7:3366.8:9:3366:6:3368:8",
+		},
+		{
+			name: "docs example, more asks than bids",
+			bids: [][2]string{{"3366.1", "7"}},
+			asks: [][2]string{{"3366.8", "9"}, {"3368", "8"}, {"3372", "8"}},
+			want: "3366.1:7:3366.8:9:3368:8:3372:8",
+		},
+		{
+			name: "raw strings are kept",
+			bids: [][2]string{{"0.0100", "1.50"}},
+			asks: [][2]string{{"0.0110", "2.00"}},
+			want: "0.0100:1.50:0.0110:2.00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := &market.OrderBookWs{Checksum: int(int32(crc32.ChecksumIEEE([]byte(tt.want))))}
+			for _, l := range tt.bids {
+				ob.Bids = append(ob.Bids, entity(l[0], l[1]))
+			}
+			for _, l := range tt.asks {
+				ob.Asks = append(ob.Asks, entity(l[0], l[1]))
+			}
+			b := book.NewBook("BTC-USDT", book.ChannelBooks)
+			b.Snapshot(ob)
+			if err := b.Verify(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// the value of the docs example computed independently
+	b := book.NewBook("BTC-USDT", book.ChannelBooks)
+	b.Snapshot(&market.OrderBookWs{
+		Bids: []*market.OrderBookEntity{entity("3366.1", "7"), entity("3366", "6")},
+		Asks: []*market.OrderBookEntity{entity("3366.8", "9"), entity("3368", "8")},
+	})
+	if got := b.Checksum(); got != -1881014294 {
+		t.Fatalf("got %d, want -1881014294", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	b := book.NewBook("BTC-USDT", book.ChannelBooks)
+	b.Snapshot(&market.OrderBookWs{
+		Bids: []*market.OrderBookEntity{entity("100", "1"), entity("99", "2"), entity("98", "3")},
+		Asks: []*market.OrderBookEntity{entity("101", "1"), entity("102", "2")},
+	})
+	b.Update(&market.OrderBookWs{
+		// replaced, removed, inserted in the middle
+		Bids: []*market.OrderBookEntity{entity("100", "5"), entity("99", "0"), entity("98.5", "4")},
+		// removal of a missing level is ignored, inserted in front
+		Asks: []*market.OrderBookEntity{entity("103", "0"), entity("100.5", "7")},
+	})
+	bids, asks := b.Depth(0)
+	wantBids := [][2]float64{{100, 5}, {98.5, 4}, {98, 3}}
+	wantAsks := [][2]float64{{100.5, 7}, {101, 1}, {102, 2}}
+	if got := levels(bids); !equal(got, wantBids) {
+		t.Fatalf("bids: got %v, want %v", got, wantBids)
+	}
+	if got := levels(asks); !equal(got, wantAsks) {
+		t.Fatalf("asks: got %v, want %v", got, wantAsks)
+	}
+
+	b.Snapshot(&market.OrderBookWs{Bids: []*market.OrderBookEntity{entity("50", "1")}})
+	bids, asks = b.Depth(0)
+	if len(bids) != 1 || len(asks) != 0 {
+		t.Fatalf("snapshot kept the old levels: %v %v", bids, asks)
+	}
+	if _, err := b.VWAP(okex.OrderBuy, 1); !errors.Is(err, book.ErrEmptyBook) {
+		t.Fatalf("got %v, want ErrEmptyBook", err)
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	b := book.NewBook("BTC-USDT", book.ChannelBooks)
+	b.Snapshot(&market.OrderBookWs{
+		Bids: []*market.OrderBookEntity{entity("99", "1"), entity("98", "2")},
+		Asks: []*market.OrderBookEntity{entity("101", "1"), entity("102", "2")},
+	})
+	tests := []struct {
+		side okex.OrderSide
+		sz   float64
+		want float64
+		err  error
+	}{
+		{okex.OrderBuy, 0.5, 101, nil},
+		{okex.OrderBuy, 2, 101.5, nil},
+		{okex.OrderSell, 3, (99 + 2*98) / 3.0, nil},
+		{okex.OrderSell, 4, 0, book.ErrInsufficientDepth},
+		{okex.OrderBuy, 0, 0, book.ErrInvalidSize},
+		{okex.OrderBuy, -1, 0, book.ErrInvalidSize},
+	}
+	for _, tt := range tests {
+		got, err := b.VWAP(tt.side, tt.sz)
+		if !errors.Is(err, tt.err) || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s %v: got %v, %v, want %v, %v", tt.side, tt.sz, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func equal(a, b [][2]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestManagerKeepsChannelsApart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := book.NewManager(ctx, c.Ws.Public)
+	chCh := make(chan *book.Change, 16)
+	reqs := []requests.OrderBook{
+		{InstID: "BTC-USDT", Channel: book.ChannelBooks},
+		{InstID: "BTC-USDT", Channel: book.ChannelBooks5},
+	}
+	if err := m.Subscribe(reqs, chCh); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range reqs {
+		if err := s.WaitSubscribed(ctx, map[string]string{"channel": req.Channel, "instId": req.InstID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	full := "100:1:101:1:99:2:102:2"
+	s.PushAction(map[string]string{"channel": book.ChannelBooks, "instId": "BTC-USDT"}, book.ActionSnapshot, map[string]any{
+		"bids":     [][]string{{"100", "1", "0", "1"}, {"99", "2", "0", "1"}},
+		"asks":     [][]string{{"101", "1", "0", "1"}, {"102", "2", "0", "1"}},
+		"ts":       "1700000000000",
+		"checksum": int32(crc32.ChecksumIEEE([]byte(full))),
+	})
+	wait(t, ctx, chCh, book.ChannelBooks)
+	s.Push(map[string]string{"channel": book.ChannelBooks5, "instId": "BTC-USDT"}, map[string]any{
+		"bids": [][]string{{"100", "3", "0", "1"}},
+		"asks": [][]string{{"101", "3", "0", "1"}},
+		"ts":   "1700000000001",
+	})
+	wait(t, ctx, chCh, book.ChannelBooks5)
+
+	b, ok := m.Book(book.ChannelBooks, "BTC-USDT")
+	if !ok {
+		t.Fatal("no books book")
+	}
+	if err := b.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if bids, _ := b.Depth(0); len(bids) != 2 || bids[0].Sz != 1 {
+		t.Fatalf("books was overwritten by books5: %v", bids)
+	}
+	b5, ok := m.Book(book.ChannelBooks5, "BTC-USDT")
+	if !ok {
+		t.Fatal("no books5 book")
+	}
+	if bids, _ := b5.Depth(0); len(bids) != 1 || bids[0].Sz != 3 {
+		t.Fatalf("got %v", bids)
+	}
+}
+
+func TestManagerResetsOnChecksumMismatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := book.NewManager(ctx, c.Ws.Public)
+	chCh := make(chan *book.Change, 16)
+	arg := map[string]string{"channel": book.ChannelBooks, "instId": "BTC-USDT"}
+	if err := m.Subscribe([]requests.OrderBook{{InstID: "BTC-USDT", Channel: book.ChannelBooks}}, chCh); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+	// the first item is wrong and the second one would match, the push must be rejected as a whole
+	s.PushAction(arg, book.ActionSnapshot,
+		map[string]any{"bids": [][]string{{"100", "1", "0", "1"}}, "asks": [][]string{}, "ts": "1", "checksum": 1},
+		map[string]any{"bids": [][]string{{"100", "1", "0", "1"}}, "asks": [][]string{}, "ts": "2", "checksum": int32(crc32.ChecksumIEEE([]byte("100:1")))},
+	)
+	ch := wait(t, ctx, chCh, book.ChannelBooks)
+	if ch.Action != book.ActionReset || ch.Err == nil {
+		t.Fatalf("got %+v, want a reset", ch)
+	}
+	if _, ok := m.Book(book.ChannelBooks, "BTC-USDT"); ok {
+		t.Fatal("the book wasn't dropped")
+	}
+}
+
+func wait(t *testing.T, ctx context.Context, chCh chan *book.Change, channel string) *book.Change {
+	t.Helper()
+	for {
+		select {
+		case c := <-chCh:
+			if c.Channel == channel {
+				return c
+			}
+		case <-ctx.Done():
+			t.Fatalf("no change of %s", channel)
+		}
+	}
+}
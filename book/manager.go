@@ -0,0 +1,233 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events/public"
+	requests "github.com/dimkus/okex/requests/ws/public"
+	"sync"
+	"time"
+)
+
+const (
+	ChannelBooks        = "books"
+	ChannelBooks5       = "books5"
+	ChannelBooks50L2TBT = "books50-l2-tbt"
+	ChannelBooksL2TBT   = "books-l2-tbt"
+
+	ActionSnapshot = "snapshot"
+	ActionUpdate   = "update"
+	// ActionReset is reported when a book failed the checksum validation and has been resubscribed
+	ActionReset = "reset"
+)
+
+type (
+	// Manager keeps a local Book for every subscribed channel and instrument, keyed by both so that i.e. books and
+	// books5 of the same instrument are kept apart
+	Manager struct {
+		p       *ws.Public
+		ctx     context.Context
+		books   map[string]*Book
+		reqs    map[string]requests.OrderBook
+		subs    map[string]*ws.Subscription[*public.OrderBook]
+		obCh    chan *public.OrderBook
+		chCh    chan *Change
+		dropped uint64
+		once    sync.Once
+		mu      sync.RWMutex
+	}
+
+	// Change notifies about an applied push
+	Change struct {
+		InstID  string
+		Channel string
+		Action  string
+		TS      time.Time
+		Err     error
+	}
+)
+
+// NewManager returns a pointer to a fresh Manager
+func NewManager(ctx context.Context, p *ws.Public) *Manager {
+	return &Manager{
+		p:     p,
+		ctx:   ctx,
+		books: make(map[string]*Book),
+		reqs:  make(map[string]requests.OrderBook),
+		subs:  make(map[string]*ws.Subscription[*public.OrderBook]),
+		obCh:  make(chan *public.OrderBook),
+	}
+}
+
+// Subscribe to the order book channels of the given instruments and keep their books in sync.
+//
+// Only books, books5, books50-l2-tbt and books-l2-tbt channels are supported. The changes are sent to ch without
+// blocking, those ch has no room for are dropped and counted by Dropped.
+func (m *Manager) Subscribe(reqs []requests.OrderBook, ch ...chan *Change) error {
+	for _, req := range reqs {
+		switch req.Channel {
+		case ChannelBooks, ChannelBooks5, ChannelBooks50L2TBT, ChannelBooksL2TBT:
+		default:
+			return fmt.Errorf("book: unsupported channel %q", req.Channel)
+		}
+	}
+	m.mu.Lock()
+	if len(ch) > 0 {
+		m.chCh = ch[0]
+	}
+	for _, req := range reqs {
+		m.reqs[key(req.Channel, req.InstID)] = req
+	}
+	m.mu.Unlock()
+	m.once.Do(func() {
+		go m.receiver()
+	})
+	// a subscription per instrument, so that each one is dropped or resubscribed on its own
+	for _, req := range reqs {
+		sub, err := m.p.OrderBook([]requests.OrderBook{req}, m.obCh)
+		if err != nil {
+			return err
+		}
+		k := key(req.Channel, req.InstID)
+		m.mu.Lock()
+		old := m.subs[k]
+		m.subs[k] = sub
+		m.mu.Unlock()
+		if old != nil {
+			_ = old.Close()
+		}
+	}
+	return nil
+}
+
+// Unsubscribe from the order book channel of the given instrument and drop its book
+func (m *Manager) Unsubscribe(channel, instID string) error {
+	k := key(channel, instID)
+	m.mu.Lock()
+	sub, ok := m.subs[k]
+	delete(m.reqs, k)
+	delete(m.subs, k)
+	delete(m.books, k)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return sub.Close()
+}
+
+// Book returns the local book of the given channel and instrument
+func (m *Manager) Book(channel, instID string) (*Book, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.books[key(channel, instID)]
+	return b, ok
+}
+
+// Dropped returns the number of changes dropped because the channel of the changes was full
+func (m *Manager) Dropped() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.dropped
+}
+
+func (m *Manager) receiver() {
+	for {
+		select {
+		case e := <-m.obCh:
+			m.process(e)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) process(e *public.OrderBook) {
+	instID, channel := argString(e, "instId"), argString(e, "channel")
+	k := key(channel, instID)
+	m.mu.Lock()
+	if _, ok := m.reqs[k]; !ok {
+		m.mu.Unlock()
+		return
+	}
+	action := e.Action
+	if action == "" {
+		// books5 pushes a full snapshot every time
+		action = ActionSnapshot
+	}
+	b, ok := m.books[k]
+	if !ok {
+		if action != ActionSnapshot {
+			// waiting for a snapshot after a reset
+			m.mu.Unlock()
+			return
+		}
+		b = NewBook(instID, channel)
+		m.books[k] = b
+	}
+	var err error
+	for _, ob := range e.Books {
+		if action == ActionSnapshot {
+			b.Snapshot(ob)
+		} else {
+			b.Update(ob)
+		}
+		if channel == ChannelBooks5 {
+			continue
+		}
+		// the book is reset on the first mismatch, the following items can't make it consistent again
+		if err = b.Verify(); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		delete(m.books, k)
+		action = ActionReset
+	}
+	req, sub := m.reqs[k], m.subs[k]
+	m.mu.Unlock()
+
+	if err != nil && sub != nil {
+		go m.resubscribe(req, sub)
+	}
+	m.notify(&Change{InstID: instID, Channel: channel, Action: action, TS: b.UpdatedAt(), Err: err})
+}
+
+// resubscribe asks the server for a fresh snapshot, the other subscribers of the book receive it as well
+func (m *Manager) resubscribe(req requests.OrderBook, sub *ws.Subscription[*public.OrderBook]) {
+	if err := sub.Resubscribe(); err != nil && !errors.Is(err, ws.ErrSubscriptionClosed) {
+		m.notify(&Change{InstID: req.InstID, Channel: req.Channel, Action: ActionReset, TS: time.Now(), Err: err})
+	}
+}
+
+// notify sends the change without blocking the receiver, the changes the channel has no room for are dropped
+func (m *Manager) notify(c *Change) {
+	m.mu.RLock()
+	ch := m.chCh
+	m.mu.RUnlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- c:
+	default:
+		m.mu.Lock()
+		m.dropped++
+		m.mu.Unlock()
+	}
+}
+
+// key of the book of a channel and an instrument
+func key(channel, instID string) string {
+	return channel + " " + instID
+}
+
+func argString(e *public.OrderBook, k string) string {
+	if e.Arg == nil {
+		return ""
+	}
+	v, _ := e.Arg.Get(k)
+	s, _ := v.(string)
+	return s
+}
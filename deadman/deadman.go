@@ -0,0 +1,248 @@
+// Package deadman keeps the cancel-all-after countdown of OKX armed while the process is healthy, so that the pending
+// orders get canceled by the exchange when the process dies, hangs or loses its connections.
+//
+//	s := deadman.NewSwitch(client.Rest.Trade, deadman.Config{Timeout: 30 * time.Second})
+//	s.OnEvent(func(e *deadman.Event) { ... })
+//	client.Ws.OnStatus(s.OnStatus)
+//	s.Start(ctx)
+//	defer s.Disarm(context.Background())
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-post-cancel-all-after
+package deadman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	responses "github.com/dimkus/okex/responses/trade"
+	"sync"
+	"time"
+)
+
+const (
+	// MinTimeout and MaxTimeout are the bounds of the countdown OKX accepts
+	MinTimeout = 10 * time.Second
+	MaxTimeout = 120 * time.Second
+	// DefaultTimeout is the countdown armed when the config doesn't set one
+	DefaultTimeout = time.Minute
+)
+
+const (
+	// StateArmed is reported each time the countdown is set again
+	StateArmed = State("armed")
+	// StateLapsed is reported when the switch stops arming because a connection is lost or the health check fails,
+	// the exchange cancels the orders at TriggerTime unless the switch is healthy again by then
+	StateLapsed = State("lapsed")
+	// StateDisarmed is reported when the countdown is disabled by Disarm
+	StateDisarmed = State("disarmed")
+	// StateFailed is reported when the countdown could not be set, the previous one keeps running
+	StateFailed = State("failed")
+)
+
+// ErrConnectionLost is the cause of a lapse due to a websocket connection being down
+var ErrConnectionLost = errors.New("deadman: websocket connection lost")
+
+type (
+	// Canceller sets the cancel-all-after countdown, rest.Trade implements it
+	Canceller interface {
+		CancelAllAfter(ctx context.Context, req requests.CancelAllAfter) (responses.CancelAllAfter, error)
+	}
+
+	Config struct {
+		// Timeout is the countdown armed on the exchange, it is clamped between MinTimeout and MaxTimeout
+		Timeout time.Duration
+		// Interval is how often the countdown is armed again, a third of Timeout by default
+		Interval time.Duration
+		// Tag restricts the cancellation to the orders placed with the tag
+		Tag string
+		// Check is run before each arming, the switch doesn't arm while it returns an error
+		Check func(ctx context.Context) error
+		// Endpoints are the websocket endpoints whose connections must be up to arm, every endpoint when empty
+		Endpoints []ws.Endpoint
+	}
+
+	// Switch arms the countdown at a regular interval while the connections are up and the health check passes
+	Switch struct {
+		t       Canceller
+		cfg     Config
+		down    map[string]error
+		armed   bool
+		trigger time.Time
+		hooks   []func(e *Event)
+		cancel  context.CancelFunc
+		done    chan struct{}
+		mu      sync.Mutex
+	}
+
+	// Event reports an arming, a lapse or a disarming of the countdown
+	Event struct {
+		State State
+		// TriggerTime is when the exchange cancels the orders unless the countdown is set again, zero once disarmed
+		TriggerTime time.Time
+		// Err is the cause of a lapse or the error of a failed request
+		Err error
+	}
+
+	State string
+)
+
+// NewSwitch returns a pointer to a fresh Switch, it doesn't arm anything before Start
+func NewSwitch(t Canceller, cfg Config) *Switch {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.Timeout = min(max(cfg.Timeout, MinTimeout), MaxTimeout)
+	if cfg.Interval <= 0 {
+		cfg.Interval = cfg.Timeout / 3
+	}
+	return &Switch{t: t, cfg: cfg, down: make(map[string]error)}
+}
+
+// OnEvent registers fn to be called for every event, from the goroutine of the heartbeat or of Disarm
+func (s *Switch) OnEvent(fn func(e *Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// OnStatus tracks the connections that are down, pass it to ws.ClientWs.OnStatus
+func (s *Switch) OnStatus(e *events.Status) {
+	if !s.watched(e.Endpoint) {
+		return
+	}
+	k := fmt.Sprintf("%s %d", e.Endpoint, e.Conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e.State {
+	case events.StatusDisconnected, events.StatusReconnecting:
+		s.down[k] = fmt.Errorf("%w: %s connection %d", ErrConnectionLost, e.Endpoint, e.Conn)
+	case events.StatusReconnected:
+		delete(s.down, k)
+	}
+}
+
+// Start arms the countdown right away and again at every interval until ctx is done or the switch is closed
+func (s *Switch) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.run(ctx, s.done)
+}
+
+// Close stops arming the countdown, the exchange cancels the orders when the last one elapses
+func (s *Switch) Close() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Disarm stops arming the countdown and disables it, the orders stay live, i.e. on a graceful shutdown
+func (s *Switch) Disarm(ctx context.Context) error {
+	s.Close()
+	_, err := s.t.CancelAllAfter(ctx, requests.CancelAllAfter{TimeOut: 0, Tag: s.cfg.Tag})
+	if err != nil {
+		s.report(&Event{State: StateFailed, Err: err})
+		return err
+	}
+	s.mu.Lock()
+	s.armed, s.trigger = false, time.Time{}
+	s.mu.Unlock()
+	s.report(&Event{State: StateDisarmed})
+	return nil
+}
+
+func (s *Switch) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	tick := time.NewTicker(s.cfg.Interval)
+	defer tick.Stop()
+	for {
+		s.beat(ctx)
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// beat arms the countdown if the switch is healthy and reports a lapse otherwise
+func (s *Switch) beat(ctx context.Context) {
+	if err := s.health(ctx); err != nil {
+		s.mu.Lock()
+		armed, trigger := s.armed, s.trigger
+		s.armed = false
+		s.mu.Unlock()
+		if armed && ctx.Err() == nil {
+			s.report(&Event{State: StateLapsed, TriggerTime: trigger, Err: err})
+		}
+		return
+	}
+	res, err := s.t.CancelAllAfter(ctx, requests.CancelAllAfter{TimeOut: int64(s.cfg.Timeout / time.Second), Tag: s.cfg.Tag})
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.report(&Event{State: StateFailed, Err: err})
+		return
+	}
+	e := &Event{State: StateArmed, TriggerTime: time.Now().Add(s.cfg.Timeout)}
+	if len(res.CancelAllAfters) > 0 {
+		e.TriggerTime = time.Time(res.CancelAllAfters[0].TriggerTime)
+	}
+	s.mu.Lock()
+	s.armed, s.trigger = true, e.TriggerTime
+	s.mu.Unlock()
+	s.report(e)
+}
+
+// health returns why the switch must not arm, nil when it is healthy
+func (s *Switch) health(ctx context.Context) error {
+	s.mu.Lock()
+	var err error
+	for _, e := range s.down {
+		err = e
+		break
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if s.cfg.Check != nil {
+		return s.cfg.Check(ctx)
+	}
+	return nil
+}
+
+func (s *Switch) watched(endpoint string) bool {
+	if len(s.cfg.Endpoints) == 0 {
+		return true
+	}
+	for _, e := range s.cfg.Endpoints {
+		if string(e) == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Switch) report(e *Event) {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+	for _, fn := range hooks {
+		fn(e)
+	}
+}
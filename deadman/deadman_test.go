@@ -0,0 +1,196 @@
+package deadman_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/deadman"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	responses "github.com/dimkus/okex/responses/trade"
+)
+
+// canceller records the countdowns set and answers with the trigger time of the countdown, or with err
+type canceller struct {
+	reqs []requests.CancelAllAfter
+	err  error
+	mu   sync.Mutex
+}
+
+func (c *canceller) CancelAllAfter(_ context.Context, req requests.CancelAllAfter) (responses.CancelAllAfter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reqs = append(c.reqs, req)
+	if c.err != nil {
+		return responses.CancelAllAfter{}, c.err
+	}
+	trigger := time.Now().Add(time.Duration(req.TimeOut) * time.Second)
+	return responses.CancelAllAfter{CancelAllAfters: []*trade.CancelAllAfter{{Tag: req.Tag, TriggerTime: okex.JSONTime(trigger)}}}, nil
+}
+
+func (c *canceller) requests() []requests.CancelAllAfter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]requests.CancelAllAfter(nil), c.reqs...)
+}
+
+func (c *canceller) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func start(t *testing.T, c deadman.Canceller, cfg deadman.Config) (*deadman.Switch, chan *deadman.Event) {
+	t.Helper()
+	s := deadman.NewSwitch(c, cfg)
+	evs := make(chan *deadman.Event, 256)
+	s.OnEvent(func(e *deadman.Event) {
+		select {
+		case evs <- e:
+		default:
+		}
+	})
+	s.Start(context.Background())
+	t.Cleanup(s.Close)
+	return s, evs
+}
+
+// wait returns the next event of the given state, the armings reported meanwhile are skipped
+func wait(t *testing.T, evs chan *deadman.Event, state deadman.State) *deadman.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-evs:
+			if e.State == state {
+				return e
+			}
+			if e.State != deadman.StateArmed {
+				t.Fatalf("got %+v while waiting for %s", e, state)
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", state)
+		}
+	}
+}
+
+func TestLapseOnDisconnect(t *testing.T) {
+	c := &canceller{}
+	s, evs := start(t, c, deadman.Config{Timeout: 30 * time.Second, Interval: 10 * time.Millisecond, Tag: "mm",
+		Endpoints: []ws.Endpoint{ws.EndpointPrivate}})
+	armed := wait(t, evs, deadman.StateArmed)
+	if d := time.Until(armed.TriggerTime); d < 29*time.Second || d > 30*time.Second {
+		t.Fatalf("got a trigger in %s, want 30s", d)
+	}
+	if req := c.requests()[0]; req.TimeOut != 30 || req.Tag != "mm" {
+		t.Fatalf("got %+v", req)
+	}
+
+	// the endpoints not watched don't matter
+	s.OnStatus(&events.Status{Endpoint: string(ws.EndpointPublic), State: events.StatusDisconnected})
+	n := len(c.requests())
+	wait(t, evs, deadman.StateArmed)
+	if len(c.requests()) == n {
+		t.Fatal("the switch stopped arming on a public disconnection")
+	}
+
+	s.OnStatus(&events.Status{Private: true, Endpoint: string(ws.EndpointPrivate), Conn: 1, State: events.StatusDisconnected})
+	lapsed := wait(t, evs, deadman.StateLapsed)
+	if !errors.Is(lapsed.Err, deadman.ErrConnectionLost) || lapsed.TriggerTime.IsZero() {
+		t.Fatalf("got %+v, want the trigger time of the last arming", lapsed)
+	}
+	n = len(c.requests())
+	time.Sleep(50 * time.Millisecond)
+	if len(c.requests()) != n {
+		t.Fatal("the countdown was armed while the connection was down")
+	}
+	select {
+	case e := <-evs:
+		t.Fatalf("got %+v, want a single lapse", e)
+	default:
+	}
+
+	s.OnStatus(&events.Status{Private: true, Endpoint: string(ws.EndpointPrivate), Conn: 1, State: events.StatusReconnecting, Attempt: 1})
+	s.OnStatus(&events.Status{Private: true, Endpoint: string(ws.EndpointPrivate), Conn: 1, State: events.StatusReconnected, Attempt: 1})
+	if e := <-evs; e.State != deadman.StateArmed {
+		t.Fatalf("got %+v, want the countdown armed again", e)
+	}
+}
+
+func TestCheckFailure(t *testing.T) {
+	c := &canceller{}
+	errUnhealthy := errors.New("unhealthy")
+	var (
+		healthy = true
+		mu      sync.Mutex
+	)
+	check := func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			return errUnhealthy
+		}
+		return nil
+	}
+	set := func(v bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		healthy = v
+	}
+	_, evs := start(t, c, deadman.Config{Interval: 10 * time.Millisecond, Check: check})
+	wait(t, evs, deadman.StateArmed)
+	set(false)
+	if e := wait(t, evs, deadman.StateLapsed); !errors.Is(e.Err, errUnhealthy) {
+		t.Fatalf("got %+v", e)
+	}
+	set(true)
+	if e := <-evs; e.State != deadman.StateArmed {
+		t.Fatalf("got %+v, want the countdown armed again", e)
+	}
+
+	// a countdown the exchange refused is reported, the switch keeps trying
+	errRefused := errors.New("refused")
+	c.fail(errRefused)
+	if e := wait(t, evs, deadman.StateFailed); !errors.Is(e.Err, errRefused) {
+		t.Fatalf("got %+v", e)
+	}
+	c.fail(nil)
+	wait(t, evs, deadman.StateArmed)
+}
+
+func TestDisarm(t *testing.T) {
+	c := &canceller{}
+	// the timeout is raised to the minimum the exchange accepts
+	s, evs := start(t, c, deadman.Config{Timeout: time.Second, Interval: 10 * time.Millisecond, Tag: "mm"})
+	wait(t, evs, deadman.StateArmed)
+	if req := c.requests()[0]; req.TimeOut != int64(deadman.MinTimeout/time.Second) {
+		t.Fatalf("got %+v, want the minimum timeout", req)
+	}
+
+	c.fail(errors.New("refused"))
+	if err := s.Disarm(context.Background()); err == nil {
+		t.Fatal("the refused disarming wasn't returned")
+	}
+	wait(t, evs, deadman.StateFailed)
+	c.fail(nil)
+	if err := s.Disarm(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if e := wait(t, evs, deadman.StateDisarmed); !e.TriggerTime.IsZero() {
+		t.Fatalf("got %+v", e)
+	}
+	reqs := c.requests()
+	if last := reqs[len(reqs)-1]; last.TimeOut != 0 || last.Tag != "mm" {
+		t.Fatalf("got %+v, want the countdown disabled", last)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(c.requests()) != len(reqs) {
+		t.Fatal("the countdown was armed after Disarm")
+	}
+}
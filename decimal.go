@@ -0,0 +1,246 @@
+package okex
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrDivisionByZero is returned by Decimal.Div when the divisor is zero or empty
+var ErrDivisionByZero = errors.New("okex: decimal division by zero")
+
+// Decimal is an exact decimal number kept as the string sent by the server.
+//
+// It marshals back to the very same string, so prices and sizes never go through a float64 round trip.
+// The zero value is an empty string, which is omitted by omitempty and reads as zero in arithmetic.
+// Malformed values also read as zero, use NewDecimal to validate user input.
+//
+//	sz := okex.Decimal("0.1").Add("0.2") // "0.3"
+type Decimal string
+
+// NewDecimal parses s and returns it as a Decimal
+func NewDecimal(s string) (Decimal, error) {
+	if _, _, err := parseDecimal(s); err != nil {
+		return "", err
+	}
+	return Decimal(s), nil
+}
+
+// DecimalFromFloat returns the shortest Decimal that reads back as f
+func DecimalFromFloat(f float64) Decimal {
+	return Decimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// DecimalFromInt returns i as a Decimal
+func DecimalFromInt(i int64) Decimal {
+	return Decimal(strconv.FormatInt(i, 10))
+}
+
+// Float64 returns the nearest float64 value of d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(d), 64)
+	return f
+}
+
+// IsZero reports whether d is zero or empty
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	c, _ := d.big()
+	return c.Sign()
+}
+
+// Cmp compares d and o and returns -1, 0 or +1
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := align(d, o)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and o are the same number, i.e. "1.50" equals "1.5"
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// LessThan reports whether d < o
+func (d Decimal) LessThan(o Decimal) bool {
+	return d.Cmp(o) < 0
+}
+
+// GreaterThan reports whether d > o
+func (d Decimal) GreaterThan(o Decimal) bool {
+	return d.Cmp(o) > 0
+}
+
+// Add returns d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return formatDecimal(a.Add(a, b), scale)
+}
+
+// Sub returns d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return formatDecimal(a.Sub(a, b), scale)
+}
+
+// Mul returns d * o
+func (d Decimal) Mul(o Decimal) Decimal {
+	a, as := d.big()
+	b, bs := o.big()
+	return formatDecimal(a.Mul(a, b), as+bs)
+}
+
+// Div returns d / o rounded half away from zero to the given number of decimal places, ErrDivisionByZero if o is zero
+func (d Decimal) Div(o Decimal, places int32) (Decimal, error) {
+	places = max(places, 0)
+	a, b, _ := align(d, o)
+	if b.Sign() == 0 {
+		return "", ErrDivisionByZero
+	}
+	a.Mul(a, pow10(places))
+	return formatDecimal(roundQuo(a, b), places), nil
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	c, scale := d.big()
+	return formatDecimal(c.Neg(c), scale)
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	c, scale := d.big()
+	return formatDecimal(c.Abs(c), scale)
+}
+
+// Round returns d rounded half away from zero to the given number of decimal places
+func (d Decimal) Round(places int32) Decimal {
+	return d.RoundStep(formatDecimal(big.NewInt(1), places))
+}
+
+// RoundStep returns the multiple of step nearest to d, i.e. a price rounded to the tick size.
+//
+// The result has as many decimal places as step.
+func (d Decimal) RoundStep(step Decimal) Decimal {
+	a, b, _ := align(d, step)
+	if b.Sign() == 0 {
+		return d
+	}
+	c, scale := step.big()
+	return formatDecimal(c.Mul(roundQuo(a, b), c), scale)
+}
+
+// FloorStep returns the largest multiple of step not greater than d, i.e. a size rounded down to the lot size.
+//
+// The result has as many decimal places as step.
+func (d Decimal) FloorStep(step Decimal) Decimal {
+	a, b, _ := align(d, step)
+	if b.Sign() == 0 {
+		return d
+	}
+	// Div is the euclidean division, which floors for a positive step
+	c, scale := step.big()
+	return formatDecimal(c.Mul(a.Div(a, b.Abs(b)), c.Abs(c)), scale)
+}
+
+func (d *Decimal) UnmarshalJSON(s []byte) error {
+	r := strings.Replace(string(s), `"`, ``, -1)
+	if r == "" || r == "null" {
+		*d = ""
+		return nil
+	}
+	if _, _, err := parseDecimal(r); err != nil {
+		return err
+	}
+	*d = Decimal(r)
+	return nil
+}
+
+// big returns the coefficient and the scale of d, so that d = coefficient * 10^-scale
+func (d Decimal) big() (*big.Int, int32) {
+	c, scale, err := parseDecimal(string(d))
+	if err != nil {
+		return new(big.Int), 0
+	}
+	return c, scale
+}
+
+func parseDecimal(s string) (*big.Int, int32, error) {
+	if s == "" {
+		return new(big.Int), 0, nil
+	}
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return nil, 0, fmt.Errorf("okex: invalid decimal %q", s)
+		}
+		mantissa = s[:i]
+	}
+	scale := int64(0)
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = int64(len(mantissa) - i - 1)
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	c, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, 0, fmt.Errorf("okex: invalid decimal %q", s)
+	}
+	scale -= exp
+	if scale < 0 {
+		c.Mul(c, pow10(int32(-scale)))
+		scale = 0
+	}
+	return c, int32(scale), nil
+}
+
+// align returns the coefficients of a and b brought to the same scale
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	ac, as := a.big()
+	bc, bs := b.big()
+	switch {
+	case as < bs:
+		ac.Mul(ac, pow10(bs-as))
+		as = bs
+	case bs < as:
+		bc.Mul(bc, pow10(as-bs))
+	}
+	return ac, bc, as
+}
+
+// roundQuo returns a / b rounded half away from zero
+func roundQuo(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Abs(r).Lsh(r, 1).CmpAbs(b) >= 0 {
+		if a.Sign() == b.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func formatDecimal(c *big.Int, scale int32) Decimal {
+	if scale <= 0 {
+		return Decimal(c.Mul(c, pow10(-scale)).String())
+	}
+	s := new(big.Int).Abs(c).String()
+	if len(s) <= int(scale) {
+		s = strings.Repeat("0", int(scale)-len(s)+1) + s
+	}
+	s = s[:len(s)-int(scale)] + "." + s[len(s)-int(scale):]
+	if c.Sign() < 0 {
+		s = "-" + s
+	}
+	return Decimal(s)
+}
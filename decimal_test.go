@@ -0,0 +1,136 @@
+package okex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want Decimal
+	}{
+		{"add keeps the larger scale", Decimal("0.1").Add("0.2"), "0.3"},
+		{"add of empty", Decimal("").Add("1.50"), "1.50"},
+		{"sub below zero", Decimal("1").Sub("1.25"), "-0.25"},
+		{"mul adds the scales", Decimal("1.5").Mul("0.02"), "0.030"},
+		{"exponent", Decimal("1e-3").Add("1"), "1.001"},
+		{"neg", Decimal("0.5").Neg(), "-0.5"},
+		{"abs", Decimal("-0.5").Abs(), "0.5"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		d      Decimal
+		places int32
+		want   Decimal
+	}{
+		{"1.2345", 2, "1.23"},
+		{"1.235", 2, "1.24"},
+		{"-1.235", 2, "-1.24"},
+		{"0.005", 2, "0.01"},
+		{"0.0049", 2, "0.00"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"7", 3, "7.000"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.Round(tt.places); got != tt.want {
+			t.Errorf("Round(%q, %d): got %q, want %q", tt.d, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalSteps(t *testing.T) {
+	tests := []struct {
+		d, step      Decimal
+		round, floor Decimal
+	}{
+		{"100.07", "0.05", "100.05", "100.05"},
+		{"100.08", "0.05", "100.10", "100.05"},
+		{"100.075", "0.05", "100.10", "100.05"},
+		{"-100.07", "0.05", "-100.05", "-100.10"},
+		{"3", "0.5", "3.0", "3.0"},
+		{"0.0009", "0.001", "0.001", "0.000"},
+		{"15", "10", "20", "10"},
+		// a zero step leaves the value as is
+		{"1.23", "", "1.23", "1.23"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.RoundStep(tt.step); got != tt.round {
+			t.Errorf("RoundStep(%q, %q): got %q, want %q", tt.d, tt.step, got, tt.round)
+		}
+		if got := tt.d.FloorStep(tt.step); got != tt.floor {
+			t.Errorf("FloorStep(%q, %q): got %q, want %q", tt.d, tt.step, got, tt.floor)
+		}
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	tests := []struct {
+		d, o   Decimal
+		places int32
+		want   Decimal
+		err    error
+	}{
+		{"1", "3", 4, "0.3333", nil},
+		{"2", "3", 4, "0.6667", nil},
+		{"-2", "3", 4, "-0.6667", nil},
+		{"1", "-8", 2, "-0.13", nil},
+		{"0.3", "0.1", 0, "3", nil},
+		{"10", "0.25", 1, "40.0", nil},
+		{"1", "3", -1, "0", nil},
+		{"1", "0", 2, "", ErrDivisionByZero},
+		{"1", "0.000", 2, "", ErrDivisionByZero},
+		{"1", "", 2, "", ErrDivisionByZero},
+	}
+	for _, tt := range tests {
+		got, err := tt.d.Div(tt.o, tt.places)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Div(%q, %q, %d): got %q, %v, want %q, %v", tt.d, tt.o, tt.places, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDecimalAlign(t *testing.T) {
+	tests := []struct {
+		a, b   Decimal
+		ac, bc string
+		scale  int32
+	}{
+		{"1.5", "2.25", "150", "225", 2},
+		{"1.50", "2", "150", "200", 2},
+		{"", "0.1", "0", "1", 1},
+		{"1e2", "0.5", "1000", "5", 1},
+		{"-3", "7", "-3", "7", 0},
+	}
+	for _, tt := range tests {
+		ac, bc, scale := align(tt.a, tt.b)
+		if ac.String() != tt.ac || bc.String() != tt.bc || scale != tt.scale {
+			t.Errorf("align(%q, %q): got %s, %s, %d, want %s, %s, %d", tt.a, tt.b, ac, bc, scale, tt.ac, tt.bc, tt.scale)
+		}
+	}
+	if !Decimal("1.50").Equal("1.5") || !Decimal("0.1").LessThan("0.25") || Decimal("").Sign() != 0 {
+		t.Fatal("comparisons don't go through the aligned coefficients")
+	}
+}
+
+func TestNewDecimal(t *testing.T) {
+	for _, s := range []string{"0", "-1.5", "1e-8", "12345678901234567890.123456789"} {
+		if _, err := NewDecimal(s); err != nil {
+			t.Errorf("NewDecimal(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"abc", "1.2.3", "1e", "--1"} {
+		if _, err := NewDecimal(s); err == nil {
+			t.Errorf("NewDecimal(%q): no error", s)
+		}
+	}
+}
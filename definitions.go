@@ -4,6 +4,7 @@
 package okex
 
 import (
+	"bytes"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -65,20 +66,25 @@ const (
 	RestURL      = BaseURL("https://www.okx.com")
 	PublicWsURL  = BaseURL("wss://ws.okx.com:8443/ws/v5/public")
 	PrivateWsURL = BaseURL("wss://ws.okx.com:8443/ws/v5/private")
+	// BusinessWsURL serves the candles, the algo orders and the other channels OKX moved off the public and private endpoints
+	BusinessWsURL = BaseURL("wss://ws.okx.com:8443/ws/v5/business")
 
-	AwsRestURL      = BaseURL("https://aws.okx.com")
-	AwsPublicWsURL  = BaseURL("wss://wsaws.okx.com:8443/ws/v5/public")
-	AwsPrivateWsURL = BaseURL("wss://wsaws.okx.com:8443/ws/v5/private")
+	AwsRestURL       = BaseURL("https://aws.okx.com")
+	AwsPublicWsURL   = BaseURL("wss://wsaws.okx.com:8443/ws/v5/public")
+	AwsPrivateWsURL  = BaseURL("wss://wsaws.okx.com:8443/ws/v5/private")
+	AwsBusinessWsURL = BaseURL("wss://wsaws.okx.com:8443/ws/v5/business")
 
-	DemoRestURL      = BaseURL("https://www.okx.com")
-	DemoPublicWsURL  = BaseURL("wss://wspap.okx.com:8443/ws/v5/public?brokerId=9999")
-	DemoPrivateWsURL = BaseURL("wss://wspap.okx.com:8443/ws/v5/private?brokerId=9999")
+	DemoRestURL       = BaseURL("https://www.okx.com")
+	DemoPublicWsURL   = BaseURL("wss://wspap.okx.com:8443/ws/v5/public?brokerId=9999")
+	DemoPrivateWsURL  = BaseURL("wss://wspap.okx.com:8443/ws/v5/private?brokerId=9999")
+	DemoBusinessWsURL = BaseURL("wss://wspap.okx.com:8443/ws/v5/business?brokerId=9999")
 
 	SpotInstrument    = InstrumentType("SPOT")
 	MarginInstrument  = InstrumentType("MARGIN")
 	SwapInstrument    = InstrumentType("SWAP")
 	FuturesInstrument = InstrumentType("FUTURES")
 	OptionsInstrument = InstrumentType("OPTION")
+	AnyInstrument     = InstrumentType("ANY")
 
 	MarginCrossMode    = MarginMode("cross")
 	MarginIsolatedMode = MarginMode("isolated")
@@ -193,6 +199,7 @@ const (
 	BatchOrderOperation       = Operation("batch-orders")
 	CancelOrderOperation      = Operation("cancel-order")
 	BatchCancelOrderOperation = Operation("batch-cancel-orders")
+	MassCancelOperation       = Operation("mass-cancel")
 	AmendOrderOperation       = Operation("amend-order")
 	BatchAmendOrderOperation  = Operation("batch-amend-orders")
 
@@ -203,11 +210,14 @@ const (
 	OrderIOC             = OrderType("ioc")
 	OrderOptimalLimitIoc = OrderType("optimal_limit_ioc")
 
-	AlgoOrderConditional = AlgoOrderType("conditional")
-	AlgoOrderOCO         = AlgoOrderType("oco")
-	AlgoOrderTrigger     = AlgoOrderType("trigger")
-	AlgoOrderIceberg     = AlgoOrderType("iceberg")
-	AlgoOrderTwap        = AlgoOrderType("twap")
+	AlgoOrderConditional  = AlgoOrderType("conditional")
+	AlgoOrderOCO          = AlgoOrderType("oco")
+	AlgoOrderTrigger      = AlgoOrderType("trigger")
+	AlgoOrderIceberg      = AlgoOrderType("iceberg")
+	AlgoOrderTwap         = AlgoOrderType("twap")
+	AlgoOrderGrid         = AlgoOrderType("grid")
+	AlgoOrderContractGrid = AlgoOrderType("contract_grid")
+	AlgoOrderMoonGrid     = AlgoOrderType("moon_grid")
 
 	QuantityBaseCcy  = QuantityType("base_ccy")
 	QuantityQuoteCcy = QuantityType("quote_ccy")
@@ -458,10 +468,45 @@ func (t BarSize) Duration() time.Duration {
 	return time.Minute
 }
 
+// S2M returns the fields of a request as the strings of a query or a websocket argument, the numbers and booleans are
+// formatted, the lists joined with commas and the nested objects encoded as json, the null fields are left out
 func S2M(i interface{}) map[string]string {
 	m := make(map[string]string)
 	j, _ := json.Marshal(i)
-	_ = json.Unmarshal(j, &m)
+	var fields map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return m
+	}
+	for k, v := range fields {
+		if s, ok := param(v); ok {
+			m[k] = s
+		}
+	}
 
 	return m
 }
+
+func param(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := param(item); ok {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ","), true
+	}
+	j, _ := json.Marshal(v)
+	return string(j), true
+}
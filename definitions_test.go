@@ -0,0 +1,53 @@
+package okex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestS2M(t *testing.T) {
+	type limits struct {
+		Max int64  `json:"max"`
+		Ccy string `json:"ccy"`
+	}
+	req := struct {
+		InstID []string `json:"instId"`
+		// the lists left nil are null, like the unset pointers
+		Empty      []string       `json:"empty"`
+		Px         float64        `json:"px,string"`
+		Lever      int64          `json:"lever"`
+		Sz         Decimal        `json:"sz"`
+		After      int64          `json:"after,string"`
+		Before     int64          `json:"before"`
+		ReduceOnly bool           `json:"reduceOnly"`
+		InstType   InstrumentType `json:"instType,omitempty"`
+		Limits     limits         `json:"limits"`
+		Omitted    *limits        `json:"omitted"`
+	}{
+		InstID:     []string{"BTC-USDT", "ETH-USDT"},
+		Px:         0.1,
+		Lever:      5,
+		Sz:         "1.50",
+		After:      1700000000000123,
+		Before:     1700000000000123,
+		ReduceOnly: true,
+		Limits:     limits{Max: 3, Ccy: "BTC"},
+	}
+	want := map[string]string{
+		"instId": "BTC-USDT,ETH-USDT",
+		"px":     "0.1",
+		"lever":  "5",
+		"sz":     "1.50",
+		// the timestamps keep all of their digits
+		"after":      "1700000000000123",
+		"before":     "1700000000000123",
+		"reduceOnly": "true",
+		"limits":     `{"ccy":"BTC","max":3}`,
+	}
+	if got := S2M(req); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := S2M(nil); len(got) != 0 {
+		t.Fatalf("got %v for nil", got)
+	}
+}
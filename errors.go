@@ -0,0 +1,155 @@
+package okex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// APIError is returned when the server responds with a non-zero code or any of the batch items failed
+	//
+	// https://www.okx.com/docs-v5/en/#error-code
+	APIError struct {
+		Code       int
+		Msg        string
+		HTTPStatus int
+		Endpoint   string
+		SubErrors  []*SubError
+	}
+
+	// SubError is the per-item status of a batch request, i.e. sCode and sMsg of a placed order
+	SubError struct {
+		Index   int
+		Code    int
+		Msg     string
+		ID      string
+		ClOrdID string
+	}
+
+	ErrorClass uint8
+)
+
+const (
+	ErrorClassUnknown ErrorClass = iota
+	ErrorClassAuth
+	ErrorClassRateLimit
+	ErrorClassInsufficientBalance
+	ErrorClassOrderNotFound
+	ErrorClassSystemBusy
+)
+
+// ErrorCodes is the catalogue of known error codes and their classes
+var ErrorCodes = map[int]ErrorClass{
+	50001: ErrorClassSystemBusy,          // Service temporarily unavailable
+	50004: ErrorClassSystemBusy,          // Endpoint request timeout
+	50005: ErrorClassSystemBusy,          // API is offline or unavailable
+	50013: ErrorClassSystemBusy,          // Systems are busy
+	50026: ErrorClassSystemBusy,          // System error
+	50011: ErrorClassRateLimit,           // Rate limit reached
+	50040: ErrorClassRateLimit,           // Too frequent operations
+	50061: ErrorClassRateLimit,           // Sub-account rate limit exceeded
+	50100: ErrorClassAuth,                // API frozen
+	50101: ErrorClassAuth,                // APIKey does not match current environment
+	50102: ErrorClassAuth,                // Timestamp request expired
+	50103: ErrorClassAuth,                // Request header OK-ACCESS-KEY cannot be empty
+	50104: ErrorClassAuth,                // Request header OK-ACCESS-PASSPHRASE cannot be empty
+	50105: ErrorClassAuth,                // Request header OK-ACCESS-PASSPHRASE incorrect
+	50106: ErrorClassAuth,                // Request header OK-ACCESS-SIGN cannot be empty
+	50107: ErrorClassAuth,                // Request header OK-ACCESS-TIMESTAMP cannot be empty
+	50110: ErrorClassAuth,                // IP is not included in the whitelist
+	50111: ErrorClassAuth,                // Invalid OK-ACCESS-KEY
+	50112: ErrorClassAuth,                // Invalid OK-ACCESS-TIMESTAMP
+	50113: ErrorClassAuth,                // Invalid signature
+	50114: ErrorClassAuth,                // Invalid authorization
+	50119: ErrorClassAuth,                // API key doesn't exist
+	60004: ErrorClassAuth,                // Invalid timestamp
+	60005: ErrorClassAuth,                // Invalid apiKey
+	60006: ErrorClassAuth,                // Timestamp request expired
+	60007: ErrorClassAuth,                // Invalid sign
+	60009: ErrorClassAuth,                // Login failed
+	60024: ErrorClassAuth,                // Wrong passphrase
+	51008: ErrorClassInsufficientBalance, // Order failed, insufficient balance
+	51127: ErrorClassInsufficientBalance, // Available balance is 0
+	51131: ErrorClassInsufficientBalance, // Insufficient balance
+	51502: ErrorClassInsufficientBalance, // Amend failed, insufficient balance
+	58350: ErrorClassInsufficientBalance, // Insufficient balance
+	51400: ErrorClassOrderNotFound,       // Cancellation failed as the order has been filled, canceled or does not exist
+	51401: ErrorClassOrderNotFound,       // Cancellation failed as the order is already canceled
+	51402: ErrorClassOrderNotFound,       // Cancellation failed as the order is already completed
+	51503: ErrorClassOrderNotFound,       // Order modification failed as the order does not exist
+	51603: ErrorClassOrderNotFound,       // Order does not exist
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "code: %d, msg: %s", e.Code, e.Msg)
+	if e.Endpoint != "" {
+		fmt.Fprintf(&b, ", endpoint: %s", e.Endpoint)
+	}
+	if e.HTTPStatus != 0 && e.HTTPStatus != http.StatusOK {
+		fmt.Fprintf(&b, ", http status: %d", e.HTTPStatus)
+	}
+	for _, s := range e.SubErrors {
+		fmt.Fprintf(&b, "; [%d] code: %d, msg: %s", s.Index, s.Code, s.Msg)
+	}
+	return b.String()
+}
+
+// Class of the error, falls back to the http status when the code is not known
+func (e *APIError) Class() ErrorClass {
+	if c := ClassOf(e.Code); c != ErrorClassUnknown {
+		return c
+	}
+	switch {
+	case e.HTTPStatus == http.StatusUnauthorized:
+		return ErrorClassAuth
+	case e.HTTPStatus == http.StatusTooManyRequests:
+		return ErrorClassRateLimit
+	case e.HTTPStatus >= http.StatusInternalServerError:
+		return ErrorClassSystemBusy
+	}
+	return ErrorClassUnknown
+}
+
+// InClass reports whether the error or any of its sub errors belongs to the given class
+func (e *APIError) InClass(class ErrorClass) bool {
+	if e.Class() == class {
+		return true
+	}
+	for _, s := range e.SubErrors {
+		if ClassOf(s.Code) == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassOf returns the class of the given error code
+func ClassOf(code int) ErrorClass {
+	return ErrorCodes[code]
+}
+
+// IsAuthError reports whether the request was rejected because of the credentials
+func IsAuthError(err error) bool { return isClass(err, ErrorClassAuth) }
+
+// IsRateLimited reports whether the request was rejected by the rate limiter
+func IsRateLimited(err error) bool { return isClass(err, ErrorClassRateLimit) }
+
+// IsInsufficientBalance reports whether the request was rejected because of balance or margin
+func IsInsufficientBalance(err error) bool { return isClass(err, ErrorClassInsufficientBalance) }
+
+// IsOrderNotFound reports whether the order does not exist or is already completed
+func IsOrderNotFound(err error) bool { return isClass(err, ErrorClassOrderNotFound) }
+
+// IsSystemBusy reports whether the server is temporarily unavailable
+func IsSystemBusy(err error) bool { return isClass(err, ErrorClassSystemBusy) }
+
+func isClass(err error, class ErrorClass) bool {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.InClass(class)
+}
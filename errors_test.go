@@ -0,0 +1,81 @@
+package okex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"known code", &APIError{Code: 50011, HTTPStatus: http.StatusTooManyRequests}, ErrorClassRateLimit},
+		{"known code over the http status", &APIError{Code: 51008, HTTPStatus: http.StatusInternalServerError}, ErrorClassInsufficientBalance},
+		{"websocket login", &APIError{Code: 60024, Endpoint: "login"}, ErrorClassAuth},
+		{"unknown code of a 401", &APIError{Code: 1, HTTPStatus: http.StatusUnauthorized}, ErrorClassAuth},
+		{"unknown code of a 429", &APIError{HTTPStatus: http.StatusTooManyRequests}, ErrorClassRateLimit},
+		{"unknown code of a 503", &APIError{HTTPStatus: http.StatusServiceUnavailable}, ErrorClassSystemBusy},
+		{"unknown code", &APIError{Code: 1, HTTPStatus: http.StatusOK}, ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		var e *APIError
+		if !errors.As(tt.err, &e) {
+			t.Fatalf("%s: not an APIError", tt.name)
+		}
+		if got := e.Class(); got != tt.want {
+			t.Errorf("%s: got class %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsClass(t *testing.T) {
+	predicates := map[ErrorClass]func(error) bool{
+		ErrorClassAuth:                IsAuthError,
+		ErrorClassRateLimit:           IsRateLimited,
+		ErrorClassInsufficientBalance: IsInsufficientBalance,
+		ErrorClassOrderNotFound:       IsOrderNotFound,
+		ErrorClassSystemBusy:          IsSystemBusy,
+	}
+	tests := []struct {
+		name string
+		err  error
+		want []ErrorClass
+	}{
+		{"nil", nil, nil},
+		{"not an APIError", errors.New("code: 50011"), nil},
+		{"wrapped", fmt.Errorf("place: %w", &APIError{Code: 50001}), []ErrorClass{ErrorClassSystemBusy}},
+		// a batch is in the classes of its items as well as in the one of its code
+		{"batch", &APIError{Code: 1, SubErrors: []*SubError{{Index: 0, Code: 51008}, {Index: 1, Code: 51603}}},
+			[]ErrorClass{ErrorClassInsufficientBalance, ErrorClassOrderNotFound}},
+		{"batch of a 429", &APIError{Code: 2, HTTPStatus: http.StatusTooManyRequests, SubErrors: []*SubError{{Code: 50026}}},
+			[]ErrorClass{ErrorClassRateLimit, ErrorClassSystemBusy}},
+	}
+	for _, tt := range tests {
+		want := make(map[ErrorClass]bool)
+		for _, class := range tt.want {
+			want[class] = true
+		}
+		for class, is := range predicates {
+			if got := is(tt.err); got != want[class] {
+				t.Errorf("%s: got %v for class %d, want %v", tt.name, got, class, want[class])
+			}
+		}
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	e := &APIError{Code: 1, Msg: "All operations failed", HTTPStatus: http.StatusOK, Endpoint: "/api/v5/trade/batch-orders",
+		SubErrors: []*SubError{{Index: 1, Code: 51008, Msg: "Insufficient balance"}}}
+	want := "code: 1, msg: All operations failed, endpoint: /api/v5/trade/batch-orders; [1] code: 51008, msg: Insufficient balance"
+	if got := e.Error(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	e = &APIError{Code: 50001, Msg: "Service temporarily unavailable", HTTPStatus: http.StatusServiceUnavailable}
+	if got, want := e.Error(), "code: 50001, msg: Service temporarily unavailable, http status: 503"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -50,13 +50,44 @@ type (
 		Event string    `json:"event"`
 		Arg   *Argument `json:"arg"`
 	}
+	// Status reports the lifecycle of a websocket connection
+	Status struct {
+		Private bool
+		// Endpoint is the name of the endpoint of the connection, public, private or business
+		Endpoint string
+		// Conn is the index of the connection in the pool of its endpoint
+		Conn    int
+		State   StatusState
+		Attempt int
+		Err     error
+	}
+	StatusState string
+)
+
+const (
+	StatusDisconnected = StatusState("disconnected")
+	StatusReconnecting = StatusState("reconnecting")
+	StatusReconnected  = StatusState("reconnected")
+	StatusResubscribed = StatusState("resubscribed")
 )
 
+// NewArgument returns an Argument holding the given values, i.e. to build events locally
+func NewArgument(arg map[string]interface{}) *Argument {
+	return &Argument{arg: arg}
+}
+
 func (a *Argument) Get(k string) (interface{}, bool) {
 	v, ok := a.arg[k]
 	return v, ok
 }
 
+func (a *Argument) MarshalJSON() ([]byte, error) {
+	if a.arg == nil && a.untypedArg != nil {
+		return json.Marshal(a.untypedArg)
+	}
+	return json.Marshal(a.arg)
+}
+
 func (a *Argument) UnmarshalJSON(buf []byte) error {
 	a.arg = make(map[string]interface{})
 	if json.Unmarshal(buf, &a.arg) != nil {
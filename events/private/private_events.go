@@ -3,6 +3,7 @@ package private
 import (
 	"github.com/dimkus/okex/events"
 	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/funding"
 	"github.com/dimkus/okex/models/trade"
 )
 
@@ -23,4 +24,35 @@ type (
 		Arg    *events.Argument `json:"arg"`
 		Orders []*trade.Order   `json:"data"`
 	}
+	AlgoOrder struct {
+		Arg        *events.Argument   `json:"arg"`
+		AlgoOrders []*trade.AlgoOrder `json:"data"`
+	}
+	AdvanceAlgoOrder struct {
+		Arg        *events.Argument   `json:"arg"`
+		AlgoOrders []*trade.AlgoOrder `json:"data"`
+	}
+	LiquidationWarning struct {
+		Arg       *events.Argument    `json:"arg"`
+		Positions []*account.Position `json:"data"`
+	}
+	// PositionRiskWarning is pushed by the liquidation-warning channel
+	PositionRiskWarning = LiquidationWarning
+
+	AccountGreeks struct {
+		Arg    *events.Argument  `json:"arg"`
+		Greeks []*account.Greeks `json:"data"`
+	}
+	DepositInfo struct {
+		Arg      *events.Argument       `json:"arg"`
+		Deposits []*funding.DepositInfo `json:"data"`
+	}
+	WithdrawalInfo struct {
+		Arg         *events.Argument          `json:"arg"`
+		Withdrawals []*funding.WithdrawalInfo `json:"data"`
+	}
+	GridOrder struct {
+		Arg        *events.Argument       `json:"arg"`
+		GridOrders []*trade.GridAlgoOrder `json:"data"`
+	}
 )
@@ -65,4 +65,32 @@ type (
 		Arg     *events.Argument      `json:"arg"`
 		Tickers []*market.IndexTicker `json:"data"`
 	}
+	LiquidationOrders struct {
+		Arg               *events.Argument               `json:"arg"`
+		LiquidationOrders []*publicdata.LiquidationOrder `json:"data"`
+	}
+	Status struct {
+		Arg    *events.Argument    `json:"arg"`
+		States []*publicdata.State `json:"data"`
+	}
+	BlockTrades struct {
+		Arg    *events.Argument     `json:"arg"`
+		Trades []*market.BlockTrade `json:"data"`
+	}
+	BlockTickers struct {
+		Arg     *events.Argument      `json:"arg"`
+		Tickers []*market.BlockTicker `json:"data"`
+	}
+	OptionTrades struct {
+		Arg    *events.Argument      `json:"arg"`
+		Trades []*market.OptionTrade `json:"data"`
+	}
+	CallAuctionDetails struct {
+		Arg     *events.Argument             `json:"arg"`
+		Details []*market.CallAuctionDetails `json:"data"`
+	}
+	EconomicCalendar struct {
+		Arg      *events.Argument               `json:"arg"`
+		Calendar []*publicdata.EconomicCalendar `json:"data"`
+	}
 )
@@ -5,7 +5,6 @@ import (
 	"crypto/tls"
 	"github.com/dimkus/okex"
 	"github.com/dimkus/okex/api"
-	"github.com/dimkus/okex/events/public"
 	requests "github.com/dimkus/okex/requests/ws/public"
 	"github.com/gorilla/websocket"
 	"log"
@@ -44,14 +43,14 @@ func main() {
 		HandshakeTimeout: 45 * time.Second,
 		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
 	})
-	obCh := make(chan *public.OrderBook)
-	err = client.Ws.Public.OrderBook(orderBookRequests, obCh)
+	sub, err := client.Ws.Public.OrderBook(orderBookRequests)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	defer sub.Close()
 
 	// Listen for updates
-	for update := range obCh {
+	for update := range sub.C {
 		log.Printf("Received order book update: %+v\n", update)
 		insId, _ := update.Arg.Get("instId")
 		log.Printf("Instrument ID: %s\n", insId)
@@ -0,0 +1,271 @@
+// Package instrument caches the instrument metadata and uses it to round and validate orders locally
+//
+// https://www.okx.com/docs-v5/en/#rest-api-public-data-get-instruments
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events/public"
+	"github.com/dimkus/okex/models/publicdata"
+	requests "github.com/dimkus/okex/requests/rest/public"
+	requests_trade "github.com/dimkus/okex/requests/rest/trade"
+	requests_ws "github.com/dimkus/okex/requests/ws/public"
+	"sync"
+)
+
+// divPlaces is the precision of the conversions that divide, before they are rounded down to the lot size
+const divPlaces = 18
+
+var (
+	ErrUnknownInstrument   = errors.New("instrument: unknown instrument")
+	ErrInstrumentSuspended = errors.New("instrument: trading is suspended")
+	ErrBelowMinSize        = errors.New("instrument: size is below the minimum order size")
+	ErrNoContractValue     = errors.New("instrument: contract value is zero")
+)
+
+// Registry keeps the metadata of the loaded instruments keyed by InstID
+type Registry struct {
+	pd    *rest.PublicData
+	p     *ws.Public
+	ctx   context.Context
+	insts map[string]*publicdata.Instrument
+	iCh   chan *public.Instruments
+	once  sync.Once
+	mu    sync.RWMutex
+}
+
+// NewRegistry returns a pointer to a fresh Registry, p can be nil if the instruments are not watched
+func NewRegistry(ctx context.Context, pd *rest.PublicData, p *ws.Public) *Registry {
+	return &Registry{
+		pd:    pd,
+		p:     p,
+		ctx:   ctx,
+		insts: make(map[string]*publicdata.Instrument),
+		iCh:   make(chan *public.Instruments),
+	}
+}
+
+// Load the instruments of the given types over rest
+func (r *Registry) Load(ctx context.Context, instTypes ...okex.InstrumentType) error {
+	for _, instType := range instTypes {
+		res, err := r.pd.GetInstruments(ctx, requests.GetInstruments{InstType: instType})
+		if err != nil {
+			return err
+		}
+		r.Put(res.Instruments...)
+	}
+	return nil
+}
+
+// Watch subscribes to the instruments channel of the given types and applies every change pushed by the server
+func (r *Registry) Watch(instTypes ...okex.InstrumentType) error {
+	if r.p == nil {
+		return errors.New("instrument: no websocket client to watch the instruments with")
+	}
+	r.once.Do(func() {
+		go r.receiver()
+	})
+	for _, instType := range instTypes {
+		if _, err := r.p.Instruments(requests_ws.Instruments{InstType: instType}, r.iCh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put adds or replaces the given instruments
+func (r *Registry) Put(insts ...*publicdata.Instrument) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, inst := range insts {
+		r.insts[inst.InstID] = inst
+	}
+}
+
+// Get returns the metadata of the given instrument
+func (r *Registry) Get(instID string) (*publicdata.Instrument, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	inst, ok := r.insts[instID]
+	return inst, ok
+}
+
+// RoundPx rounds the price to the nearest multiple of the tick size
+func (r *Registry) RoundPx(instID string, px okex.Decimal) (okex.Decimal, error) {
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	return px.RoundStep(inst.TickSz), nil
+}
+
+// RoundSz rounds the size down to a multiple of the lot size, so the order never exceeds the requested size
+func (r *Registry) RoundSz(instID string, sz okex.Decimal) (okex.Decimal, error) {
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	return sz.FloorStep(inst.LotSz), nil
+}
+
+// ContractsToBase converts a number of contracts to the base currency at the given price.
+//
+// For SPOT and MARGIN the size is already in the base currency and is returned as is.
+func (r *Registry) ContractsToBase(instID string, contracts, px okex.Decimal) (okex.Decimal, error) {
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	if !isContract(inst) {
+		return contracts, nil
+	}
+	if contractValue(inst).IsZero() {
+		return "", fmt.Errorf("%w: %s", ErrNoContractValue, instID)
+	}
+	v := contracts.Mul(contractValue(inst))
+	if inverse(inst) {
+		if px.IsZero() {
+			return "", fmt.Errorf("instrument: price of %s is required for the conversion", instID)
+		}
+		return v.Div(px, divPlaces)
+	}
+	return v, nil
+}
+
+// ContractsToQuote converts a number of contracts to the quote currency at the given price
+func (r *Registry) ContractsToQuote(instID string, contracts, px okex.Decimal) (okex.Decimal, error) {
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	if !isContract(inst) {
+		return contracts.Mul(px), nil
+	}
+	if contractValue(inst).IsZero() {
+		return "", fmt.Errorf("%w: %s", ErrNoContractValue, instID)
+	}
+	v := contracts.Mul(contractValue(inst))
+	if inverse(inst) {
+		return v, nil
+	}
+	return v.Mul(px), nil
+}
+
+// BaseToContracts converts an amount of the base currency to contracts at the given price, rounded down to the lot size
+func (r *Registry) BaseToContracts(instID string, base, px okex.Decimal) (okex.Decimal, error) {
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	contracts := base
+	if isContract(inst) {
+		if inverse(inst) {
+			base = base.Mul(px)
+		}
+		if contracts, err = base.Div(contractValue(inst), divPlaces); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrNoContractValue, instID)
+		}
+	}
+	return contracts.FloorStep(inst.LotSz), nil
+}
+
+// QuoteToContracts converts an amount of the quote currency to contracts at the given price, rounded down to the lot size
+func (r *Registry) QuoteToContracts(instID string, quote, px okex.Decimal) (okex.Decimal, error) {
+	if px.IsZero() {
+		return "", fmt.Errorf("instrument: price of %s is required for the conversion", instID)
+	}
+	inst, err := r.get(instID)
+	if err != nil {
+		return "", err
+	}
+	contracts, err := quote.Div(px, divPlaces)
+	if err != nil {
+		return "", err
+	}
+	if isContract(inst) {
+		if !inverse(inst) {
+			quote = contracts
+		}
+		if contracts, err = quote.Div(contractValue(inst), divPlaces); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrNoContractValue, instID)
+		}
+	}
+	return contracts.FloorStep(inst.LotSz), nil
+}
+
+// Check returns an error if an order of the given size can't be placed on the instrument.
+//
+// Sizes in the quote currency of SPOT market orders are not checked against the minimum size.
+func (r *Registry) Check(instID string, sz okex.Decimal, tgtCcy okex.QuantityType) error {
+	inst, err := r.get(instID)
+	if err != nil {
+		return err
+	}
+	if inst.State == okex.InstrumentSuspend {
+		return fmt.Errorf("%w: %s", ErrInstrumentSuspended, instID)
+	}
+	if tgtCcy != okex.QuantityQuoteCcy && sz.LessThan(inst.MinSz) {
+		return fmt.Errorf("%w: %s %s < %s", ErrBelowMinSize, instID, sz, inst.MinSz)
+	}
+	return nil
+}
+
+// Validate implements rest.OrderValidator
+func (r *Registry) Validate(req requests_trade.PlaceOrder) error {
+	return r.Check(req.InstID, req.Sz, req.TgtCcy)
+}
+
+// Normalize rounds the price and the size of the order and validates the result
+func (r *Registry) Normalize(req requests_trade.PlaceOrder) (requests_trade.PlaceOrder, error) {
+	var err error
+	if req.Px != "" {
+		if req.Px, err = r.RoundPx(req.InstID, req.Px); err != nil {
+			return req, err
+		}
+	}
+	if req.TgtCcy != okex.QuantityQuoteCcy {
+		if req.Sz, err = r.RoundSz(req.InstID, req.Sz); err != nil {
+			return req, err
+		}
+	}
+	return req, r.Validate(req)
+}
+
+func (r *Registry) receiver() {
+	for {
+		select {
+		case e := <-r.iCh:
+			r.Put(e.Instruments...)
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Registry) get(instID string) (*publicdata.Instrument, error) {
+	inst, ok := r.Get(instID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInstrument, instID)
+	}
+	return inst, nil
+}
+
+func isContract(inst *publicdata.Instrument) bool {
+	return inst.InstType == okex.FuturesInstrument || inst.InstType == okex.SwapInstrument || inst.InstType == okex.OptionsInstrument
+}
+
+func inverse(inst *publicdata.Instrument) bool {
+	return inst.CtType == okex.ContractInverseType
+}
+
+func contractValue(inst *publicdata.Instrument) okex.Decimal {
+	if inst.CtMult == "" {
+		return inst.CtVal
+	}
+	return inst.CtVal.Mul(inst.CtMult)
+}
@@ -0,0 +1,86 @@
+package instrument_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/instrument"
+	"github.com/dimkus/okex/models/publicdata"
+)
+
+func registry() *instrument.Registry {
+	r := instrument.NewRegistry(context.Background(), nil, nil)
+	r.Put(
+		&publicdata.Instrument{InstID: "BTC-USDT", InstType: okex.SpotInstrument, LotSz: "0.0001"},
+		&publicdata.Instrument{InstID: "BTC-USDT-SWAP", InstType: okex.SwapInstrument, CtType: okex.ContractLinearType, CtVal: "0.01", LotSz: "1"},
+		&publicdata.Instrument{InstID: "BTC-USD-SWAP", InstType: okex.SwapInstrument, CtType: okex.ContractInverseType, CtVal: "100", LotSz: "1"},
+		&publicdata.Instrument{InstID: "X-USDT-SWAP", InstType: okex.SwapInstrument, CtType: okex.ContractLinearType, LotSz: "1"},
+	)
+	return r
+}
+
+func TestToContracts(t *testing.T) {
+	r := registry()
+	tests := []struct {
+		instID string
+		base   bool
+		amt    okex.Decimal
+		px     okex.Decimal
+		want   okex.Decimal
+		err    error
+	}{
+		{"BTC-USDT", true, "0.12345", "40000", "0.1234", nil},
+		{"BTC-USDT", false, "1000", "40000", "0.0250", nil},
+		{"BTC-USDT-SWAP", true, "0.123", "40000", "12", nil},
+		{"BTC-USDT-SWAP", false, "1000", "40000", "2", nil},
+		{"BTC-USD-SWAP", true, "0.1", "40000", "40", nil},
+		{"BTC-USD-SWAP", false, "1050", "40000", "10", nil},
+		{"X-USDT-SWAP", true, "1", "1", "", instrument.ErrNoContractValue},
+		{"X-USDT-SWAP", false, "1", "1", "", instrument.ErrNoContractValue},
+		{"ETH-USDT", true, "1", "1", "", instrument.ErrUnknownInstrument},
+	}
+	for _, tt := range tests {
+		convert := r.QuoteToContracts
+		if tt.base {
+			convert = r.BaseToContracts
+		}
+		got, err := convert(tt.instID, tt.amt, tt.px)
+		if !errors.Is(err, tt.err) || !got.Equal(tt.want) {
+			t.Errorf("%s base=%v %s at %s: got %q, %v, want %q, %v", tt.instID, tt.base, tt.amt, tt.px, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFromContracts(t *testing.T) {
+	r := registry()
+	tests := []struct {
+		instID    string
+		base      bool
+		contracts okex.Decimal
+		px        okex.Decimal
+		want      okex.Decimal
+		err       error
+	}{
+		{"BTC-USDT", true, "0.5", "40000", "0.5", nil},
+		{"BTC-USDT", false, "0.5", "40000", "20000", nil},
+		{"BTC-USDT-SWAP", true, "12", "40000", "0.12", nil},
+		{"BTC-USDT-SWAP", false, "12", "40000", "4800", nil},
+		{"BTC-USD-SWAP", true, "40", "40000", "0.1", nil},
+		{"BTC-USD-SWAP", false, "10", "40000", "1000", nil},
+		{"X-USDT-SWAP", true, "1", "1", "", instrument.ErrNoContractValue},
+		{"X-USDT-SWAP", false, "1", "1", "", instrument.ErrNoContractValue},
+		{"ETH-USDT", true, "1", "1", "", instrument.ErrUnknownInstrument},
+	}
+	for _, tt := range tests {
+		convert := r.ContractsToQuote
+		if tt.base {
+			convert = r.ContractsToBase
+		}
+		got, err := convert(tt.instID, tt.contracts, tt.px)
+		if !errors.Is(err, tt.err) || !got.Equal(tt.want) {
+			t.Errorf("%s base=%v %s at %s: got %q, %v, want %q, %v", tt.instID, tt.base, tt.contracts, tt.px, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -81,11 +81,16 @@ type (
 		UTime       okex.JSONTime       `json:"uTime"`
 	}
 	BalanceAndPosition struct {
-		EventType okex.EventType    `json:"eventType"`
-		PTime     okex.JSONTime     `json:"pTime"`
-		UTime     okex.JSONTime     `json:"uTime"`
-		PosData   []*Position       `json:"posData"`
-		BalData   []*BalanceDetails `json:"balData"`
+		EventType okex.EventType             `json:"eventType"`
+		PTime     okex.JSONTime              `json:"pTime"`
+		UTime     okex.JSONTime              `json:"uTime"`
+		PosData   []*Position                `json:"posData"`
+		BalData   []*BalanceDetails          `json:"balData"`
+		Trades    []*BalanceAndPositionTrade `json:"trades"`
+	}
+	BalanceAndPositionTrade struct {
+		InstID  string `json:"instId"`
+		TradeID string `json:"tradeId"`
 	}
 	PositionAndAccountRisk struct {
 		AdjEq   okex.JSONFloat64                     `json:"adjEq,omitempty"`
@@ -199,6 +204,18 @@ type (
 	Greek struct {
 		GreeksType string `json:"greeksType"`
 	}
+	Greeks struct {
+		Ccy     string           `json:"ccy"`
+		DeltaBS okex.JSONFloat64 `json:"deltaBS"`
+		DeltaPA okex.JSONFloat64 `json:"deltaPA"`
+		GammaBS okex.JSONFloat64 `json:"gammaBS"`
+		GammaPA okex.JSONFloat64 `json:"gammaPA"`
+		ThetaBS okex.JSONFloat64 `json:"thetaBS"`
+		ThetaPA okex.JSONFloat64 `json:"thetaPA"`
+		VegaBS  okex.JSONFloat64 `json:"vegaBS"`
+		VegaPA  okex.JSONFloat64 `json:"vegaPA"`
+		TS      okex.JSONTime    `json:"ts"`
+	}
 	MaxWithdrawal struct {
 		Ccy   string           `json:"ccy"`
 		MaxWd okex.JSONFloat64 `json:"maxWd"`
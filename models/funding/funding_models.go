@@ -58,6 +58,23 @@ type (
 		State okex.DepositState `json:"state,string"`
 		TS    okex.JSONTime     `json:"ts"`
 	}
+	DepositInfo struct {
+		UID                 string            `json:"uid"`
+		SubAcct             string            `json:"subAcct"`
+		Ccy                 string            `json:"ccy"`
+		Chain               string            `json:"chain"`
+		TxID                string            `json:"txId"`
+		From                string            `json:"from"`
+		AreaCodeFrom        string            `json:"areaCodeFrom"`
+		To                  string            `json:"to"`
+		DepID               string            `json:"depId"`
+		FromWdID            string            `json:"fromWdId"`
+		Amt                 okex.JSONFloat64  `json:"amt"`
+		ActualDepBlkConfirm okex.JSONInt64    `json:"actualDepBlkConfirm"`
+		State               okex.DepositState `json:"state,string"`
+		PTime               okex.JSONTime     `json:"pTime"`
+		TS                  okex.JSONTime     `json:"ts"`
+	}
 	Withdrawal struct {
 		Ccy   string           `json:"ccy"`
 		Chain string           `json:"chain"`
@@ -79,6 +96,28 @@ type (
 		State okex.WithdrawalState `json:"state,string"`
 		TS    okex.JSONTime        `json:"ts"`
 	}
+	WithdrawalInfo struct {
+		UID          string               `json:"uid"`
+		SubAcct      string               `json:"subAcct"`
+		Ccy          string               `json:"ccy"`
+		Chain        string               `json:"chain"`
+		TxID         string               `json:"txId"`
+		From         string               `json:"from"`
+		AreaCodeFrom string               `json:"areaCodeFrom"`
+		To           string               `json:"to"`
+		AreaCodeTo   string               `json:"areaCodeTo"`
+		Tag          string               `json:"tag,omitempty"`
+		PmtID        string               `json:"pmtId,omitempty"`
+		Memo         string               `json:"memo,omitempty"`
+		FeeCcy       string               `json:"feeCcy"`
+		ClientID     string               `json:"clientId"`
+		Amt          okex.JSONFloat64     `json:"amt"`
+		Fee          okex.JSONFloat64     `json:"fee"`
+		WdID         okex.JSONInt64       `json:"wdId"`
+		State        okex.WithdrawalState `json:"state,string"`
+		PTime        okex.JSONTime        `json:"pTime"`
+		TS           okex.JSONTime        `json:"ts"`
+	}
 	PiggyBank struct {
 		Ccy  string           `json:"ccy"`
 		Amt  okex.JSONFloat64 `json:"amt"`
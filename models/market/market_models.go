@@ -53,6 +53,9 @@ type (
 		Size            float64
 		LiquidatedOrder int
 		OrderNumbers    int
+		// RawDepthPrice and RawSize keep the strings as sent by the server, they are needed for checksum validation
+		RawDepthPrice string
+		RawSize       string
 	}
 	Candle struct {
 		O      float64
@@ -78,6 +81,48 @@ type (
 		Side    okex.TradeSide   `json:"side"`
 		TS      okex.JSONTime    `json:"ts"`
 	}
+	BlockTrade struct {
+		InstID  string           `json:"instId"`
+		TradeID string           `json:"tradeId"`
+		Px      okex.JSONFloat64 `json:"px"`
+		Sz      okex.JSONFloat64 `json:"sz"`
+		FillVol okex.JSONFloat64 `json:"fillVol"`
+		FwdPx   okex.JSONFloat64 `json:"fwdPx"`
+		IdxPx   okex.JSONFloat64 `json:"idxPx"`
+		MarkPx  okex.JSONFloat64 `json:"markPx"`
+		Side    okex.TradeSide   `json:"side"`
+		TS      okex.JSONTime    `json:"ts"`
+	}
+	BlockTicker struct {
+		InstID    string              `json:"instId"`
+		VolCcy24h okex.JSONFloat64    `json:"volCcy24h"`
+		Vol24h    okex.JSONFloat64    `json:"vol24h"`
+		InstType  okex.InstrumentType `json:"instType"`
+		TS        okex.JSONTime       `json:"ts"`
+	}
+	OptionTrade struct {
+		InstID     string           `json:"instId"`
+		InstFamily string           `json:"instFamily"`
+		TradeID    string           `json:"tradeId"`
+		Px         okex.JSONFloat64 `json:"px"`
+		Sz         okex.JSONFloat64 `json:"sz"`
+		FillVol    okex.JSONFloat64 `json:"fillVol"`
+		FwdPx      okex.JSONFloat64 `json:"fwdPx"`
+		IdxPx      okex.JSONFloat64 `json:"idxPx"`
+		MarkPx     okex.JSONFloat64 `json:"markPx"`
+		Side       okex.TradeSide   `json:"side"`
+		OptType    okex.OptionType  `json:"optType"`
+		TS         okex.JSONTime    `json:"ts"`
+	}
+	CallAuctionDetails struct {
+		InstID         string           `json:"instId"`
+		State          string           `json:"state"`
+		EqPx           okex.JSONFloat64 `json:"eqPx"`
+		MatchedSz      okex.JSONFloat64 `json:"matchedSz"`
+		UnmatchedSz    okex.JSONFloat64 `json:"unmatchedSz"`
+		AuctionEndTime okex.JSONTime    `json:"auctionEndTime"`
+		TS             okex.JSONTime    `json:"ts"`
+	}
 	TotalVolume24H struct {
 		VolUsd okex.JSONFloat64 `json:"volUsd"`
 		VolCny okex.JSONFloat64 `json:"volCny"`
@@ -112,6 +157,7 @@ func (o *OrderBookEntity) UnmarshalJSON(buf []byte) error {
 	if g, e := len(tmp), wantLen; g != e {
 		return fmt.Errorf("wrong number of fields in OrderBookEntity: %d != %d", g, e)
 	}
+	o.RawDepthPrice, o.RawSize = dp, s
 	o.DepthPrice, err = strconv.ParseFloat(dp, 64)
 	if err != nil {
 		return err
@@ -12,12 +12,12 @@ type (
 		QuoteCcy  string               `json:"quoteCcy,omitempty"`
 		SettleCcy string               `json:"settleCcy,omitempty"`
 		CtValCcy  string               `json:"ctValCcy,omitempty"`
-		CtVal     okex.JSONFloat64     `json:"ctVal,omitempty"`
-		CtMult    okex.JSONFloat64     `json:"ctMult,omitempty"`
+		CtVal     okex.Decimal         `json:"ctVal,omitempty"`
+		CtMult    okex.Decimal         `json:"ctMult,omitempty"`
 		Stk       okex.JSONFloat64     `json:"stk,omitempty"`
-		TickSz    okex.JSONFloat64     `json:"tickSz,omitempty"`
-		LotSz     okex.JSONFloat64     `json:"lotSz,omitempty"`
-		MinSz     okex.JSONFloat64     `json:"minSz,omitempty"`
+		TickSz    okex.Decimal         `json:"tickSz,omitempty"`
+		LotSz     okex.Decimal         `json:"lotSz,omitempty"`
+		MinSz     okex.Decimal         `json:"minSz,omitempty"`
 		Lever     okex.JSONFloat64     `json:"lever"`
 		InstType  okex.InstrumentType  `json:"instType"`
 		Category  okex.FeeCategory     `json:"category,string"`
@@ -99,11 +99,12 @@ type (
 		TS okex.JSONTime `json:"ts"`
 	}
 	LiquidationOrder struct {
-		InstID    string                    `json:"instId"`
-		Uly       string                    `json:"uly,omitempty"`
-		InstType  okex.InstrumentType       `json:"instType"`
-		TotalLoss okex.JSONFloat64          `json:"totalLoss"`
-		Details   []*LiquidationOrderDetail `json:"details"`
+		InstID     string                    `json:"instId"`
+		InstFamily string                    `json:"instFamily,omitempty"`
+		Uly        string                    `json:"uly,omitempty"`
+		InstType   okex.InstrumentType       `json:"instType"`
+		TotalLoss  okex.JSONFloat64          `json:"totalLoss"`
+		Details    []*LiquidationOrderDetail `json:"details"`
 	}
 	LiquidationOrderDetail struct {
 		Ccy     string            `json:"ccy,omitempty"`
@@ -151,13 +152,34 @@ type (
 		LoanQuotaCoef int              `json:"loanQuotaCoef,string"`
 	}
 	State struct {
-		Title       string        `json:"title"`
-		State       string        `json:"state"`
-		Href        string        `json:"href"`
-		ServiceType string        `json:"serviceType"`
-		System      string        `json:"system"`
-		ScheDesc    string        `json:"scheDesc"`
-		Begin       okex.JSONTime `json:"begin"`
-		End         okex.JSONTime `json:"end"`
+		Title        string        `json:"title"`
+		State        string        `json:"state"`
+		Href         string        `json:"href"`
+		ServiceType  string        `json:"serviceType"`
+		System       string        `json:"system"`
+		ScheDesc     string        `json:"scheDesc"`
+		MaintType    string        `json:"maintType"`
+		Env          string        `json:"env"`
+		Begin        okex.JSONTime `json:"begin"`
+		End          okex.JSONTime `json:"end"`
+		PreOpenBegin okex.JSONTime `json:"preOpenBegin"`
+		TS           okex.JSONTime `json:"ts"`
+	}
+	EconomicCalendar struct {
+		CalendarID  string         `json:"calendarId"`
+		Region      string         `json:"region"`
+		Category    string         `json:"category"`
+		Event       string         `json:"event"`
+		Unit        string         `json:"unit"`
+		Ccy         string         `json:"ccy"`
+		DateSpan    string         `json:"dateSpan"`
+		Actual      string         `json:"actual"`
+		Previous    string         `json:"previous"`
+		Forecast    string         `json:"forecast"`
+		PrevInitial string         `json:"prevInitial"`
+		Importance  okex.JSONInt64 `json:"importance"`
+		Date        okex.JSONTime  `json:"date"`
+		RefDate     okex.JSONTime  `json:"refDate"`
+		UTime       okex.JSONTime  `json:"uTime"`
 	}
 )
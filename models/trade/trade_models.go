@@ -6,11 +6,11 @@ import (
 
 type (
 	PlaceOrder struct {
-		ClOrdID string           `json:"clOrdId"`
-		Tag     string           `json:"tag"`
-		SMsg    string           `json:"sMsg"`
-		SCode   okex.JSONInt64   `json:"sCode"`
-		OrdID   okex.JSONFloat64 `json:"ordId"`
+		ClOrdID string         `json:"clOrdId"`
+		Tag     string         `json:"tag"`
+		SMsg    string         `json:"sMsg"`
+		SCode   okex.JSONInt64 `json:"sCode"`
+		OrdID   string         `json:"ordId"`
 	}
 	CancelOrder struct {
 		OrdID   string           `json:"ordId"`
@@ -29,6 +29,35 @@ type (
 		InstID  string            `json:"instId"`
 		PosSide okex.PositionSide `json:"posSide"`
 	}
+	MassCancel struct {
+		Result bool `json:"result"`
+	}
+	OrderPrecheck struct {
+		AdjEq          okex.JSONFloat64 `json:"adjEq"`
+		AdjEqChg       okex.JSONFloat64 `json:"adjEqChg"`
+		Imr            okex.JSONFloat64 `json:"imr"`
+		ImrChg         okex.JSONFloat64 `json:"imrChg"`
+		Mmr            okex.JSONFloat64 `json:"mmr"`
+		MmrChg         okex.JSONFloat64 `json:"mmrChg"`
+		MgnRatio       okex.JSONFloat64 `json:"mgnRatio"`
+		MgnRatioChg    okex.JSONFloat64 `json:"mgnRatioChg"`
+		AvailBal       okex.JSONFloat64 `json:"availBal"`
+		AvailBalChg    okex.JSONFloat64 `json:"availBalChg"`
+		LiqPx          okex.JSONFloat64 `json:"liqPx"`
+		LiqPxDiff      okex.JSONFloat64 `json:"liqPxDiff"`
+		LiqPxDiffRatio okex.JSONFloat64 `json:"liqPxDiffRatio"`
+		PosBal         okex.JSONFloat64 `json:"posBal"`
+		PosBalChg      okex.JSONFloat64 `json:"posBalChg"`
+		Liab           okex.JSONFloat64 `json:"liab"`
+		LiabChg        okex.JSONFloat64 `json:"liabChg"`
+		LiabChgCcy     string           `json:"liabChgCcy"`
+		Type           string           `json:"type"`
+	}
+	CancelAllAfter struct {
+		Tag         string        `json:"tag"`
+		TriggerTime okex.JSONTime `json:"triggerTime"`
+		TS          okex.JSONTime `json:"ts"`
+	}
 	Order struct {
 		InstID      string              `json:"instId"`
 		Ccy         string              `json:"ccy"`
@@ -39,20 +68,20 @@ type (
 		Category    string              `json:"category"`
 		FeeCcy      string              `json:"feeCcy"`
 		RebateCcy   string              `json:"rebateCcy"`
-		Px          okex.JSONFloat64    `json:"px"`
-		Sz          okex.JSONFloat64    `json:"sz"`
+		Px          okex.Decimal        `json:"px"`
+		Sz          okex.Decimal        `json:"sz"`
 		Pnl         okex.JSONFloat64    `json:"pnl"`
-		AccFillSz   okex.JSONFloat64    `json:"accFillSz"`
-		FillPx      okex.JSONFloat64    `json:"fillPx"`
-		FillSz      okex.JSONFloat64    `json:"fillSz"`
+		AccFillSz   okex.Decimal        `json:"accFillSz"`
+		FillPx      okex.Decimal        `json:"fillPx"`
+		FillSz      okex.Decimal        `json:"fillSz"`
 		FillTime    okex.JSONFloat64    `json:"fillTime"`
-		AvgPx       okex.JSONFloat64    `json:"avgPx"`
+		AvgPx       okex.Decimal        `json:"avgPx"`
 		Lever       okex.JSONFloat64    `json:"lever"`
-		TpTriggerPx okex.JSONFloat64    `json:"tpTriggerPx"`
-		TpOrdPx     okex.JSONFloat64    `json:"tpOrdPx"`
-		SlTriggerPx okex.JSONFloat64    `json:"slTriggerPx"`
-		SlOrdPx     okex.JSONFloat64    `json:"slOrdPx"`
-		Fee         okex.JSONFloat64    `json:"fee"`
+		TpTriggerPx okex.Decimal        `json:"tpTriggerPx"`
+		TpOrdPx     okex.Decimal        `json:"tpOrdPx"`
+		SlTriggerPx okex.Decimal        `json:"slTriggerPx"`
+		SlOrdPx     okex.Decimal        `json:"slOrdPx"`
+		Fee         okex.Decimal        `json:"fee"`
 		Rebate      okex.JSONFloat64    `json:"rebate"`
 		State       okex.OrderState     `json:"state"`
 		TdMode      okex.TradeMode      `json:"tdMode"`
@@ -70,11 +99,11 @@ type (
 		TradeID  string              `json:"tradeId"`
 		ClOrdID  string              `json:"clOrdId"`
 		BillID   string              `json:"billId"`
-		Tag      okex.JSONFloat64    `json:"tag"`
-		FillPx   okex.JSONFloat64    `json:"fillPx"`
-		FillSz   okex.JSONFloat64    `json:"fillSz"`
-		FeeCcy   okex.JSONFloat64    `json:"feeCcy"`
-		Fee      okex.JSONFloat64    `json:"fee"`
+		Tag      string              `json:"tag"`
+		FillPx   okex.Decimal        `json:"fillPx"`
+		FillSz   okex.Decimal        `json:"fillSz"`
+		FeeCcy   string              `json:"feeCcy"`
+		Fee      okex.Decimal        `json:"fee"`
 		InstType okex.InstrumentType `json:"instType"`
 		Side     okex.OrderSide      `json:"side"`
 		PosSide  okex.PositionSide   `json:"posSide"`
@@ -96,6 +125,7 @@ type (
 		Ccy          string              `json:"ccy"`
 		OrdID        string              `json:"ordId"`
 		AlgoID       string              `json:"algoId"`
+		AlgoClOrdID  string              `json:"algoClOrdId"`
 		ClOrdID      string              `json:"clOrdId"`
 		TradeID      string              `json:"tradeId"`
 		Tag          string              `json:"tag"`
@@ -103,26 +133,28 @@ type (
 		FeeCcy       string              `json:"feeCcy"`
 		RebateCcy    string              `json:"rebateCcy"`
 		TimeInterval string              `json:"timeInterval"`
-		Px           okex.JSONFloat64    `json:"px"`
-		PxVar        okex.JSONFloat64    `json:"pxVar"`
-		PxSpread     okex.JSONFloat64    `json:"pxSpread"`
-		PxLimit      okex.JSONFloat64    `json:"pxLimit"`
-		Sz           okex.JSONFloat64    `json:"sz"`
-		SzLimit      okex.JSONFloat64    `json:"szLimit"`
-		ActualSz     okex.JSONFloat64    `json:"actualSz"`
-		ActualPx     okex.JSONFloat64    `json:"actualPx"`
+		Px           okex.Decimal        `json:"px"`
+		PxVar        okex.Decimal        `json:"pxVar"`
+		PxSpread     okex.Decimal        `json:"pxSpread"`
+		PxLimit      okex.Decimal        `json:"pxLimit"`
+		Sz           okex.Decimal        `json:"sz"`
+		SzLimit      okex.Decimal        `json:"szLimit"`
+		ActualSz     okex.Decimal        `json:"actualSz"`
+		ActualPx     okex.Decimal        `json:"actualPx"`
 		Pnl          okex.JSONFloat64    `json:"pnl"`
-		AccFillSz    okex.JSONFloat64    `json:"accFillSz"`
-		FillPx       okex.JSONFloat64    `json:"fillPx"`
-		FillSz       okex.JSONFloat64    `json:"fillSz"`
+		AccFillSz    okex.Decimal        `json:"accFillSz"`
+		FillPx       okex.Decimal        `json:"fillPx"`
+		FillSz       okex.Decimal        `json:"fillSz"`
 		FillTime     okex.JSONFloat64    `json:"fillTime"`
-		AvgPx        okex.JSONFloat64    `json:"avgPx"`
+		AvgPx        okex.Decimal        `json:"avgPx"`
 		Lever        okex.JSONFloat64    `json:"lever"`
-		TpTriggerPx  okex.JSONFloat64    `json:"tpTriggerPx"`
-		TpOrdPx      okex.JSONFloat64    `json:"tpOrdPx"`
-		SlTriggerPx  okex.JSONFloat64    `json:"slTriggerPx"`
-		SlOrdPx      okex.JSONFloat64    `json:"slOrdPx"`
-		OrdPx        okex.JSONFloat64    `json:"ordPx"`
+		TpTriggerPx  okex.Decimal        `json:"tpTriggerPx"`
+		TpOrdPx      okex.Decimal        `json:"tpOrdPx"`
+		SlTriggerPx  okex.Decimal        `json:"slTriggerPx"`
+		SlOrdPx      okex.Decimal        `json:"slOrdPx"`
+		OrdPx        okex.Decimal        `json:"ordPx"`
+		TriggerPx    okex.Decimal        `json:"triggerPx"`
+		Count        okex.JSONInt64      `json:"count"`
 		Fee          okex.JSONFloat64    `json:"fee"`
 		Rebate       okex.JSONFloat64    `json:"rebate"`
 		State        okex.OrderState     `json:"state"`
@@ -134,6 +166,43 @@ type (
 		InstType     okex.InstrumentType `json:"instType"`
 		TgtCcy       okex.QuantityType   `json:"tgtCcy"`
 		CTime        okex.JSONTime       `json:"cTime"`
+		UTime        okex.JSONTime       `json:"uTime"`
 		TriggerTime  okex.JSONTime       `json:"triggerTime"`
 	}
+	GridAlgoOrder struct {
+		AlgoID         string              `json:"algoId"`
+		AlgoClOrdID    string              `json:"algoClOrdId"`
+		InstID         string              `json:"instId"`
+		Tag            string              `json:"tag"`
+		State          string              `json:"state"`
+		RunType        string              `json:"runType"`
+		Direction      string              `json:"direction"`
+		StopType       string              `json:"stopType"`
+		CancelType     string              `json:"cancelType"`
+		GridNum        okex.JSONInt64      `json:"gridNum"`
+		MaxPx          okex.Decimal        `json:"maxPx"`
+		MinPx          okex.Decimal        `json:"minPx"`
+		Investment     okex.JSONFloat64    `json:"investment"`
+		TotalPnl       okex.JSONFloat64    `json:"totalPnl"`
+		GridProfit     okex.JSONFloat64    `json:"gridProfit"`
+		FloatProfit    okex.JSONFloat64    `json:"floatProfit"`
+		PnlRatio       okex.JSONFloat64    `json:"pnlRatio"`
+		AnnualizedRate okex.JSONFloat64    `json:"annualizedRate"`
+		ArbitrageNum   okex.JSONInt64      `json:"arbitrageNum"`
+		Lever          okex.JSONFloat64    `json:"lever"`
+		ActualLever    okex.JSONFloat64    `json:"actualLever"`
+		LiqPx          okex.JSONFloat64    `json:"liqPx"`
+		Sz             okex.Decimal        `json:"sz"`
+		BaseSz         okex.Decimal        `json:"baseSz"`
+		QuoteSz        okex.Decimal        `json:"quoteSz"`
+		SingleAmt      okex.Decimal        `json:"singleAmt"`
+		TpTriggerPx    okex.Decimal        `json:"tpTriggerPx"`
+		SlTriggerPx    okex.Decimal        `json:"slTriggerPx"`
+		AlgoOrdType    okex.AlgoOrderType  `json:"algoOrdType"`
+		InstType       okex.InstrumentType `json:"instType"`
+		CTime          okex.JSONTime       `json:"cTime"`
+		UTime          okex.JSONTime       `json:"uTime"`
+		TriggerTime    okex.JSONTime       `json:"triggerTime"`
+		PTime          okex.JSONTime       `json:"pTime"`
+	}
 )
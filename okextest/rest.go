@@ -0,0 +1,158 @@
+package okextest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// publicPrefixes are the rest endpoints that don't require a signature
+var publicPrefixes = []string{"/api/v5/public/", "/api/v5/market/", "/api/v5/system/", "/api/v5/rubik/"}
+
+// Handle scripts the responses of the given endpoint.
+//
+// The handlers answer the requests in order and the last one keeps answering once the others are used up.
+func (s *Server) Handle(method, path string, handlers ...Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[method+" "+path] = &route{handlers: handlers}
+}
+
+// FailNext answers the next requests of the given endpoint with the error code instead of the scripted response
+func (s *Server) FailNext(method, path string, code int, msg string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method+" "+path] = &fault{code: code, msg: msg, times: times}
+}
+
+// Requests returns the rest requests received so far
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// OK answers with a zero code and the given items as data
+func OK(data ...any) Handler {
+	if data == nil {
+		data = []any{}
+	}
+	return func(*Request) (int, any) {
+		return http.StatusOK, reply(0, "", data)
+	}
+}
+
+// Fail answers with the given error code, along with the http status the server uses for it
+func Fail(code int, msg string) Handler {
+	return func(*Request) (int, any) {
+		return statusOf(code), reply(code, msg, []any{})
+	}
+}
+
+func (s *Server) serveRest(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := &Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+	s.wait()
+
+	if !isPublic(r.URL.Path) {
+		if code, msg := s.verify(r, body); code != 0 {
+			writeJSON(w, http.StatusUnauthorized, reply(code, msg, []any{}))
+			return
+		}
+	}
+	key := r.Method + " " + r.URL.Path
+	if f := s.takeFault(key); f != nil {
+		writeJSON(w, statusOf(f.code), reply(f.code, f.msg, []any{}))
+		return
+	}
+	s.mu.Lock()
+	rt, ok := s.routes[key]
+	var h Handler
+	if ok && len(rt.handlers) > 0 {
+		h = rt.handlers[min(rt.next, len(rt.handlers)-1)]
+		rt.next++
+	}
+	s.mu.Unlock()
+	if h == nil {
+		writeJSON(w, http.StatusNotFound, reply(http.StatusNotFound, "okextest: no handler for "+key, []any{}))
+		return
+	}
+	status, res := h(req)
+	writeJSON(w, status, res)
+}
+
+// verify the signature headers of a private request
+//
+// https://www.okx.com/docs-v5/en/#overview-rest-authentication-signature
+func (s *Server) verify(r *http.Request, body []byte) (int, string) {
+	if r.Header.Get("OK-ACCESS-KEY") != s.APIKey {
+		return 50111, "Invalid OK-ACCESS-KEY"
+	}
+	if r.Header.Get("OK-ACCESS-PASSPHRASE") != s.Passphrase {
+		return 50105, "Invalid OK-ACCESS-PASSPHRASE"
+	}
+	ts := r.Header.Get("OK-ACCESS-TIMESTAMP")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return 50112, "Invalid OK-ACCESS-TIMESTAMP"
+	}
+	if d := time.Since(t); d > 30*time.Second || d < -30*time.Second {
+		return 50102, "Timestamp request expired"
+	}
+	if !hmac.Equal([]byte(r.Header.Get("OK-ACCESS-SIGN")), []byte(s.sign(ts+r.Method+r.URL.RequestURI()+string(body)))) {
+		return 50113, "Invalid Sign"
+	}
+	return 0, ""
+}
+
+func (s *Server) sign(prehash string) string {
+	h := hmac.New(sha256.New, []byte(s.SecretKey))
+	h.Write([]byte(prehash))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func isPublic(path string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func statusOf(code int) int {
+	switch {
+	case code == 50011 || code == 50061:
+		return http.StatusTooManyRequests
+	case code >= 50100 && code < 50200:
+		return http.StatusUnauthorized
+	case code == 50001 || code == 50004 || code == 50013 || code == 50026:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func reply(code int, msg string, data any) map[string]any {
+	return map[string]any{"code": strconv.Itoa(code), "msg": msg, "data": data}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("okextest: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(j)
+}
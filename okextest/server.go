@@ -0,0 +1,187 @@
+// Package okextest runs an in-process server speaking the OKX V5 rest and websocket protocols, so clients can be tested offline
+//
+//	srv := okextest.NewServer("key", "secret", "passphrase")
+//	defer srv.Close()
+//	srv.Handle(http.MethodGet, "/api/v5/account/balance", okextest.OK(balance))
+//	client, _ := srv.NewClient(ctx)
+//
+// Private requests and the websocket login are verified against the credentials of the server.
+package okextest
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PublicWsPath   = "/ws/v5/public"
+	PrivateWsPath  = "/ws/v5/private"
+	BusinessWsPath = "/ws/v5/business"
+)
+
+type (
+	// Server is a local OKX V5 server
+	Server struct {
+		APIKey     string
+		SecretKey  string
+		Passphrase string
+		srv        *httptest.Server
+		upgrader   websocket.Upgrader
+		routes     map[string]*route
+		ops        map[okex.Operation]OpHandler
+		faults     map[string]*fault
+		requests   []*Request
+		conns      map[*conn]struct{}
+		latency    time.Duration
+		seq        uint64
+		mu         sync.Mutex
+	}
+
+	// Request is a rest request received by the server
+	Request struct {
+		Method string
+		Path   string
+		Query  url.Values
+		Header http.Header
+		Body   []byte
+	}
+
+	// Handler builds the response of a rest request, body is encoded as json
+	Handler func(r *Request) (status int, body any)
+
+	// OpHandler builds the reply of a websocket order operation, data is encoded as json
+	OpHandler func(args []map[string]any) (code int, msg string, data any)
+
+	route struct {
+		handlers []Handler
+		next     int
+	}
+
+	fault struct {
+		code  int
+		msg   string
+		times int
+	}
+)
+
+// NewServer starts a Server accepting the given credentials, it must be closed by the caller
+func NewServer(apiKey, secretKey, passphrase string) *Server {
+	s := &Server{
+		APIKey:     apiKey,
+		SecretKey:  secretKey,
+		Passphrase: passphrase,
+		routes:     make(map[string]*route),
+		ops:        make(map[okex.Operation]OpHandler),
+		faults:     make(map[string]*fault),
+		conns:      make(map[*conn]struct{}),
+	}
+	mux := http.NewServeMux()
+	for _, path := range []string{PublicWsPath, PrivateWsPath, BusinessWsPath} {
+		path := path
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) { s.serveWs(w, r, path) })
+	}
+	mux.HandleFunc("/", s.serveRest)
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// Close disconnects every websocket client and shuts the server down
+func (s *Server) Close() {
+	s.Disconnect(false)
+	s.Disconnect(true)
+	s.DisconnectPath(BusinessWsPath)
+	s.srv.Close()
+}
+
+// RestURL returns the base url of the rest api
+func (s *Server) RestURL() okex.BaseURL {
+	return okex.BaseURL(s.srv.URL)
+}
+
+// PublicWsURL returns the url of the public websocket
+func (s *Server) PublicWsURL() okex.BaseURL {
+	return okex.BaseURL(s.wsURL(PublicWsPath))
+}
+
+// PrivateWsURL returns the url of the private websocket
+func (s *Server) PrivateWsURL() okex.BaseURL {
+	return okex.BaseURL(s.wsURL(PrivateWsPath))
+}
+
+// BusinessWsURL returns the url of the business websocket
+func (s *Server) BusinessWsURL() okex.BaseURL {
+	return okex.BaseURL(s.wsURL(BusinessWsPath))
+}
+
+// NewClient returns an api.Client connected to the server with its credentials
+func (s *Server) NewClient(ctx context.Context) (*api.Client, error) {
+	return api.NewClient(ctx, s.APIKey, s.SecretKey, s.Passphrase, okex.NormalServer, api.WithURLs(s.RestURL(), s.PublicWsURL(), s.PrivateWsURL(), s.BusinessWsURL()))
+}
+
+// SetLatency delays every rest response, websocket reply and push by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Disconnect drops the public or private websocket connections without a close frame
+func (s *Server) Disconnect(private bool) {
+	if private {
+		s.DisconnectPath(PrivateWsPath)
+	} else {
+		s.DisconnectPath(PublicWsPath)
+	}
+}
+
+// DisconnectPath drops the websocket connections of the endpoint at path without a close frame, i.e. BusinessWsPath
+func (s *Server) DisconnectPath(path string) {
+	s.mu.Lock()
+	var conns []*conn
+	for c := range s.conns {
+		if c.path == path {
+			conns = append(conns, c)
+			delete(s.conns, c)
+		}
+	}
+	s.mu.Unlock()
+	for _, c := range conns {
+		_ = c.ws.Close()
+	}
+}
+
+func (s *Server) wait() {
+	s.mu.Lock()
+	d := s.latency
+	s.mu.Unlock()
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// takeFault returns the pending fault of the given key and counts it down
+func (s *Server) takeFault(key string) *fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.faults[key]
+	if !ok {
+		return nil
+	}
+	f.times--
+	if f.times <= 0 {
+		delete(s.faults, key)
+	}
+	return f
+}
+
+func (s *Server) wsURL(path string) string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http") + path
+}
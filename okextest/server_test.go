@@ -0,0 +1,258 @@
+package okextest_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/okextest"
+	requests_account "github.com/dimkus/okex/requests/rest/account"
+	requests_public "github.com/dimkus/okex/requests/ws/public"
+	requests_trade "github.com/dimkus/okex/requests/ws/trade"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+)
+
+func sign(secret, prehash string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(prehash))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// dial connects to the websocket endpoint at url without the client, so that malformed requests can be sent
+func dial(t *testing.T, url okex.BaseURL) *websocket.Conn {
+	t.Helper()
+	c, _, err := websocket.DefaultDialer.Dial(string(url), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func roundTrip(t *testing.T, c *websocket.Conn, req any) map[string]any {
+	t.Helper()
+	if err := c.WriteJSON(req); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]any
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func login(secret, key, passphrase string, ts time.Time) map[string]any {
+	s := strconv.FormatInt(ts.Unix(), 10)
+	return map[string]any{"op": "login", "args": []map[string]string{{
+		"apiKey": key, "passphrase": passphrase, "timestamp": s, "sign": sign(secret, s+http.MethodGet+"/users/self/verify"),
+	}}}
+}
+
+func TestLogin(t *testing.T) {
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	tests := []struct {
+		name string
+		req  map[string]any
+		code string
+	}{
+		{"valid", login("secret", "key", "pass", time.Now()), "0"},
+		{"wrong secret", login("other", "key", "pass", time.Now()), "60007"},
+		{"wrong key", login("secret", "other", "pass", time.Now()), "60005"},
+		{"wrong passphrase", login("secret", "key", "other", time.Now()), "60024"},
+		{"expired", login("secret", "key", "pass", time.Now().Add(-time.Minute)), "60006"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, s.PrivateWsURL())
+			res := roundTrip(t, c, tt.req)
+			if res["code"] != tt.code {
+				t.Fatalf("got %v, want code %s", res, tt.code)
+			}
+			// the private channels are served to the logged in connections only
+			res = roundTrip(t, c, map[string]any{"op": "subscribe", "args": []map[string]string{{"channel": "account"}}})
+			if subscribed := res["event"] == "subscribe"; subscribed != (tt.code == "0") {
+				t.Fatalf("got %v after a login answered with %s", res, tt.code)
+			}
+		})
+	}
+}
+
+func TestRestSignature(t *testing.T) {
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	s.Handle(http.MethodGet, "/api/v5/account/balance", okextest.OK())
+	path := "/api/v5/account/balance?ccy=BTC,ETH"
+	request := func(secret, key, ts string) map[string]any {
+		r, _ := http.NewRequest(http.MethodGet, string(s.RestURL())+path, nil)
+		r.Header.Set("OK-ACCESS-KEY", key)
+		r.Header.Set("OK-ACCESS-PASSPHRASE", "pass")
+		r.Header.Set("OK-ACCESS-TIMESTAMP", ts)
+		r.Header.Set("OK-ACCESS-SIGN", sign(secret, ts+http.MethodGet+path))
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var body map[string]any
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.999Z07:00")
+	tests := []struct {
+		name            string
+		secret, key, ts string
+		code            string
+	}{
+		{"valid", "secret", "key", now, "0"},
+		{"wrong secret", "other", "key", now, "50113"},
+		{"wrong key", "secret", "other", now, "50111"},
+		{"malformed timestamp", "secret", "key", "yesterday", "50112"},
+		{"expired", "secret", "key", time.Now().Add(-time.Minute).UTC().Format(time.RFC3339), "50102"},
+	}
+	for _, tt := range tests {
+		if res := request(tt.secret, tt.key, tt.ts); res["code"] != tt.code {
+			t.Errorf("%s: got %v, want code %s", tt.name, res, tt.code)
+		}
+	}
+}
+
+func TestRestRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(http.MethodGet, "/api/v5/account/balance",
+		okextest.OK(map[string]any{"totalEq": "1"}),
+		okextest.OK(map[string]any{"totalEq": "2"}),
+	)
+	s.FailNext(http.MethodGet, "/api/v5/account/balance", 50011, "Rate limit reached", 1)
+
+	// the fault comes first, then the handlers in order and the last one repeats
+	if _, err := c.Rest.Account.GetBalance(ctx, requests_account.GetBalance{Ccy: []string{"BTC", "ETH"}}); !okex.IsRateLimited(err) {
+		t.Fatalf("got %v, want the injected fault", err)
+	}
+	for _, want := range []float64{1, 2, 2} {
+		res, err := c.Rest.Account.GetBalance(ctx, requests_account.GetBalance{Ccy: []string{"BTC", "ETH"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Balances) != 1 || float64(res.Balances[0].TotalEq) != want {
+			t.Fatalf("got %+v, want totalEq %v", res.Balances, want)
+		}
+	}
+	reqs := s.Requests()
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests, want 4", len(reqs))
+	}
+	if r := reqs[0]; r.Method != http.MethodGet || r.Path != "/api/v5/account/balance" || r.Query.Get("ccy") != "BTC,ETH" {
+		t.Fatalf("got %s %s %v", r.Method, r.Path, r.Query)
+	}
+
+	// an endpoint that isn't scripted answers 404
+	var apiErr *okex.APIError
+	if _, err := c.Rest.Account.GetPositions(ctx, requests_account.GetPositions{}); !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
+		t.Fatalf("got %v, want a 404", err)
+	}
+}
+
+func TestFailOp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := requests_trade.PlaceOrder{InstID: "BTC-USDT", ClOrdID: "a", Sz: "1", Px: "100",
+		TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit}
+
+	s.FailOp(okex.OrderOperation, 51008, "Order failed, insufficient balance", 2)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Ws.Trade.PlaceOrderWait(ctx, order); !okex.IsInsufficientBalance(err) {
+			t.Fatalf("attempt %d: got %v, want the injected fault", i, err)
+		}
+	}
+	res, err := c.Ws.Trade.PlaceOrderWait(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.PlaceOrders) != 1 || res.PlaceOrders[0].ClOrdID != "a" || res.PlaceOrders[0].OrdID == "" {
+		t.Fatalf("got %+v, want the default acknowledgement", res.PlaceOrders)
+	}
+
+	s.HandleOp(okex.OrderOperation, func(args []map[string]any) (int, string, any) {
+		return 0, "", []map[string]any{{"clOrdId": args[0]["clOrdId"], "ordId": "42", "sCode": "0", "sMsg": ""}}
+	})
+	s.SetLatency(100 * time.Millisecond)
+	start := time.Now()
+	if res, err = c.Ws.Trade.PlaceOrderWait(ctx, order); err != nil || res.PlaceOrders[0].OrdID != "42" {
+		t.Fatalf("got %+v, %v, want the scripted reply", res.PlaceOrders, err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("the reply came after %s, want the latency", d)
+	}
+}
+
+func TestPush(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg := map[string]string{"channel": "tickers", "instId": "BTC-USDT"}
+
+	short, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer stop()
+	if err := s.WaitSubscribed(short, arg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v before any subscription", err)
+	}
+	if n := s.Push(arg, map[string]string{"instId": "BTC-USDT", "last": "1"}); n != 0 {
+		t.Fatalf("pushed to %d connections before any subscription", n)
+	}
+
+	tickers, err := c.Ws.Public.Tickers(requests_public.Tickers{InstID: "BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitSubscribed(ctx, arg); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Subscribed(arg) || s.Subscribed(map[string]string{"channel": "tickers", "instId": "ETH-USDT"}) {
+		t.Fatal("the subscriptions don't match their arguments")
+	}
+	if n := s.Push(arg, map[string]string{"instId": "BTC-USDT", "last": "42000"}); n != 1 {
+		t.Fatalf("pushed to %d connections, want 1", n)
+	}
+	select {
+	case e := <-tickers.C:
+		if len(e.Tickers) != 1 || e.Tickers[0].Last != 42000 {
+			t.Fatalf("got %+v", e.Tickers)
+		}
+	case <-ctx.Done():
+		t.Fatal("the push wasn't received")
+	}
+}
@@ -0,0 +1,334 @@
+package okextest
+
+import (
+	"context"
+	"fmt"
+	"github.com/dimkus/okex"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRequestBytes is the longest subscribe or unsubscribe request the exchange accepts
+const maxRequestBytes = 4096
+
+type (
+	conn struct {
+		ws         *websocket.Conn
+		path       string
+		private    bool
+		authorized bool
+		subs       map[string]map[string]string
+		mu         sync.Mutex
+	}
+
+	request struct {
+		ID   string           `json:"id"`
+		Op   okex.Operation   `json:"op"`
+		Args []map[string]any `json:"args"`
+	}
+)
+
+// HandleOp scripts the replies of a websocket operation, i.e. okex.OrderOperation.
+//
+// Order operations are acknowledged with generated order ids by default, mass cancels with a successful result.
+func (s *Server) HandleOp(op okex.Operation, h OpHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[op] = h
+}
+
+// FailOp answers the next websocket operations of the given kind with the error code, okex.LoginOperation fails the
+// next logins
+func (s *Server) FailOp(op okex.Operation, code int, msg string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults["op "+string(op)] = &fault{code: code, msg: msg, times: times}
+}
+
+// Push sends the data to every connection subscribed to the channel of arg and returns how many received it.
+//
+// A subscription matches when each of its arguments is either "ANY" or equal to the one of arg.
+func (s *Server) Push(arg map[string]string, data ...any) int {
+	return s.push(arg, map[string]any{"arg": arg, "data": data})
+}
+
+// PushAction is Push for the channels that tell snapshots from updates, such as books
+func (s *Server) PushAction(arg map[string]string, action string, data ...any) int {
+	return s.push(arg, map[string]any{"arg": arg, "action": action, "data": data})
+}
+
+// PushRaw sends msg as is to every public or private connection
+func (s *Server) PushRaw(private bool, msg []byte) {
+	s.wait()
+	for _, c := range s.connections() {
+		if c.private == private {
+			_ = c.writeRaw(msg)
+		}
+	}
+}
+
+// Subscribed reports whether any connection is subscribed to the channel of arg
+func (s *Server) Subscribed(arg map[string]string) bool {
+	for _, c := range s.connections() {
+		if c.subscribed(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribedAt reports whether a connection of the endpoint at path is subscribed to the channel of arg, i.e.
+// BusinessWsPath
+func (s *Server) SubscribedAt(path string, arg map[string]string) bool {
+	for _, c := range s.connections() {
+		if c.path == path && c.subscribed(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitSubscribed blocks until a connection is subscribed to the channel of arg or the context is done
+func (s *Server) WaitSubscribed(ctx context.Context, arg map[string]string) error {
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+	for !s.Subscribed(arg) {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveWs(w http.ResponseWriter, r *http.Request, path string) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws, path: path, private: path == PrivateWsPath, subs: make(map[string]map[string]string)}
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = ws.Close()
+	}()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.wait()
+		if string(data) == "ping" {
+			_ = c.writeRaw([]byte("pong"))
+			continue
+		}
+		req := new(request)
+		if err := json.Unmarshal(data, req); err != nil {
+			_ = c.write(map[string]any{"event": "error", "code": "60012", "msg": "Illegal request: " + string(data)})
+			continue
+		}
+		if (req.Op == okex.SubscribeOperation || req.Op == okex.UnsubscribeOperation) && len(data) > maxRequestBytes {
+			_ = c.write(map[string]any{"event": "error", "code": "60012", "msg": "Illegal request: the request exceeds 4096 bytes"})
+			continue
+		}
+		s.handle(c, req)
+	}
+}
+
+func (s *Server) handle(c *conn, req *request) {
+	switch req.Op {
+	case okex.LoginOperation:
+		s.login(c, req)
+	case okex.SubscribeOperation, okex.UnsubscribeOperation:
+		for _, a := range req.Args {
+			arg := stringify(a)
+			if c.private && !c.authorized {
+				_ = c.write(map[string]any{"event": "error", "code": "60011", "msg": "Please log in"})
+				continue
+			}
+			c.mu.Lock()
+			if req.Op == okex.SubscribeOperation {
+				c.subs[argKey(arg)] = arg
+			} else {
+				delete(c.subs, argKey(arg))
+			}
+			c.mu.Unlock()
+			_ = c.write(map[string]any{"event": string(req.Op), "arg": arg})
+		}
+	default:
+		s.operation(c, req)
+	}
+}
+
+// login verifies the signature of the login operation
+//
+// https://www.okx.com/docs-v5/en/#overview-websocket-login
+func (s *Server) login(c *conn, req *request) {
+	code, msg := 0, ""
+	var arg map[string]string
+	if len(req.Args) > 0 {
+		arg = stringify(req.Args[0])
+	}
+	ts, err := strconv.ParseInt(arg["timestamp"], 10, 64)
+	switch f := s.takeFault("op " + string(okex.LoginOperation)); {
+	case f != nil:
+		code, msg = f.code, f.msg
+	case arg["apiKey"] != s.APIKey:
+		code, msg = 60005, "Invalid apiKey"
+	case arg["passphrase"] != s.Passphrase:
+		code, msg = 60024, "Wrong passphrase"
+	case err != nil:
+		code, msg = 60004, "Invalid timestamp"
+	case time.Since(time.Unix(ts, 0)).Abs() > 30*time.Second:
+		code, msg = 60006, "Timestamp request expired"
+	case arg["sign"] != s.sign(arg["timestamp"]+http.MethodGet+"/users/self/verify"):
+		code, msg = 60007, "Invalid sign"
+	}
+	if code != 0 {
+		_ = c.write(map[string]any{"event": "error", "code": strconv.Itoa(code), "msg": msg})
+		return
+	}
+	c.mu.Lock()
+	// the business endpoint serves private channels to the connections logged in
+	c.authorized = c.path != PublicWsPath
+	c.mu.Unlock()
+	_ = c.write(map[string]any{"event": "login", "code": "0", "msg": ""})
+}
+
+func (s *Server) operation(c *conn, req *request) {
+	if !c.private || !c.authorized {
+		_ = c.write(map[string]any{"id": req.ID, "op": req.Op, "code": "60011", "msg": "Please log in", "data": []any{}})
+		return
+	}
+	if f := s.takeFault("op " + string(req.Op)); f != nil {
+		_ = c.write(map[string]any{"id": req.ID, "op": req.Op, "code": strconv.Itoa(f.code), "msg": f.msg, "data": []any{}})
+		return
+	}
+	s.mu.Lock()
+	h, ok := s.ops[req.Op]
+	s.mu.Unlock()
+	if !ok {
+		switch req.Op {
+		case okex.OrderOperation, okex.BatchOrderOperation, okex.CancelOrderOperation,
+			okex.BatchCancelOrderOperation, okex.AmendOrderOperation, okex.BatchAmendOrderOperation:
+			h = s.acknowledge
+		case okex.MassCancelOperation:
+			h = func([]map[string]any) (int, string, any) {
+				return 0, "", []map[string]any{{"result": true}}
+			}
+		default:
+			_ = c.write(map[string]any{"event": "error", "code": "60012", "msg": "Illegal request: unknown op " + string(req.Op)})
+			return
+		}
+	}
+	code, msg, data := h(req.Args)
+	_ = c.write(map[string]any{"id": req.ID, "op": req.Op, "code": strconv.Itoa(code), "msg": msg, "data": data})
+}
+
+// acknowledge accepts every order of the operation
+func (s *Server) acknowledge(args []map[string]any) (int, string, any) {
+	data := make([]map[string]string, len(args))
+	for i, a := range args {
+		arg := stringify(a)
+		ordID := arg["ordId"]
+		if ordID == "" {
+			ordID = strconv.FormatUint(atomic.AddUint64(&s.seq, 1), 10)
+		}
+		data[i] = map[string]string{"ordId": ordID, "clOrdId": arg["clOrdId"], "reqId": arg["reqId"], "tag": arg["tag"], "sCode": "0", "sMsg": ""}
+	}
+	return 0, "", data
+}
+
+func (s *Server) push(arg map[string]string, msg map[string]any) int {
+	s.wait()
+	n := 0
+	for _, c := range s.connections() {
+		if c.subscribed(arg) && c.write(msg) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) connections() []*conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func (c *conn) subscribed(arg map[string]string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sub := range c.subs {
+		if sub["channel"] != arg["channel"] {
+			continue
+		}
+		match := true
+		for k, v := range sub {
+			if v != "ANY" && arg[k] != v {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *conn) write(v any) error {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeRaw(j)
+}
+
+func (c *conn) writeRaw(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+func stringify(arg map[string]any) map[string]string {
+	m := make(map[string]string, len(arg))
+	for k, v := range arg {
+		if s, ok := v.(string); ok {
+			m[k] = s
+		} else {
+			m[k] = fmt.Sprint(v)
+		}
+	}
+	return m
+}
+
+func argKey(arg map[string]string) string {
+	keys := make([]string, 0, len(arg))
+	for k := range arg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + arg[k]
+	}
+	return strings.Join(parts, ",")
+}
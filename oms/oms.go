@@ -0,0 +1,394 @@
+// Package oms keeps a live view of the orders of the account, fed by the orders channel and reconciled over rest
+//
+//	m := oms.NewManager(ctx, client.Rest.Trade, client.Ws.Private)
+//	m.OnFill(func(f *oms.Fill) { ... })
+//	_ = m.Subscribe(requests_ws.Order{InstType: okex.SwapInstrument})
+//	client.Ws.OnStatus(m.OnReconnect)
+//
+// https://www.okx.com/docs-v5/en/#order-book-trading-trade-ws-order-channel
+package oms
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	requests_ws "github.com/dimkus/okex/requests/ws/private"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long the filled and canceled orders stay queryable
+	DefaultRetention = time.Hour
+
+	// maxBatch is the number of orders a batch cancel request takes at most
+	maxBatch = 20
+	// pageSize is the number of pending orders GetOrderList returns at most
+	pageSize = 100
+	// avgPlaces is the precision of the price of a fill derived from the average price of its order
+	avgPlaces = 12
+)
+
+const (
+	// sourcePush is an update of the orders channel
+	sourcePush source = iota
+	// sourceRest is an update found by Reconcile
+	sourceRest
+	// sourceLoad is an update of the first Reconcile, the orders filled before they were tracked report no fills
+	sourceLoad
+)
+
+type (
+	// Manager tracks the lifecycle of the orders keyed by OrdID and ClOrdID.
+	//
+	// The pushes of the orders channel are applied in the order of their UTime, a push older than the known state of
+	// its order is ignored, so are the pushes and the rest replies that would bring a final order back to live.
+	Manager struct {
+		t         rest.TradeI
+		p         ws.PrivateI
+		ctx       context.Context
+		req       *requests_ws.Order
+		sub       *ws.Subscription[*private.Order]
+		orders    map[string]*trade.Order
+		clOrds    map[string]string
+		fills     []func(f *Fill)
+		retention time.Duration
+		loaded    bool
+		oCh       chan *private.Order
+		once      sync.Once
+		mu        sync.RWMutex
+	}
+
+	// Fill is an execution of a tracked order
+	Fill struct {
+		Order   *trade.Order
+		TradeID string
+		FillPx  okex.Decimal
+		FillSz  okex.Decimal
+		// Fee is the fee of the fill, negative when it is charged
+		Fee    okex.Decimal
+		FeeCcy string
+		TS     time.Time
+		// Reconciled is set for the fills missed by the orders channel and found by Reconcile, their TradeID is empty
+		// and FillPx is the average price of the missed executions
+		Reconciled bool
+	}
+
+	// source of an update
+	source uint8
+)
+
+// NewManager returns a pointer to a fresh Manager, t and p can be the paper counterparts of rest.Trade and ws.Private
+func NewManager(ctx context.Context, t rest.TradeI, p ws.PrivateI) *Manager {
+	return &Manager{
+		t:         t,
+		p:         p,
+		ctx:       ctx,
+		orders:    make(map[string]*trade.Order),
+		clOrds:    make(map[string]string),
+		retention: DefaultRetention,
+		oCh:       make(chan *private.Order),
+	}
+}
+
+// SetRetention sets how long the filled and canceled orders stay queryable
+func (m *Manager) SetRetention(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retention = d
+}
+
+// OnFill registers fn to be called for every fill of a tracked order, from the goroutine applying the updates
+func (m *Manager) OnFill(fn func(f *Fill)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fills = append(m.fills, fn)
+}
+
+// Subscribe to the orders channel and load the pending orders matching req over rest
+func (m *Manager) Subscribe(req requests_ws.Order) error {
+	m.once.Do(func() {
+		go m.receiver()
+	})
+	sub, err := m.p.Order(req, m.oCh)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	old := m.sub
+	m.req, m.sub = &req, sub
+	m.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+	return m.Reconcile(m.ctx)
+}
+
+// Close unsubscribes from the orders channel, the orders stay queryable
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	sub := m.sub
+	m.sub = nil
+	m.mu.Unlock()
+	if sub == nil {
+		return nil
+	}
+	return sub.Close()
+}
+
+// OnReconnect reconciles the orders once the private subscriptions have been replayed, pass it to ws.ClientWs.OnStatus
+func (m *Manager) OnReconnect(e *events.Status) {
+	if e.State != events.StatusResubscribed || !e.Private {
+		return
+	}
+	go func() {
+		_ = m.Reconcile(m.ctx)
+	}()
+}
+
+// Reconcile catches up with the changes the orders channel may have missed, i.e. while reconnecting.
+//
+// The pending orders are loaded over rest and the tracked orders no longer pending are looked up one by one to learn
+// how they ended, the ones OKX doesn't know anymore are marked canceled. The fills found that way are reported as
+// reconciled fills.
+func (m *Manager) Reconcile(ctx context.Context) error {
+	m.mu.RLock()
+	var req requests.OrderList
+	if m.req != nil {
+		req = requests.OrderList{InstType: m.req.InstType, InstID: m.req.InstID, Uly: m.req.Uly}
+	}
+	src := sourceRest
+	if !m.loaded {
+		src = sourceLoad
+	}
+	m.mu.RUnlock()
+
+	pending := make(map[string]bool)
+	for {
+		res, err := m.t.GetOrderList(ctx, req)
+		if err != nil {
+			return err
+		}
+		m.apply(src, res.Orders...)
+		for _, o := range res.Orders {
+			pending[o.OrdID] = true
+		}
+		if len(res.Orders) < pageSize {
+			break
+		}
+		req.After = res.Orders[len(res.Orders)-1].OrdID
+	}
+
+	for _, o := range m.Open("") {
+		if pending[o.OrdID] {
+			continue
+		}
+		res, err := m.t.GetOrderDetail(ctx, requests.OrderDetails{InstID: o.InstID, OrdID: o.OrdID})
+		if err != nil {
+			if !okex.IsOrderNotFound(err) {
+				return err
+			}
+			// OKX keeps the canceled orders with no fills for a couple of hours only, the other final orders stay
+			// queryable, so an order it no longer knows has been canceled without filling any further
+			c := *o
+			c.State, c.UTime = okex.OrderCancel, okex.JSONTime(time.Now())
+			m.apply(src, &c)
+			continue
+		}
+		m.apply(src, res.Orders...)
+	}
+	m.mu.Lock()
+	m.loaded = true
+	m.mu.Unlock()
+	return nil
+}
+
+// Get returns the order by OrdID
+func (m *Manager) Get(ordID string) (*trade.Order, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.orders[ordID]
+	if !ok {
+		return nil, false
+	}
+	c := *o
+	return &c, true
+}
+
+// GetByClOrdID returns the order by ClOrdID
+func (m *Manager) GetByClOrdID(clOrdID string) (*trade.Order, bool) {
+	m.mu.RLock()
+	ordID, ok := m.clOrds[clOrdID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return m.Get(ordID)
+}
+
+// Open returns the live and partially filled orders of the instrument, of every instrument when instID is empty,
+// oldest first
+func (m *Manager) Open(instID string) []*trade.Order {
+	return m.list(func(o *trade.Order) bool {
+		return !final(o.State) && (instID == "" || o.InstID == instID)
+	})
+}
+
+// Orders returns the open and the recent orders, oldest first
+func (m *Manager) Orders() []*trade.Order {
+	return m.list(func(*trade.Order) bool { return true })
+}
+
+// CancelAll cancels the open orders of the instrument, of every instrument when instID is empty, in batches of 20
+func (m *Manager) CancelAll(ctx context.Context, instID string) error {
+	open := m.Open(instID)
+	for len(open) > 0 {
+		n := min(len(open), maxBatch)
+		req := make([]requests.CancelOrder, n)
+		for i, o := range open[:n] {
+			req[i] = requests.CancelOrder{InstID: o.InstID, OrdID: o.OrdID}
+		}
+		if _, err := m.t.CandleOrder(ctx, req); err != nil {
+			return err
+		}
+		open = open[n:]
+	}
+	return nil
+}
+
+func (m *Manager) receiver() {
+	for {
+		select {
+		case e := <-m.oCh:
+			m.apply(sourcePush, e.Orders...)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// apply merges the updates into the tracked orders and reports the fills they carry
+func (m *Manager) apply(src source, updates ...*trade.Order) {
+	var fills []*Fill
+	m.mu.Lock()
+	for _, u := range updates {
+		if u == nil || u.OrdID == "" {
+			continue
+		}
+		cur, ok := m.orders[u.OrdID]
+		if ok && stale(cur, u) {
+			continue
+		}
+		o := *u
+		if f := fill(cur, &o, src == sourceRest); f != nil && src != sourceLoad {
+			fills = append(fills, f)
+		}
+		m.orders[o.OrdID] = &o
+		if o.ClOrdID != "" {
+			m.clOrds[o.ClOrdID] = o.OrdID
+		}
+	}
+	m.prune()
+	hooks := m.fills
+	m.mu.Unlock()
+
+	for _, f := range fills {
+		for _, fn := range hooks {
+			fn(f)
+		}
+	}
+}
+
+// prune forgets the final orders older than the retention, mu must be held
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-m.retention)
+	for ordID, o := range m.orders {
+		if final(o.State) && time.Time(o.UTime).Before(cutoff) {
+			delete(m.orders, ordID)
+			if m.clOrds[o.ClOrdID] == ordID {
+				delete(m.clOrds, o.ClOrdID)
+			}
+		}
+	}
+}
+
+func (m *Manager) list(keep func(o *trade.Order) bool) []*trade.Order {
+	m.mu.RLock()
+	var res []*trade.Order
+	for _, o := range m.orders {
+		if keep(o) {
+			c := *o
+			res = append(res, &c)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		return time.Time(res[i].CTime).Before(time.Time(res[j].CTime))
+	})
+	return res
+}
+
+// stale reports whether the update is older than the known state of the order
+func stale(cur, u *trade.Order) bool {
+	cu, uu := time.Time(cur.UTime), time.Time(u.UTime)
+	switch {
+	case uu.Before(cu):
+		return true
+	case uu.After(cu):
+		// a final order never comes back to live
+		return final(cur.State) && !final(u.State)
+	}
+	return rank(u.State) < rank(cur.State) || u.AccFillSz.LessThan(cur.AccFillSz)
+}
+
+// fill returns the execution the update reports, nil if it doesn't fill the order any further
+func fill(cur, u *trade.Order, reconciled bool) *Fill {
+	var prevSz, prevPx, prevFee okex.Decimal
+	if cur != nil {
+		prevSz, prevPx, prevFee = cur.AccFillSz, cur.AvgPx, cur.Fee
+	}
+	delta := u.AccFillSz.Sub(prevSz)
+	if delta.Sign() <= 0 {
+		return nil
+	}
+	f := &Fill{
+		Order:   u,
+		TradeID: u.TradeID,
+		FillPx:  u.FillPx,
+		FillSz:  u.FillSz,
+		Fee:     u.Fee.Sub(prevFee),
+		FeeCcy:  u.FeeCcy,
+		TS:      time.Time(u.UTime),
+	}
+	if reconciled || !u.FillSz.Equal(delta) {
+		// the executions between the known state and the update were missed, they are reported as one at their
+		// average price
+		f.TradeID = ""
+		f.FillSz = delta
+		// delta is positive, the division can't fail
+		f.FillPx, _ = u.AvgPx.Mul(u.AccFillSz).Sub(prevPx.Mul(prevSz)).Div(delta, avgPlaces)
+		f.Reconciled = true
+	}
+	return f
+}
+
+func final(state okex.OrderState) bool {
+	return state == okex.OrderFilled || state == okex.OrderCancel
+}
+
+// rank orders the states an order goes through
+func rank(state okex.OrderState) int {
+	switch state {
+	case okex.OrderPartiallyFilled:
+		return 1
+	case okex.OrderFilled, okex.OrderCancel:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package oms
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/trade"
+	"github.com/dimkus/okex/okextest"
+	requests_ws "github.com/dimkus/okex/requests/ws/private"
+)
+
+func order(state okex.OrderState, utime int64, accFillSz okex.Decimal) *trade.Order {
+	return &trade.Order{OrdID: "1", InstID: "BTC-USDT", State: state, UTime: okex.JSONTime(time.UnixMilli(utime)), AccFillSz: accFillSz}
+}
+
+func TestStale(t *testing.T) {
+	tests := []struct {
+		name string
+		cur  *trade.Order
+		u    *trade.Order
+		want bool
+	}{
+		{"older", order(okex.OrderLive, 2, ""), order(okex.OrderLive, 1, ""), true},
+		{"newer", order(okex.OrderLive, 1, ""), order(okex.OrderPartiallyFilled, 2, "1"), false},
+		{"newer final", order(okex.OrderPartiallyFilled, 1, "1"), order(okex.OrderFilled, 2, "2"), false},
+		{"newer live after final", order(okex.OrderCancel, 1, ""), order(okex.OrderLive, 2, ""), true},
+		{"newer partial after filled", order(okex.OrderFilled, 1, "2"), order(okex.OrderPartiallyFilled, 2, "1"), true},
+		{"same time, later state", order(okex.OrderLive, 1, ""), order(okex.OrderPartiallyFilled, 1, "1"), false},
+		{"same time, earlier state", order(okex.OrderPartiallyFilled, 1, "1"), order(okex.OrderLive, 1, ""), true},
+		{"same time, more filled", order(okex.OrderPartiallyFilled, 1, "1"), order(okex.OrderPartiallyFilled, 1, "1.5"), false},
+		{"same time, less filled", order(okex.OrderPartiallyFilled, 1, "1.5"), order(okex.OrderPartiallyFilled, 1, "1"), true},
+		{"same time, same state", order(okex.OrderLive, 1, ""), order(okex.OrderLive, 1, ""), false},
+	}
+	for _, tt := range tests {
+		if got := stale(tt.cur, tt.u); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	cur := &trade.Order{AccFillSz: "1", AvgPx: "100", Fee: "-0.1"}
+	u := &trade.Order{AccFillSz: "3", AvgPx: "102", FillSz: "2", FillPx: "103", Fee: "-0.3", TradeID: "t"}
+	f := fill(cur, u, false)
+	if f == nil || f.TradeID != "t" || !f.FillSz.Equal("2") || !f.FillPx.Equal("103") || !f.Fee.Equal("-0.2") || f.Reconciled {
+		t.Fatalf("got %+v", f)
+	}
+	// the update reports the last execution only, the ones before it were missed
+	u.FillSz = "1"
+	f = fill(cur, u, false)
+	if f == nil || f.TradeID != "" || !f.FillSz.Equal("2") || !f.FillPx.Equal("103") || !f.Reconciled {
+		t.Fatalf("got %+v", f)
+	}
+	if f := fill(u, u, false); f != nil {
+		t.Fatalf("got %+v for an update filling nothing more", f)
+	}
+}
+
+func TestReconcileCancelsOrdersNotFound(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := okextest.NewServer("key", "secret", "pass")
+	defer s.Close()
+	c, err := s.NewClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixMilli()
+	pending := func(ordID string) map[string]any {
+		return map[string]any{"ordId": ordID, "instId": "BTC-USDT", "state": "live", "uTime": now, "cTime": now}
+	}
+	s.Handle(http.MethodGet, "/api/v5/trade/orders-pending", okextest.OK(pending("1"), pending("2")), okextest.OK(pending("2")))
+	s.Handle(http.MethodGet, "/api/v5/trade/order", okextest.Fail(51603, "Order does not exist"))
+
+	m := NewManager(ctx, c.Rest.Trade, c.Ws.Private)
+	if err := m.Subscribe(requests_ws.Order{InstType: okex.SpotInstrument}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Open("")); n != 2 {
+		t.Fatalf("got %d open orders, want 2", n)
+	}
+	if err := m.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	open := m.Open("")
+	if len(open) != 1 || open[0].OrdID != "2" {
+		t.Fatalf("got %+v, want order 2 only", open)
+	}
+	if o, ok := m.Get("1"); !ok || o.State != okex.OrderCancel {
+		t.Fatalf("got %+v, want order 1 canceled", o)
+	}
+}
@@ -0,0 +1,434 @@
+// Package paper is a local simulated exchange that matches orders against the live public market data.
+//
+// It implements the order surface of rest.Trade, ws.Trade and ws.Private, so a strategy written against
+// rest.TradeI, ws.TradeI and ws.PrivateI runs unchanged on both the real and the simulated exchange.
+//
+//	x := paper.NewExchange(paper.Config{TakerFee: 0.001, MakerFee: 0.0008, Balances: map[string]float64{"USDT": 10000}})
+//	_ = x.Watch(ctx, client.Ws.Public, "BTC-USDT")
+//	var trade rest.TradeI = x.Trade
+//
+// Margin requirements, leverage, funding and liquidations are not simulated.
+package paper
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/book"
+	"github.com/dimkus/okex/events/public"
+	"github.com/dimkus/okex/instrument"
+	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/ws/public"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// changesSize is the room of the changes channel of the watched books, a book whose change is dropped is picked up
+// with its next change
+const changesSize = 64
+
+type (
+	// Config of the simulated exchange
+	Config struct {
+		// MakerFee and TakerFee are the rates charged on the notional of the fills, i.e. 0.001 for 0.1%
+		MakerFee float64
+		TakerFee float64
+		// Balances are the initial cash balances keyed by currency
+		Balances map[string]float64
+		// Instruments provides the contract values of derivatives, a contract is worth one unit of the base currency without it
+		Instruments *instrument.Registry
+		// Clock returns the current time, time.Now by default
+		Clock func() time.Time
+	}
+
+	// Exchange is a simulated exchange, it is safe for concurrent use
+	Exchange struct {
+		Trade     *Trade
+		WsTrade   *WsTrade
+		Private   *Private
+		cfg       Config
+		books     map[string]*book.Book
+		last      map[string]float64
+		taken     map[string]*taken
+		orders    map[string]*order
+		fills     []*trade.TransactionDetail
+		balances  map[string]*balance
+		positions map[string]*position
+		seq       uint64
+		mu        sync.Mutex
+	}
+
+	balance struct {
+		cash   float64
+		frozen float64
+		uTime  time.Time
+	}
+
+	position struct {
+		instID   string
+		instType okex.InstrumentType
+		posSide  okex.PositionSide
+		mgnMode  okex.MarginMode
+		ccy      string
+		// pos is negative for short positions
+		pos   float64
+		avgPx float64
+		cTime time.Time
+		uTime time.Time
+	}
+
+	order struct {
+		m          *trade.Order
+		sz         float64
+		px         float64
+		filled     float64
+		notional   float64
+		fee        float64
+		pnl        float64
+		frozen     float64
+		frozenCcy  string
+		inQuote    bool
+		reduceOnly bool
+	}
+
+	// taken is the liquidity already consumed from the current version of a book
+	taken struct {
+		at     time.Time
+		levels map[float64]float64
+	}
+
+	level struct {
+		px float64
+		sz float64
+	}
+)
+
+// NewExchange returns a pointer to a fresh Exchange
+func NewExchange(cfg Config) *Exchange {
+	if cfg.Clock == nil {
+		cfg.Clock = time.Now
+	}
+	x := &Exchange{
+		cfg:       cfg,
+		books:     make(map[string]*book.Book),
+		last:      make(map[string]float64),
+		taken:     make(map[string]*taken),
+		orders:    make(map[string]*order),
+		balances:  make(map[string]*balance),
+		positions: make(map[string]*position),
+	}
+	for ccy, cash := range cfg.Balances {
+		x.balances[ccy] = &balance{cash: cash, uTime: cfg.Clock()}
+	}
+	x.Trade = &Trade{x: x}
+	x.WsTrade = &WsTrade{t: x.Trade}
+	x.Private = &Private{x: x}
+	return x
+}
+
+// Watch subscribes to the order books and the trades of the given instruments and matches the orders against them.
+//
+// The trades channel of p is taken over by the exchange.
+func (x *Exchange) Watch(ctx context.Context, p *ws.Public, instIDs ...string) error {
+	m := book.NewManager(ctx, p)
+	chCh := make(chan *book.Change, changesSize)
+	trCh := make(chan *public.Trades)
+	obReqs := make([]requests.OrderBook, len(instIDs))
+	for i, instID := range instIDs {
+		obReqs[i] = requests.OrderBook{InstID: instID, Channel: book.ChannelBooks}
+	}
+	go func() {
+		for {
+			select {
+			case c := <-chCh:
+				if b, ok := m.Book(c.Channel, c.InstID); ok && c.Err == nil {
+					x.SetBook(b)
+				}
+			case e := <-trCh:
+				for _, t := range e.Trades {
+					x.ApplyTrade(t)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	if err := m.Subscribe(obReqs, chCh); err != nil {
+		return err
+	}
+	for _, instID := range instIDs {
+		if _, err := p.Trades(requests.Trades{InstID: instID}, trCh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBook sets the order book of its instrument and fills the resting orders it crosses
+func (x *Exchange) SetBook(b *book.Book) {
+	x.mu.Lock()
+	x.books[b.InstID] = b
+	var evs changes
+	for _, side := range []okex.OrderSide{okex.OrderBuy, okex.OrderSell} {
+		for _, o := range x.resting(b.InstID, side) {
+			for _, l := range x.liquidity(o) {
+				if o.remaining() <= 0 {
+					break
+				}
+				sz := math.Min(o.remaining(), l.sz)
+				x.take(b.InstID, l.px, sz)
+				evs.merge(x.fill(o, o.px, sz, true))
+			}
+		}
+	}
+	out := x.batch(evs)
+	x.mu.Unlock()
+	x.Private.emit(out)
+}
+
+// ApplyTrade records the last price of the instrument and fills the resting orders the trade went through, it returns
+// the size filled
+func (x *Exchange) ApplyTrade(t *market.Trade) float64 {
+	px, sz := float64(t.Px), float64(t.Sz)
+	x.mu.Lock()
+	x.last[t.InstID] = px
+	var evs changes
+	for _, side := range []okex.OrderSide{okex.OrderBuy, okex.OrderSell} {
+		for _, o := range x.resting(t.InstID, side) {
+			if sz <= 0 {
+				break
+			}
+			// a trade sold by the taker hits the bids, a trade bought lifts the asks
+			if (side == okex.OrderBuy && (o.px < px || t.Side == okex.TradeBuySide)) ||
+				(side == okex.OrderSell && (o.px > px || t.Side == okex.TradeSellSide)) {
+				continue
+			}
+			fsz := math.Min(o.remaining(), sz)
+			sz -= fsz
+			evs.merge(x.fill(o, o.px, fsz, true))
+		}
+	}
+	b := x.batch(evs)
+	x.mu.Unlock()
+	x.Private.emit(b)
+	return float64(t.Sz) - sz
+}
+
+// Balances returns the cash balances keyed by currency
+func (x *Exchange) Balances() map[string]float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	res := make(map[string]float64, len(x.balances))
+	for ccy, b := range x.balances {
+		res[ccy] = b.cash
+	}
+	return res
+}
+
+// Positions returns the open positions valued at the last prices
+func (x *Exchange) Positions() []*account.Position {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	res := make([]*account.Position, 0, len(x.positions))
+	for _, p := range x.positions {
+		if p.pos != 0 {
+			res = append(res, x.positionModel(p))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].InstID < res[j].InstID })
+	return res
+}
+
+// Orders returns every order placed on the exchange, oldest first
+func (x *Exchange) Orders() []*trade.Order {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	res := make([]*trade.Order, 0, len(x.orders))
+	for _, o := range x.orders {
+		res = append(res, x.orderModel(o))
+	}
+	sort.Slice(res, func(i, j int) bool { return cursor(res[i].OrdID) < cursor(res[j].OrdID) })
+	return res
+}
+
+// Fills returns every fill of the orders, oldest first
+func (x *Exchange) Fills() []*trade.TransactionDetail {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	res := make([]*trade.TransactionDetail, len(x.fills))
+	for i, f := range x.fills {
+		d := *f
+		res[i] = &d
+	}
+	return res
+}
+
+// Mark returns the price the positions of the instrument are valued at
+func (x *Exchange) Mark(instID string) (float64, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.mark(instID)
+}
+
+func (x *Exchange) mark(instID string) (float64, bool) {
+	if px, ok := x.last[instID]; ok {
+		return px, true
+	}
+	if b, ok := x.books[instID]; ok {
+		bid, bOk := b.BestBid()
+		ask, aOk := b.BestAsk()
+		if bOk && aOk {
+			return (bid.Px + ask.Px) / 2, true
+		}
+	}
+	return 0, false
+}
+
+// levels returns the opposite side of the book an order of the given side takes liquidity from, net of the liquidity taken already.
+//
+// Without a book the last price is used with an unlimited size.
+func (x *Exchange) levels(instID string, side okex.OrderSide) []level {
+	b, ok := x.books[instID]
+	if !ok {
+		if px, ok := x.last[instID]; ok {
+			return []level{{px: px, sz: math.Inf(1)}}
+		}
+		return nil
+	}
+	bids, asks := b.Depth(0)
+	src := asks
+	if side == okex.OrderSell {
+		src = bids
+	}
+	t := x.taken[instID]
+	if t != nil && !t.at.Equal(b.UpdatedAt()) {
+		t = nil
+		delete(x.taken, instID)
+	}
+	res := make([]level, 0, len(src))
+	for _, l := range src {
+		sz := l.Sz
+		if t != nil {
+			sz -= t.levels[l.Px]
+		}
+		if sz > 0 {
+			res = append(res, level{px: l.Px, sz: sz})
+		}
+	}
+	return res
+}
+
+// liquidity returns the levels the order crosses
+func (x *Exchange) liquidity(o *order) []level {
+	levels := x.levels(o.m.InstID, o.m.Side)
+	if o.m.OrdType == okex.OrderMarket || o.m.OrdType == okex.OrderOptimalLimitIoc {
+		return levels
+	}
+	for i, l := range levels {
+		if (o.m.Side == okex.OrderBuy && l.px > o.px) || (o.m.Side == okex.OrderSell && l.px < o.px) {
+			return levels[:i]
+		}
+	}
+	return levels
+}
+
+func (x *Exchange) take(instID string, px, sz float64) {
+	b, ok := x.books[instID]
+	if !ok {
+		return
+	}
+	t := x.taken[instID]
+	if t == nil {
+		t = &taken{at: b.UpdatedAt(), levels: make(map[float64]float64)}
+		x.taken[instID] = t
+	}
+	t.levels[px] += sz
+}
+
+// resting returns the live orders of one side of the instrument by price and time priority, the best price first
+func (x *Exchange) resting(instID string, side okex.OrderSide) []*order {
+	var res []*order
+	for _, o := range x.orders {
+		if o.m.InstID == instID && o.m.Side == side && o.live() {
+			res = append(res, o)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.px != b.px {
+			if side == okex.OrderBuy {
+				return a.px > b.px
+			}
+			return a.px < b.px
+		}
+		return cursor(a.m.OrdID) < cursor(b.m.OrdID)
+	})
+	return res
+}
+
+func (x *Exchange) balance(ccy string) *balance {
+	b, ok := x.balances[ccy]
+	if !ok {
+		b = &balance{}
+		x.balances[ccy] = b
+	}
+	return b
+}
+
+// contract returns the value of a contract of the instrument and whether it is an inverse contract
+func (x *Exchange) contract(instID string) (ctVal float64, inverse bool) {
+	if x.cfg.Instruments != nil {
+		if inst, ok := x.cfg.Instruments.Get(instID); ok && inst.CtVal != "" {
+			ctVal = inst.CtVal.Float64()
+			if inst.CtMult != "" {
+				ctVal *= inst.CtMult.Float64()
+			}
+			return ctVal, inst.CtType == okex.ContractInverseType
+		}
+	}
+	return 1, false
+}
+
+func (x *Exchange) instType(instID string) okex.InstrumentType {
+	if x.cfg.Instruments != nil {
+		if inst, ok := x.cfg.Instruments.Get(instID); ok {
+			return inst.InstType
+		}
+	}
+	parts := strings.Split(instID, "-")
+	switch {
+	case len(parts) == 2:
+		return okex.SpotInstrument
+	case parts[len(parts)-1] == "SWAP":
+		return okex.SwapInstrument
+	case len(parts) == 3:
+		return okex.FuturesInstrument
+	}
+	return okex.OptionsInstrument
+}
+
+// currencies returns the base and the quote currency of the instrument
+func currencies(instID string) (base, quote string) {
+	parts := strings.Split(instID, "-")
+	if len(parts) < 2 {
+		return instID, ""
+	}
+	return parts[0], parts[1]
+}
+
+func (o *order) live() bool {
+	return o.m.State == okex.OrderLive || o.m.State == okex.OrderPartiallyFilled
+}
+
+// remaining returns the unfilled size, in the quote currency for market buys sized in it
+func (o *order) remaining() float64 {
+	if o.inQuote {
+		return o.sz - o.notional
+	}
+	return o.sz - o.filled
+}
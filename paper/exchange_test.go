@@ -0,0 +1,45 @@
+package paper_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/paper"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+)
+
+func TestRestingOrdersPriority(t *testing.T) {
+	x := paper.NewExchange(paper.Config{Balances: map[string]float64{"USDT": 10000, "BTC": 10}})
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT", Px: 100, Sz: 1})
+	var reqs []requests.PlaceOrder
+	// the tenth order ranks after the ninth one although "10" < "9"
+	for i := 0; i < 10; i++ {
+		reqs = append(reqs, requests.PlaceOrder{InstID: "BTC-USDT", ClOrdID: "b" + strconv.Itoa(i+1), TdMode: okex.TradeCashMode,
+			Side: okex.OrderBuy, OrdType: okex.OrderLimit, Sz: "1", Px: "90"})
+	}
+	// a better bid placed last, and a sell whose price would rank it first among the buys
+	reqs = append(reqs,
+		requests.PlaceOrder{InstID: "BTC-USDT", ClOrdID: "best", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit, Sz: "1", Px: "95"},
+		requests.PlaceOrder{InstID: "BTC-USDT", ClOrdID: "ask", TdMode: okex.TradeCashMode, Side: okex.OrderSell, OrdType: okex.OrderLimit, Sz: "1", Px: "200"},
+	)
+	if _, err := x.Trade.PlaceOrder(context.Background(), reqs); err != nil {
+		t.Fatal(err)
+	}
+
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT", Px: 90, Sz: 10, Side: okex.TradeSellSide})
+	filled := make(map[string]bool)
+	for _, o := range x.Orders() {
+		filled[o.ClOrdID] = o.State == okex.OrderFilled
+	}
+	for i := 1; i <= 9; i++ {
+		if !filled["b"+strconv.Itoa(i)] {
+			t.Errorf("b%d not filled", i)
+		}
+	}
+	if !filled["best"] || filled["b10"] || filled["ask"] {
+		t.Fatalf("got %v, want the best bid and the nine oldest ones filled", filled)
+	}
+}
@@ -0,0 +1,409 @@
+package paper
+
+import (
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Error codes of the rejected requests, as used by the exchange
+const (
+	codeParameter         = 51000
+	codeDuplicatedClOrdID = 51016
+	codeInsufficient      = 51008
+	codeReduceOnly        = 51169
+	codeOrderNotFound     = 51603
+	codeOrderCompleted    = 51402
+)
+
+// place the order and match it against the book
+func (x *Exchange) place(req requests.PlaceOrder) (*trade.PlaceOrder, changes) {
+	res := &trade.PlaceOrder{ClOrdID: req.ClOrdID, Tag: req.Tag}
+	reject := func(code int, msg string) (*trade.PlaceOrder, changes) {
+		res.SCode, res.SMsg = okex.JSONInt64(code), msg
+		return res, changes{}
+	}
+	now := x.cfg.Clock()
+	sz, px := req.Sz.Float64(), req.Px.Float64()
+	market := req.OrdType == okex.OrderMarket || req.OrdType == okex.OrderOptimalLimitIoc
+	switch {
+	case sz <= 0:
+		return reject(codeParameter, "Parameter sz error")
+	case !market && px <= 0:
+		return reject(codeParameter, "Parameter px error")
+	case req.Side != okex.OrderBuy && req.Side != okex.OrderSell:
+		return reject(codeParameter, "Parameter side error")
+	}
+	if req.ClOrdID != "" {
+		for _, o := range x.orders {
+			if o.m.ClOrdID == req.ClOrdID && o.live() {
+				return reject(codeDuplicatedClOrdID, "Duplicated clOrdId")
+			}
+		}
+	}
+	ref, ok := x.mark(req.InstID)
+	if !ok {
+		return reject(codeParameter, "no market data for "+req.InstID)
+	}
+	if !market {
+		ref = px
+	}
+
+	o := &order{
+		m: &trade.Order{
+			InstID:   req.InstID,
+			Ccy:      req.Ccy,
+			ClOrdID:  req.ClOrdID,
+			Tag:      req.Tag,
+			Px:       req.Px,
+			Sz:       req.Sz,
+			State:    okex.OrderLive,
+			TdMode:   req.TdMode,
+			PosSide:  req.PosSide,
+			Side:     req.Side,
+			OrdType:  req.OrdType,
+			InstType: x.instType(req.InstID),
+			TgtCcy:   req.TgtCcy,
+			CTime:    okex.JSONTime(now),
+			UTime:    okex.JSONTime(now),
+		},
+		sz:         sz,
+		px:         px,
+		reduceOnly: req.ReduceOnly,
+	}
+	if o.m.PosSide == "" && o.m.TdMode != okex.TradeCashMode {
+		o.m.PosSide = okex.PositionNetSide
+	}
+	if o.m.TdMode == okex.TradeCashMode {
+		// spot market buys are sized in the quote currency unless told otherwise
+		o.inQuote = req.TgtCcy == okex.QuantityQuoteCcy || (market && req.Side == okex.OrderBuy && req.TgtCcy == "")
+		base, quote := currencies(req.InstID)
+		need, ccy := sz, base
+		switch {
+		case o.inQuote && req.Side == okex.OrderBuy:
+			ccy = quote
+		case o.inQuote:
+			need = sz / ref
+		case req.Side == okex.OrderBuy:
+			need, ccy = sz*ref, quote
+		}
+		b := x.balance(ccy)
+		if b.cash-b.frozen < need {
+			return reject(codeInsufficient, "Insufficient balance")
+		}
+		if !market {
+			o.frozen, o.frozenCcy = need, ccy
+			b.frozen += need
+		}
+	} else if o.reduceOnly {
+		p := x.positions[positionKey(req.InstID, o.m.PosSide)]
+		if p == nil || p.pos == 0 || (p.pos > 0) == (req.Side == okex.OrderBuy) {
+			return reject(codeReduceOnly, "Reduce only order can only reduce the position")
+		}
+		o.sz = math.Min(o.sz, math.Abs(p.pos))
+	}
+
+	x.seq++
+	o.m.OrdID = strconv.FormatUint(x.seq, 10)
+	x.orders[o.m.OrdID] = o
+	res.OrdID = o.m.OrdID
+	return res, x.execute(o)
+}
+
+// execute matches the order as a taker and cancels or rests what is left according to its type
+func (x *Exchange) execute(o *order) changes {
+	liquidity := x.liquidity(o)
+	evs := changes{orders: []*trade.Order{x.orderModel(o)}}
+	switch o.m.OrdType {
+	case okex.OrderPostOnly:
+		if len(liquidity) > 0 {
+			// a post only order that would take liquidity is canceled
+			evs.merge(x.cancel(o))
+			return evs
+		}
+	case okex.OrderFOK:
+		available := 0.0
+		for _, l := range liquidity {
+			available += l.sz
+		}
+		if available < o.remaining() {
+			evs.merge(x.cancel(o))
+			return evs
+		}
+	}
+	for _, l := range liquidity {
+		left := o.remaining()
+		if left <= 0 {
+			break
+		}
+		sz := math.Min(left, l.sz)
+		if o.inQuote {
+			sz = math.Min(left/l.px, l.sz)
+		}
+		x.take(o.m.InstID, l.px, sz)
+		evs.merge(x.fill(o, l.px, sz, false))
+	}
+	switch o.m.OrdType {
+	case okex.OrderMarket, okex.OrderOptimalLimitIoc, okex.OrderIOC, okex.OrderFOK:
+		if o.live() {
+			evs.merge(x.cancel(o))
+		}
+	}
+	return evs
+}
+
+func (x *Exchange) cancel(o *order) changes {
+	now := x.cfg.Clock()
+	if o.frozen > 0 {
+		b := x.balance(o.frozenCcy)
+		b.frozen -= o.frozen
+		b.uTime = now
+		o.frozen = 0
+	}
+	o.m.State = okex.OrderCancel
+	o.m.UTime = okex.JSONTime(now)
+	o.m.FillPx, o.m.FillSz = "", ""
+	return changes{orders: []*trade.Order{x.orderModel(o)}}
+}
+
+// amend the size and the price of a live order, the new size includes the filled part
+func (x *Exchange) amend(o *order, req requests.AmendOrder) changes {
+	if req.NewSz != "" {
+		o.sz = req.NewSz.Float64()
+		o.m.Sz = req.NewSz
+	}
+	if req.NewPx != "" {
+		o.px = req.NewPx.Float64()
+		o.m.Px = req.NewPx
+	}
+	if o.frozen > 0 {
+		need := o.sz - o.filled
+		if o.m.Side == okex.OrderBuy {
+			need *= o.px
+		}
+		b := x.balance(o.frozenCcy)
+		b.frozen += need - o.frozen
+		o.frozen = need
+	}
+	o.m.UTime = okex.JSONTime(x.cfg.Clock())
+	if o.remaining() <= 0 {
+		o.m.State = okex.OrderFilled
+		return changes{orders: []*trade.Order{x.orderModel(o)}}
+	}
+	return x.execute(o)
+}
+
+// fill sz of the order at px and update the balances and the positions
+func (x *Exchange) fill(o *order, px, sz float64, maker bool) changes {
+	now := x.cfg.Clock()
+	rate, execType := x.cfg.TakerFee, okex.OrderTakerFlow
+	if maker {
+		rate, execType = x.cfg.MakerFee, okex.OrderMakerFlow
+	}
+	var (
+		fee, pnl float64
+		feeCcy   string
+		evs      changes
+	)
+	base, quote := currencies(o.m.InstID)
+	if o.m.TdMode == okex.TradeCashMode {
+		if o.m.Side == okex.OrderBuy {
+			fee, feeCcy = sz*rate, base
+			x.balance(quote).cash -= px * sz
+			x.balance(base).cash += sz - fee
+		} else {
+			fee, feeCcy = px*sz*rate, quote
+			x.balance(base).cash -= sz
+			x.balance(quote).cash += px*sz - fee
+		}
+		if o.frozen > 0 {
+			release := sz
+			if o.m.Side == okex.OrderBuy {
+				release *= o.px
+			}
+			release = math.Min(release, o.frozen)
+			o.frozen -= release
+			x.balance(o.frozenCcy).frozen -= release
+		}
+		x.balance(base).uTime, x.balance(quote).uTime = now, now
+		evs.accounts = append(evs.accounts, base, quote)
+	} else {
+		ctVal, inverse := x.contract(o.m.InstID)
+		feeCcy = quote
+		value := sz * ctVal * px
+		if inverse {
+			feeCcy = base
+			value = sz * ctVal / px
+		}
+		fee = value * rate
+		pnl = x.position(o, px, sz, now)
+		b := x.balance(feeCcy)
+		b.cash += pnl - fee
+		b.uTime = now
+		evs.accounts = append(evs.accounts, feeCcy)
+		evs.positions = append(evs.positions, positionKey(o.m.InstID, o.m.PosSide))
+	}
+
+	o.filled += sz
+	o.notional += px * sz
+	o.fee -= fee
+	o.pnl += pnl
+	o.m.FillPx = okex.DecimalFromFloat(px)
+	o.m.FillSz = okex.DecimalFromFloat(sz)
+	o.m.FillTime = okex.JSONFloat64(now.UnixMilli())
+	o.m.AccFillSz = okex.DecimalFromFloat(o.filled)
+	o.m.AvgPx = okex.DecimalFromFloat(o.notional / o.filled)
+	o.m.Fee = okex.DecimalFromFloat(o.fee)
+	o.m.FeeCcy = feeCcy
+	o.m.Pnl = okex.JSONFloat64(o.pnl)
+	o.m.UTime = okex.JSONTime(now)
+	o.m.State = okex.OrderPartiallyFilled
+	if o.remaining() <= 1e-12 {
+		o.m.State = okex.OrderFilled
+	}
+	if o.frozen > 0 && o.m.State == okex.OrderFilled {
+		x.balance(o.frozenCcy).frozen -= o.frozen
+		o.frozen = 0
+	}
+	x.seq++
+	o.m.TradeID = strconv.FormatUint(x.seq, 10)
+	x.fills = append(x.fills, &trade.TransactionDetail{
+		InstID:   o.m.InstID,
+		OrdID:    o.m.OrdID,
+		TradeID:  o.m.TradeID,
+		ClOrdID:  o.m.ClOrdID,
+		BillID:   o.m.TradeID,
+		Tag:      o.m.Tag,
+		FillPx:   o.m.FillPx,
+		FillSz:   o.m.FillSz,
+		FeeCcy:   feeCcy,
+		Fee:      okex.DecimalFromFloat(-fee),
+		InstType: o.m.InstType,
+		Side:     o.m.Side,
+		PosSide:  o.m.PosSide,
+		ExecType: execType,
+		TS:       okex.JSONTime(now),
+	})
+	evs.orders = append(evs.orders, x.orderModel(o))
+	return evs
+}
+
+// position applies a fill of a derivatives order to its position and returns the realized pnl
+func (x *Exchange) position(o *order, px, sz float64, now time.Time) float64 {
+	key := positionKey(o.m.InstID, o.m.PosSide)
+	p, ok := x.positions[key]
+	if !ok {
+		p = &position{instID: o.m.InstID, instType: o.m.InstType, posSide: o.m.PosSide, mgnMode: okex.MarginMode(o.m.TdMode), cTime: now}
+		x.positions[key] = p
+	}
+	ctVal, inverse := x.contract(o.m.InstID)
+	base, quote := currencies(o.m.InstID)
+	p.ccy = quote
+	if inverse {
+		p.ccy = base
+	}
+	p.uTime = now
+	delta := sz
+	if o.m.Side == okex.OrderSell {
+		delta = -sz
+	}
+	if p.pos == 0 || (p.pos > 0) == (delta > 0) {
+		p.avgPx = average(p.avgPx, math.Abs(p.pos), px, sz, inverse)
+		p.pos += delta
+		return 0
+	}
+	closed := math.Min(sz, math.Abs(p.pos))
+	realized := pnl(p.avgPx, px, closed, ctVal, inverse, p.pos > 0)
+	p.pos += math.Copysign(closed, delta)
+	if left := sz - closed; left > 0 {
+		// net mode flips the position
+		p.pos = math.Copysign(left, delta)
+		p.avgPx = px
+	}
+	if math.Abs(p.pos) < 1e-12 {
+		p.pos = 0
+	}
+	return realized
+}
+
+func (x *Exchange) orderModel(o *order) *trade.Order {
+	m := *o.m
+	return &m
+}
+
+func (x *Exchange) positionModel(p *position) *account.Position {
+	ctVal, inverse := x.contract(p.instID)
+	var upl float64
+	last, ok := x.mark(p.instID)
+	if ok && p.pos != 0 {
+		upl = pnl(p.avgPx, last, math.Abs(p.pos), ctVal, inverse, p.pos > 0)
+	}
+	pos := p.pos
+	if p.posSide != okex.PositionNetSide {
+		pos = math.Abs(pos)
+	}
+	return &account.Position{
+		InstID:   p.instID,
+		Ccy:      p.ccy,
+		PosID:    positionKey(p.instID, p.posSide),
+		Pos:      okex.JSONFloat64(pos),
+		AvailPos: okex.JSONFloat64(math.Abs(pos)),
+		AvgPx:    okex.JSONFloat64(p.avgPx),
+		Upl:      okex.JSONFloat64(upl),
+		Last:     okex.JSONFloat64(last),
+		PosSide:  p.posSide,
+		MgnMode:  p.mgnMode,
+		InstType: p.instType,
+		CTime:    okex.JSONTime(p.cTime),
+		UTime:    okex.JSONTime(p.uTime),
+	}
+}
+
+func (x *Exchange) balanceModel(ccys []string) *account.Balance {
+	res := &account.Balance{UTime: okex.JSONTime(x.cfg.Clock())}
+	for _, ccy := range ccys {
+		b := x.balance(ccy)
+		res.Details = append(res.Details, &account.BalanceDetails{
+			Ccy:       ccy,
+			Eq:        okex.JSONFloat64(b.cash),
+			CashBal:   okex.JSONFloat64(b.cash),
+			AvailEq:   okex.JSONFloat64(b.cash - b.frozen),
+			AvailBal:  okex.JSONFloat64(b.cash - b.frozen),
+			FrozenBal: okex.JSONFloat64(b.frozen),
+			OrdFrozen: okex.JSONFloat64(b.frozen),
+			UTime:     okex.JSONTime(b.uTime),
+		})
+	}
+	return res
+}
+
+func positionKey(instID string, posSide okex.PositionSide) string {
+	return instID + "|" + string(posSide)
+}
+
+// average returns the entry price after adding sz at px to a position of pos at avg, inverse contracts average the reciprocals
+func average(avg, pos, px, sz float64, inverse bool) float64 {
+	if pos == 0 {
+		return px
+	}
+	if inverse {
+		return (pos + sz) / (pos/avg + sz/px)
+	}
+	return (avg*pos + px*sz) / (pos + sz)
+}
+
+// pnl returns the profit of closing sz contracts entered at avg for px
+func pnl(avg, px, sz, ctVal float64, inverse, long bool) float64 {
+	res := (px - avg) * sz * ctVal
+	if inverse {
+		res = (1/avg - 1/px) * sz * ctVal
+	}
+	if !long {
+		res = -res
+	}
+	return res
+}
@@ -0,0 +1,199 @@
+package paper_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/book"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/models/market"
+	"github.com/dimkus/okex/paper"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	requests_ws "github.com/dimkus/okex/requests/ws/private"
+)
+
+// orderBook returns a book of BTC-USDT with the bids at 99 x 1, 98 x 2 and the asks at 101 x 1, 102 x 2
+func orderBook() *book.Book {
+	entity := func(px, sz float64) *market.OrderBookEntity {
+		return &market.OrderBookEntity{DepthPrice: px, Size: sz}
+	}
+	b := book.NewBook("BTC-USDT", book.ChannelBooks)
+	b.Snapshot(&market.OrderBookWs{
+		Bids: []*market.OrderBookEntity{entity(99, 1), entity(98, 2)},
+		Asks: []*market.OrderBookEntity{entity(101, 1), entity(102, 2)},
+		TS:   okex.JSONTime(time.Now()),
+	})
+	return b
+}
+
+func exchange() *paper.Exchange {
+	x := paper.NewExchange(paper.Config{TakerFee: 0.001, MakerFee: 0.0005, Balances: map[string]float64{"USDT": 10000, "BTC": 10}})
+	x.SetBook(orderBook())
+	return x
+}
+
+func TestPlaceOrder(t *testing.T) {
+	limit := func(ordType okex.OrderType, side okex.OrderSide, sz, px okex.Decimal) requests.PlaceOrder {
+		return requests.PlaceOrder{InstID: "BTC-USDT", TdMode: okex.TradeCashMode, Side: side, OrdType: ordType, Sz: sz, Px: px}
+	}
+	tests := []struct {
+		name      string
+		req       requests.PlaceOrder
+		state     okex.OrderState
+		accFillSz okex.Decimal
+		avgPx     float64
+		fee       float64
+	}{
+		{"post only crossing", limit(okex.OrderPostOnly, okex.OrderBuy, "1", "101"), okex.OrderCancel, "0", 0, 0},
+		{"post only resting", limit(okex.OrderPostOnly, okex.OrderBuy, "1", "100"), okex.OrderLive, "0", 0, 0},
+		{"ioc fills what it crosses", limit(okex.OrderIOC, okex.OrderBuy, "2", "101"), okex.OrderCancel, "1", 101, -0.001},
+		{"fok short of liquidity", limit(okex.OrderFOK, okex.OrderBuy, "2", "101"), okex.OrderCancel, "0", 0, 0},
+		{"fok walks the book", limit(okex.OrderFOK, okex.OrderBuy, "3", "102"), okex.OrderFilled, "3", 305.0 / 3, -0.003},
+		{"limit rests the rest", limit(okex.OrderLimit, okex.OrderBuy, "2", "101"), okex.OrderPartiallyFilled, "1", 101, -0.001},
+		{"market sell pays the fee in the quote", limit(okex.OrderMarket, okex.OrderSell, "2", ""), okex.OrderFilled, "2", 98.5, -0.197},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := exchange()
+			res, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{tt.req})
+			if err != nil {
+				t.Fatal(err)
+			}
+			orders := x.Orders()
+			if len(orders) != 1 || orders[0].OrdID != res.PlaceOrders[0].OrdID {
+				t.Fatalf("got %+v", orders)
+			}
+			o := orders[0]
+			if o.State != tt.state || !o.AccFillSz.Equal(tt.accFillSz) || !near(o.AvgPx.Float64(), tt.avgPx) || !near(o.Fee.Float64(), tt.fee) {
+				t.Fatalf("got state %s filled %s at %s fee %s, want %s %s at %v fee %v", o.State, o.AccFillSz, o.AvgPx, o.Fee, tt.state, tt.accFillSz, tt.avgPx, tt.fee)
+			}
+		})
+	}
+}
+
+func TestRestingOrderIsFilledAsMaker(t *testing.T) {
+	x := exchange()
+	req := requests.PlaceOrder{InstID: "BTC-USDT", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit, Sz: "2", Px: "100"}
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{req}); err != nil {
+		t.Fatal(err)
+	}
+	if bal := x.Balances()["USDT"]; bal != 10000 {
+		t.Fatalf("got %v USDT before the fill", bal)
+	}
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT", Px: 100, Sz: 0.5, Side: okex.TradeSellSide})
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT", Px: 100, Sz: 5, Side: okex.TradeBuySide})
+	o := x.Orders()[0]
+	if o.State != okex.OrderPartiallyFilled || !o.AccFillSz.Equal("0.5") {
+		t.Fatalf("got %s filled %s, want the trade sold into the bid only", o.State, o.AccFillSz)
+	}
+	fills := x.Fills()
+	if len(fills) != 1 || fills[0].ExecType != okex.OrderMakerFlow || !near(fills[0].Fee.Float64(), -0.00025) {
+		t.Fatalf("got %+v, want a maker fill paying the maker fee in BTC", fills)
+	}
+	if bal := x.Balances(); !near(bal["USDT"], 9950) || !near(bal["BTC"], 10.49975) {
+		t.Fatalf("got %v", bal)
+	}
+}
+
+func TestReduceOnly(t *testing.T) {
+	x := paper.NewExchange(paper.Config{Balances: map[string]float64{"USDT": 10000}})
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT-SWAP", Px: 100, Sz: 1})
+	order := func(side okex.OrderSide, sz okex.Decimal, reduceOnly bool) requests.PlaceOrder {
+		return requests.PlaceOrder{InstID: "BTC-USDT-SWAP", TdMode: okex.TradeCrossMode, Side: side, OrdType: okex.OrderMarket, Sz: sz, ReduceOnly: reduceOnly}
+	}
+	if res, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{order(okex.OrderSell, "1", true)}); err == nil || res.PlaceOrders[0].SCode != 51169 {
+		t.Fatalf("got %+v, %v, want a rejection without a position", res.PlaceOrders[0], err)
+	}
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{order(okex.OrderBuy, "2", false)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{order(okex.OrderBuy, "1", true)}); err == nil {
+		t.Fatal("a reduce only order added to the position")
+	}
+	// the size is capped at the position, it never flips it
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{order(okex.OrderSell, "5", true)}); err != nil {
+		t.Fatal(err)
+	}
+	if pos := x.Positions(); len(pos) != 0 {
+		t.Fatalf("got %+v, want the position closed", pos[0])
+	}
+	if o := x.Orders()[1]; !o.AccFillSz.Equal("2") {
+		t.Fatalf("got %s filled, want the size of the position", o.AccFillSz)
+	}
+}
+
+func TestPrivateEvents(t *testing.T) {
+	x := exchange()
+	orders := make(chan *private.Order, 16)
+	positions := make(chan *private.Position, 16)
+	accounts := make(chan *private.Account, 16)
+	if _, err := x.Private.Order(requests_ws.Order{InstType: okex.AnyInstrument}, orders); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Private.Position(requests_ws.Position{InstType: okex.AnyInstrument}, positions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Private.Account(requests_ws.Account{Ccy: "USDT"}, accounts); err != nil {
+		t.Fatal(err)
+	}
+
+	x.ApplyTrade(&market.Trade{InstID: "BTC-USDT-SWAP", Px: 100, Sz: 1})
+	req := requests.PlaceOrder{InstID: "BTC-USDT-SWAP", TdMode: okex.TradeCrossMode, Side: okex.OrderSell, OrdType: okex.OrderMarket, Sz: "2"}
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{req}); err != nil {
+		t.Fatal(err)
+	}
+	var states []okex.OrderState
+	for len(states) < 2 {
+		e := next(t, orders)
+		for _, o := range e.Orders {
+			states = append(states, o.State)
+		}
+	}
+	if states[0] != okex.OrderLive || states[1] != okex.OrderFilled {
+		t.Fatalf("got %v, want live then filled", states)
+	}
+	p := next(t, positions)
+	if len(p.Positions) != 1 || p.Positions[0].InstID != "BTC-USDT-SWAP" || p.Positions[0].Pos != -2 {
+		t.Fatalf("got %+v, want the net short position", p.Positions)
+	}
+	a := next(t, accounts)
+	if len(a.Balances) != 1 || len(a.Balances[0].Details) != 1 || a.Balances[0].Details[0].Ccy != "USDT" {
+		t.Fatalf("got %+v, want the USDT balance", a.Balances)
+	}
+	// the spot orders are not pushed to the subscription of the swap instrument
+	swap := make(chan *private.Order, 16)
+	if _, err := x.Private.Order(requests_ws.Order{InstType: okex.SwapInstrument}, swap); err != nil {
+		t.Fatal(err)
+	}
+	spot := requests.PlaceOrder{InstID: "BTC-USDT", TdMode: okex.TradeCashMode, Side: okex.OrderBuy, OrdType: okex.OrderLimit, Sz: "1", Px: "90"}
+	if _, err := x.Trade.PlaceOrder(context.Background(), []requests.PlaceOrder{spot}); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(t, orders); e.Orders[0].InstID != "BTC-USDT" {
+		t.Fatalf("got %+v", e.Orders[0])
+	}
+	select {
+	case e := <-swap:
+		t.Fatalf("got %+v on the swap subscription", e.Orders[0])
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func next[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	var zero T
+	return zero
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
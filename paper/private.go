@@ -0,0 +1,218 @@
+package paper
+
+import (
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/events"
+	"github.com/dimkus/okex/events/private"
+	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/ws/private"
+	"sort"
+	"sync"
+)
+
+type (
+	// Private emits the account, positions and orders events of the simulated account like ws.Private does
+	Private struct {
+		x         *Exchange
+		accounts  []*feed[*private.Account, requests.Account]
+		positions []*feed[*private.Position, requests.Position]
+		orders    []*feed[*private.Order, requests.Order]
+		mu        sync.RWMutex
+	}
+
+	// feed is a subscription and the request it was made with
+	feed[T any, R comparable] struct {
+		req R
+		sub *ws.Subscription[T]
+	}
+
+	// changes collects what changed while processing a request or some market data
+	changes struct {
+		orders    []*trade.Order
+		positions []string
+		accounts  []string
+	}
+
+	// batch is the events built while the exchange was locked, ready to be emitted
+	batch struct {
+		orders    []*trade.Order
+		positions []*account.Position
+		balance   *account.Balance
+	}
+)
+
+// Account
+// Events are pushed whenever a fill, an order or a cancel changes a balance.
+func (p *Private) Account(req requests.Account, ch ...chan *private.Account) (*ws.Subscription[*private.Account], error) {
+	return subscribe(p, &p.accounts, req, "account", ch), nil
+}
+
+// UAccount closes every subscription made with req
+func (p *Private) UAccount(req requests.Account) error {
+	return unsubscribe(p, &p.accounts, req)
+}
+
+// Position
+// Events are pushed on every fill of a derivatives order.
+func (p *Private) Position(req requests.Position, ch ...chan *private.Position) (*ws.Subscription[*private.Position], error) {
+	return subscribe(p, &p.positions, req, "positions", ch), nil
+}
+
+// UPosition closes every subscription made with req
+func (p *Private) UPosition(req requests.Position) error {
+	return unsubscribe(p, &p.positions, req)
+}
+
+// Order
+// Events are pushed when an order is placed, filled, amended or canceled.
+func (p *Private) Order(req requests.Order, ch ...chan *private.Order) (*ws.Subscription[*private.Order], error) {
+	return subscribe(p, &p.orders, req, "orders", ch), nil
+}
+
+// UOrder closes every subscription made with req
+func (p *Private) UOrder(req requests.Order) error {
+	return unsubscribe(p, &p.orders, req)
+}
+
+// emit the events to the subscriptions, it must be called without holding the lock of the exchange
+func (p *Private) emit(b *batch) {
+	if b == nil {
+		return
+	}
+	p.mu.RLock()
+	accounts, positions, orders := p.accounts, p.positions, p.orders
+	p.mu.RUnlock()
+
+	for _, f := range orders {
+		for _, o := range b.orders {
+			if match(f.req.InstType, f.req.InstID, o.InstType, o.InstID) {
+				f.sub.Publish(&private.Order{Arg: argument("orders", string(f.req.InstType), f.req.InstID), Orders: []*trade.Order{o}})
+			}
+		}
+	}
+	for _, f := range positions {
+		var matched []*account.Position
+		for _, pos := range b.positions {
+			if match(f.req.InstType, f.req.InstID, pos.InstType, pos.InstID) {
+				matched = append(matched, pos)
+			}
+		}
+		if len(matched) > 0 {
+			f.sub.Publish(&private.Position{Arg: argument("positions", string(f.req.InstType), f.req.InstID), Positions: matched})
+		}
+	}
+	if b.balance == nil {
+		return
+	}
+	for _, f := range accounts {
+		bal := *b.balance
+		if f.req.Ccy != "" {
+			bal.Details = nil
+			for _, d := range b.balance.Details {
+				if d.Ccy == f.req.Ccy {
+					bal.Details = append(bal.Details, d)
+				}
+			}
+		}
+		if len(bal.Details) > 0 {
+			arg := map[string]interface{}{"channel": "account"}
+			if f.req.Ccy != "" {
+				arg["ccy"] = f.req.Ccy
+			}
+			f.sub.Publish(&private.Account{Arg: events.NewArgument(arg), Balances: []*account.Balance{&bal}})
+		}
+	}
+}
+
+// subscribe adds a feed to the list, closing its subscription removes it
+func subscribe[T any, R comparable](p *Private, list *[]*feed[T, R], req R, channel string, ch []chan T) *ws.Subscription[T] {
+	m := okex.S2M(req)
+	m["channel"] = channel
+	f := &feed[T, R]{req: req}
+	f.sub = ws.NewSubscription([]map[string]string{m}, func() error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// the list is copied, so that emit can range over a snapshot of it
+		kept := make([]*feed[T, R], 0, len(*list))
+		for _, g := range *list {
+			if g != f {
+				kept = append(kept, g)
+			}
+		}
+		*list = kept
+		return nil
+	}, ch...)
+	p.mu.Lock()
+	*list = append((*list)[:len(*list):len(*list)], f)
+	p.mu.Unlock()
+	return f.sub
+}
+
+// unsubscribe closes the subscriptions of the list made with req
+func unsubscribe[T any, R comparable](p *Private, list *[]*feed[T, R], req R) error {
+	p.mu.RLock()
+	var subs []*ws.Subscription[T]
+	for _, f := range *list {
+		if f.req == req {
+			subs = append(subs, f.sub)
+		}
+	}
+	p.mu.RUnlock()
+	for _, sub := range subs {
+		_ = sub.Close()
+	}
+	return nil
+}
+
+func (e *changes) merge(o changes) {
+	e.orders = append(e.orders, o.orders...)
+	e.positions = append(e.positions, o.positions...)
+	e.accounts = append(e.accounts, o.accounts...)
+}
+
+// batch builds the models of the changed positions and balances, it must be called holding the lock
+func (x *Exchange) batch(evs changes) *batch {
+	if len(evs.orders) == 0 && len(evs.positions) == 0 && len(evs.accounts) == 0 {
+		return nil
+	}
+	b := &batch{orders: evs.orders}
+	for _, key := range dedup(evs.positions) {
+		if p, ok := x.positions[key]; ok {
+			b.positions = append(b.positions, x.positionModel(p))
+		}
+	}
+	if ccys := dedup(evs.accounts); len(ccys) > 0 {
+		b.balance = x.balanceModel(ccys)
+	}
+	return b
+}
+
+func match(reqType okex.InstrumentType, reqID string, instType okex.InstrumentType, instID string) bool {
+	return (reqType == "" || reqType == okex.AnyInstrument || reqType == instType) && (reqID == "" || reqID == instID)
+}
+
+func argument(channel, instType, instID string) *events.Argument {
+	arg := map[string]interface{}{"channel": channel}
+	if instType != "" {
+		arg["instType"] = instType
+	}
+	if instID != "" {
+		arg["instId"] = instID
+	}
+	return events.NewArgument(arg)
+}
+
+func dedup(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	res := make([]string, 0, len(s))
+	for _, v := range s {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
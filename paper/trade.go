@@ -0,0 +1,308 @@
+package paper
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/api/rest"
+	"github.com/dimkus/okex/api/ws"
+	"github.com/dimkus/okex/models/trade"
+	requests "github.com/dimkus/okex/requests/rest/trade"
+	requests_ws "github.com/dimkus/okex/requests/ws/trade"
+	responses "github.com/dimkus/okex/responses/trade"
+	"math"
+	"sort"
+	"strconv"
+)
+
+var (
+	_ rest.TradeI = (*Trade)(nil)
+	_ ws.TradeI   = (*WsTrade)(nil)
+	_ ws.PrivateI = (*Private)(nil)
+)
+
+type (
+	// Trade is the simulated counterpart of rest.Trade
+	Trade struct {
+		x *Exchange
+	}
+
+	// WsTrade is the simulated counterpart of ws.Trade, the replies are available as soon as the methods return
+	WsTrade struct {
+		t *Trade
+	}
+)
+
+// PlaceOrder places the orders on the simulated exchange, rejected orders are reported as *okex.APIError
+func (c *Trade) PlaceOrder(ctx context.Context, req []requests.PlaceOrder) (response responses.PlaceOrder, err error) {
+	x := c.x
+	x.mu.Lock()
+	var evs changes
+	for _, r := range req {
+		res, e := x.place(r)
+		response.PlaceOrders = append(response.PlaceOrders, res)
+		evs.merge(e)
+	}
+	b := x.batch(evs)
+	x.mu.Unlock()
+	x.Private.emit(b)
+	return response, batchError(&response, "/api/v5/trade/order", len(req))
+}
+
+// PlaceMultipleOrders is PlaceOrder
+func (c *Trade) PlaceMultipleOrders(ctx context.Context, req []requests.PlaceOrder) (responses.PlaceOrder, error) {
+	return c.PlaceOrder(ctx, req)
+}
+
+// CandleOrder cancels the live orders
+func (c *Trade) CandleOrder(ctx context.Context, req []requests.CancelOrder) (response responses.PlaceOrder, err error) {
+	x := c.x
+	x.mu.Lock()
+	var evs changes
+	for _, r := range req {
+		res := &trade.PlaceOrder{OrdID: r.OrdID, ClOrdID: r.ClOrdID}
+		o, code, msg := x.find(r.InstID, r.OrdID, r.ClOrdID)
+		if o != nil {
+			res.OrdID, res.ClOrdID = o.m.OrdID, o.m.ClOrdID
+			evs.merge(x.cancel(o))
+		}
+		res.SCode, res.SMsg = okex.JSONInt64(code), msg
+		response.PlaceOrders = append(response.PlaceOrders, res)
+	}
+	b := x.batch(evs)
+	x.mu.Unlock()
+	x.Private.emit(b)
+	return response, batchError(&response, "/api/v5/trade/cancel-order", len(req))
+}
+
+// AmendOrder amends the size and the price of the live orders, an order that crosses the book after the amendment is matched
+func (c *Trade) AmendOrder(ctx context.Context, req []requests.AmendOrder) (response responses.AmendOrder, err error) {
+	x := c.x
+	x.mu.Lock()
+	var evs changes
+	for _, r := range req {
+		res := &trade.AmendOrder{OrdID: r.OrdID, ClOrdID: r.ClOrdID, ReqID: r.ReqID}
+		o, code, msg := x.find(r.InstID, r.OrdID, r.ClOrdID)
+		switch {
+		case o == nil:
+		case r.NewSz != "" && r.NewSz.Float64() < o.filled:
+			code, msg = codeParameter, "New size is less than the filled size"
+			if r.CxlOnFail {
+				evs.merge(x.cancel(o))
+			}
+		default:
+			res.OrdID, res.ClOrdID = o.m.OrdID, o.m.ClOrdID
+			evs.merge(x.amend(o, r))
+		}
+		res.SCode, res.SMsg = okex.JSONFloat64(code), msg
+		response.AmendOrders = append(response.AmendOrders, res)
+	}
+	b := x.batch(evs)
+	x.mu.Unlock()
+	x.Private.emit(b)
+	var subErrors []*okex.SubError
+	for i, r := range response.AmendOrders {
+		if r.SCode != 0 {
+			subErrors = append(subErrors, &okex.SubError{Index: i, Code: int(r.SCode), Msg: r.SMsg, ID: r.OrdID, ClOrdID: r.ClOrdID})
+		}
+	}
+	return response, subError(subErrors, "/api/v5/trade/amend-order", len(req))
+}
+
+// ClosePosition closes the position with a market order
+func (c *Trade) ClosePosition(ctx context.Context, req requests.ClosePosition) (response responses.ClosePosition, err error) {
+	posSide := req.PosSide
+	if posSide == "" {
+		posSide = okex.PositionNetSide
+	}
+	c.x.mu.Lock()
+	p, ok := c.x.positions[positionKey(req.InstID, posSide)]
+	var pos float64
+	if ok {
+		pos = p.pos
+	}
+	c.x.mu.Unlock()
+	if pos == 0 {
+		return response, &okex.APIError{Code: 51023, Msg: "Position does not exist", Endpoint: "/api/v5/trade/close-position"}
+	}
+	side := okex.OrderSell
+	if pos < 0 {
+		side = okex.OrderBuy
+	}
+	_, err = c.PlaceOrder(ctx, []requests.PlaceOrder{{
+		InstID:     req.InstID,
+		Ccy:        req.Ccy,
+		ReduceOnly: true,
+		Sz:         okex.DecimalFromFloat(math.Abs(pos)),
+		TdMode:     okex.TradeMode(req.MgnMode),
+		Side:       side,
+		PosSide:    posSide,
+		OrdType:    okex.OrderMarket,
+	}})
+	if err != nil {
+		return
+	}
+	response.ClosePositions = []*trade.ClosePosition{{InstID: req.InstID, PosSide: posSide}}
+	return
+}
+
+// GetOrderDetail returns the order by OrdID or ClOrdID
+func (c *Trade) GetOrderDetail(ctx context.Context, req requests.OrderDetails) (response responses.OrderList, err error) {
+	c.x.mu.Lock()
+	defer c.x.mu.Unlock()
+	o, code, msg := c.x.find(req.InstID, req.OrdID, req.ClOrdID)
+	if o == nil {
+		return response, &okex.APIError{Code: code, Msg: msg, Endpoint: "/api/v5/trade/order"}
+	}
+	response.Orders = []*trade.Order{c.x.orderModel(o)}
+	return
+}
+
+// GetOrderList returns the live orders, newest first
+func (c *Trade) GetOrderList(ctx context.Context, req requests.OrderList) (response responses.OrderList, err error) {
+	response.Orders = c.x.list(req, true)
+	return
+}
+
+// GetOrderHistory returns the filled and canceled orders, newest first
+func (c *Trade) GetOrderHistory(ctx context.Context, req requests.OrderList, arch bool) (response responses.OrderList, err error) {
+	response.Orders = c.x.list(req, false)
+	return
+}
+
+// GetTransactionDetails returns the fills, newest first
+func (c *Trade) GetTransactionDetails(ctx context.Context, req requests.TransactionDetails, arch bool) (response responses.TransactionDetail, err error) {
+	x := c.x
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	after, before := cursor(req.After), cursor(req.Before)
+	for i := len(x.fills) - 1; i >= 0; i-- {
+		f := x.fills[i]
+		id := cursor(f.BillID)
+		if (req.InstID != "" && f.InstID != req.InstID) || (req.OrdID != "" && f.OrdID != req.OrdID) ||
+			(req.InstType != "" && f.InstType != req.InstType) || (after > 0 && id >= after) || (before > 0 && id <= before) {
+			continue
+		}
+		d := *f
+		response.TransactionDetails = append(response.TransactionDetails, &d)
+		if len(response.TransactionDetails) >= limit(req.Limit) {
+			break
+		}
+	}
+	return
+}
+
+// PlaceOrder places the orders, rejected orders are reported as *okex.APIError
+func (c *WsTrade) PlaceOrder(req ...requests_ws.PlaceOrder) error {
+	_, err := c.PlaceOrderWait(context.Background(), req...)
+	return err
+}
+
+// PlaceOrderWait places the orders and returns the reply
+func (c *WsTrade) PlaceOrderWait(ctx context.Context, req ...requests_ws.PlaceOrder) (responses.PlaceOrder, error) {
+	orders := make([]requests.PlaceOrder, len(req))
+	for i, r := range req {
+		orders[i] = requests.PlaceOrder(r)
+	}
+	return c.t.PlaceOrder(ctx, orders)
+}
+
+// CancelOrder cancels the orders, failures are reported as *okex.APIError
+func (c *WsTrade) CancelOrder(req ...requests_ws.CancelOrder) error {
+	_, err := c.CancelOrderWait(context.Background(), req...)
+	return err
+}
+
+// CancelOrderWait cancels the orders and returns the reply
+func (c *WsTrade) CancelOrderWait(ctx context.Context, req ...requests_ws.CancelOrder) (response responses.CancelOrder, err error) {
+	orders := make([]requests.CancelOrder, len(req))
+	for i, r := range req {
+		orders[i] = requests.CancelOrder(r)
+	}
+	res, err := c.t.CandleOrder(ctx, orders)
+	for _, o := range res.PlaceOrders {
+		response.CancelOrders = append(response.CancelOrders, &trade.CancelOrder{OrdID: o.OrdID, ClOrdID: o.ClOrdID, SMsg: o.SMsg, SCode: okex.JSONFloat64(o.SCode)})
+	}
+	return response, err
+}
+
+// AmendOrder amends the orders, failures are reported as *okex.APIError
+func (c *WsTrade) AmendOrder(req ...requests_ws.AmendOrder) error {
+	_, err := c.AmendOrderWait(context.Background(), req...)
+	return err
+}
+
+// AmendOrderWait amends the orders and returns the reply
+func (c *WsTrade) AmendOrderWait(ctx context.Context, req ...requests_ws.AmendOrder) (responses.AmendOrder, error) {
+	orders := make([]requests.AmendOrder, len(req))
+	for i, r := range req {
+		orders[i] = requests.AmendOrder(r)
+	}
+	return c.t.AmendOrder(ctx, orders)
+}
+
+// find the order by OrdID or ClOrdID, it returns the error code and message when it isn't live
+func (x *Exchange) find(instID, ordID, clOrdID string) (*order, int, string) {
+	var found *order
+	if o, ok := x.orders[ordID]; ok && ordID != "" {
+		found = o
+	} else if clOrdID != "" {
+		for _, o := range x.orders {
+			// the latest order wins when a ClOrdID has been reused
+			if o.m.ClOrdID == clOrdID && (found == nil || cursor(o.m.OrdID) > cursor(found.m.OrdID)) {
+				found = o
+			}
+		}
+	}
+	if found == nil || (instID != "" && found.m.InstID != instID) {
+		return nil, codeOrderNotFound, "Order does not exist"
+	}
+	if !found.live() {
+		return found, codeOrderCompleted, "Order has been completed"
+	}
+	return found, 0, ""
+}
+
+func (x *Exchange) list(req requests.OrderList, live bool) []*trade.Order {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	after, before := cursor(req.After), cursor(req.Before)
+	var res []*trade.Order
+	for _, o := range x.orders {
+		id := cursor(o.m.OrdID)
+		if o.live() != live || (req.InstID != "" && o.m.InstID != req.InstID) || (req.InstType != "" && o.m.InstType != req.InstType) ||
+			(req.OrdType != "" && o.m.OrdType != req.OrdType) || (req.State != "" && o.m.State != req.State) ||
+			(after > 0 && id >= after) || (before > 0 && id <= before) {
+			continue
+		}
+		res = append(res, x.orderModel(o))
+	}
+	sort.Slice(res, func(i, j int) bool { return cursor(res[i].OrdID) > cursor(res[j].OrdID) })
+	return res[:min(len(res), limit(req.Limit))]
+}
+
+// batchError returns an *okex.APIError if any of the items failed, like the exchange does
+func batchError(res *responses.PlaceOrder, endpoint string, n int) error {
+	return subError(res.GetSubErrors(), endpoint, n)
+}
+
+func subError(subErrors []*okex.SubError, endpoint string, n int) error {
+	if len(subErrors) == 0 {
+		return nil
+	}
+	if len(subErrors) < n {
+		return &okex.APIError{Code: 2, Msg: "Bulk operation partially succeeded", Endpoint: endpoint, SubErrors: subErrors}
+	}
+	return &okex.APIError{Code: 1, Msg: "Operation failed", Endpoint: endpoint, SubErrors: subErrors}
+}
+
+func cursor(id string) uint64 {
+	n, _ := strconv.ParseUint(id, 10, 64)
+	return n
+}
+
+func limit(l float64) int {
+	if l <= 0 || l > 100 {
+		return 100
+	}
+	return int(l)
+}
@@ -0,0 +1,139 @@
+package pnl
+
+import (
+	"github.com/dimkus/okex"
+	"math"
+)
+
+type (
+	// ledger keeps the positions of one attribution, i.e. of the whole account or of a tag
+	ledger struct {
+		method    Method
+		positions map[key]*position
+	}
+
+	key struct {
+		instID  string
+		posSide okex.PositionSide
+	}
+
+	// position is the signed size held on an instrument, positive when long
+	position struct {
+		lots     []lot
+		realized float64
+		funding  float64
+		fees     map[string]float64
+	}
+
+	// lot is a part of the position opened at the same price, there is a single one with MethodAverage
+	lot struct {
+		sz float64
+		px float64
+	}
+
+	// contract describes how a unit of size turns into PnL
+	contract struct {
+		ctVal   float64
+		inverse bool
+	}
+)
+
+func newLedger(method Method) *ledger {
+	return &ledger{method: method, positions: make(map[key]*position)}
+}
+
+func (l *ledger) position(k key) *position {
+	p, ok := l.positions[k]
+	if !ok {
+		p = &position{fees: make(map[string]float64)}
+		l.positions[k] = p
+	}
+	return p
+}
+
+// fill applies a signed fill to the position, closing the oldest lots first. The sizes left under epsilon are the
+// float residue of the closes and count as zero, so that they neither open a lot nor keep one open.
+func (l *ledger) fill(k key, c contract, sz, px float64) {
+	p := l.position(k)
+	for math.Abs(sz) > epsilon && len(p.lots) > 0 && sign(p.lots[0].sz) != sign(sz) {
+		lt := &p.lots[0]
+		closed := math.Min(math.Abs(sz), math.Abs(lt.sz)) * sign(lt.sz)
+		p.realized += c.pnl(closed, lt.px, px)
+		lt.sz -= closed
+		sz += closed
+		if math.Abs(lt.sz) <= epsilon {
+			p.lots = p.lots[1:]
+		}
+	}
+	if math.Abs(sz) <= epsilon {
+		return
+	}
+	if l.method == MethodAverage && len(p.lots) > 0 {
+		lt := &p.lots[0]
+		lt.px = c.average(lt.sz, lt.px, sz, px)
+		lt.sz += sz
+		return
+	}
+	p.lots = append(p.lots, lot{sz: sz, px: px})
+}
+
+func (p *position) size() float64 {
+	sz := 0.0
+	for _, lt := range p.lots {
+		sz += lt.sz
+	}
+	return sz
+}
+
+// avgPx returns the average entry price of the open lots
+func (p *position) avgPx(c contract) float64 {
+	sz, px := 0.0, 0.0
+	for _, lt := range p.lots {
+		px = c.average(sz, px, lt.sz, lt.px)
+		sz += lt.sz
+	}
+	return px
+}
+
+func (p *position) unrealized(c contract, mark float64) float64 {
+	if mark == 0 {
+		return 0
+	}
+	upl := 0.0
+	for _, lt := range p.lots {
+		upl += c.pnl(lt.sz, lt.px, mark)
+	}
+	return upl
+}
+
+// pnl returns the profit of a signed size opened at entry and valued at exit, in the settlement currency
+func (c contract) pnl(sz, entry, exit float64) float64 {
+	if c.inverse {
+		if entry == 0 || exit == 0 {
+			return 0
+		}
+		return sz * c.ctVal * (1/entry - 1/exit)
+	}
+	return sz * c.ctVal * (exit - entry)
+}
+
+// average returns the entry price of two sizes of the same side, the prices of inverse contracts average harmonically
+func (c contract) average(sz1, px1, sz2, px2 float64) float64 {
+	if sz1+sz2 == 0 {
+		return 0
+	}
+	if c.inverse && px1 != 0 && px2 != 0 {
+		return (sz1 + sz2) / (sz1/px1 + sz2/px2)
+	}
+	return (sz1*px1 + sz2*px2) / (sz1 + sz2)
+}
+
+func sign(f float64) float64 {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,384 @@
+// Package pnl tracks the positions and the PnL of the account from its fills, the mark prices and the funding bills,
+// with an attribution per strategy Tag and per ClOrdID prefix.
+//
+//	t := pnl.NewTracker(pnl.MethodFIFO, registry, "grid-", "mm-")
+//	t.AddFill(detail)    // or t.AddOrder(order) for the pushes of the orders channel
+//	t.Mark(markPrice)
+//	t.AddBill(bill)      // the funding fee bills, the other ones are ignored
+//	for tag, reports := range t.Tags() { ... }
+//
+// The amounts are in the settlement currency of the instruments, the quote currency of the linear ones and the base
+// currency of the inverse ones, except for the fees which are keyed by their own currency.
+package pnl
+
+import (
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/instrument"
+	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/publicdata"
+	"github.com/dimkus/okex/models/trade"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// MethodFIFO closes the oldest open lots first
+	MethodFIFO Method = iota
+	// MethodAverage keeps a single lot at the average entry price, like the exchange does
+	MethodAverage
+)
+
+type (
+	// Method of matching the closing fills with the open ones
+	Method uint8
+
+	// Tracker keeps the positions of the account and of each tag and ClOrdID prefix
+	Tracker struct {
+		method   Method
+		insts    *instrument.Registry
+		prefixes []string
+		account  *ledger
+		// check is the account at average cost, the way the exchange computes Upl
+		check  *ledger
+		tags   map[string]*ledger
+		groups map[string]*ledger
+		marks  map[string]float64
+		seen   map[string]*window
+		fees   map[string]float64
+		mu     sync.RWMutex
+	}
+
+	// Report is the position and the PnL of an instrument
+	Report struct {
+		InstID  string
+		PosSide okex.PositionSide
+		// Pos is the signed size, negative when short, in contracts for the derivatives
+		Pos        float64
+		AvgPx      float64
+		MarkPx     float64
+		Realized   float64
+		Unrealized float64
+		// Funding is the funding received, negative when paid
+		Funding float64
+		// Fees are the fees paid keyed by currency, rebates are negative
+		Fees map[string]float64
+	}
+
+	// Mismatch is a position whose tracked state differs from the one of the exchange
+	Mismatch struct {
+		InstID      string
+		PosSide     okex.PositionSide
+		Pos         float64
+		ExchangePos float64
+		Unrealized  float64
+		ExchangeUpl float64
+	}
+)
+
+// NewTracker returns a pointer to a fresh Tracker.
+//
+// insts provides the contract values and types of the derivatives, a contract is worth one unit of the base
+// currency without it. The fills are attributed to the longest of the prefixes their ClOrdID starts with.
+func NewTracker(method Method, insts *instrument.Registry, prefixes ...string) *Tracker {
+	prefixes = append([]string(nil), prefixes...)
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return &Tracker{
+		method:   method,
+		insts:    insts,
+		prefixes: prefixes,
+		account:  newLedger(method),
+		check:    newLedger(MethodAverage),
+		tags:     make(map[string]*ledger),
+		groups:   make(map[string]*ledger),
+		marks:    make(map[string]float64),
+		seen:     make(map[string]*window),
+		fees:     make(map[string]float64),
+	}
+}
+
+// AddFill applies a fill of the transaction details, a fill is applied once however many times it is added among the
+// last fills of its instrument, see seenWindow
+func (t *Tracker) AddFill(d *trade.TransactionDetail) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dup(d.InstID, d.TradeID) {
+		return
+	}
+	t.fill(d.InstID, d.PosSide, d.Side, d.Tag, d.ClOrdID, d.FillSz.Float64(), d.FillPx.Float64(), d.FeeCcy, d.Fee.Float64())
+}
+
+// AddOrder applies the last fill of an order update of the orders channel, the updates without a fill are ignored.
+//
+// The fee of the fill is the change of the accumulated fee of the order since its previous fill.
+func (t *Tracker) AddOrder(o *trade.Order) {
+	if o.TradeID == "" || o.FillSz.Sign() <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dup(o.InstID, o.TradeID) {
+		return
+	}
+	fee := o.Fee.Float64() - t.fees[o.OrdID]
+	t.fees[o.OrdID] = o.Fee.Float64()
+	if o.State == okex.OrderFilled || o.State == okex.OrderCancel {
+		delete(t.fees, o.OrdID)
+	}
+	t.fill(o.InstID, o.PosSide, o.Side, o.Tag, o.ClOrdID, o.FillSz.Float64(), o.FillPx.Float64(), o.FeeCcy, fee)
+}
+
+// Mark sets the price the open positions of the instrument are valued at
+func (t *Tracker) Mark(m *publicdata.MarkPrice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.marks[m.InstID] = float64(m.MarkPx)
+}
+
+// AddBill applies a funding fee bill, the other bills are ignored.
+//
+// The bills don't tell the side of the position they are for, so the funding of an instrument held both long and
+// short is split across the sides by their size. The share of a side is then split across the tags and the prefixes
+// by their share of the position of the account on that side.
+func (t *Tracker) AddBill(b *account.Bill) {
+	if b.Type != okex.BillFundingFeeType {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dup("", b.BillID) {
+		return
+	}
+	amt := float64(b.BalChg)
+	sizes := make(map[key]float64)
+	total := 0.0
+	for k, p := range t.account.positions {
+		if sz := p.size(); k.instID == b.InstID && math.Abs(sz) > epsilon {
+			sizes[k] = sz
+			total += math.Abs(sz)
+		}
+	}
+	if total == 0 {
+		// the position has been closed since the funding time, the funding can't be attributed anymore
+		k := t.fundingKey(b.InstID)
+		t.account.position(k).funding += amt
+		t.check.position(k).funding += amt
+		return
+	}
+	ledgers := t.ledgers()
+	for k, sz := range sizes {
+		share := amt * math.Abs(sz) / total
+		for _, l := range ledgers {
+			if p, ok := l.positions[k]; ok {
+				// a tag short on the net position of a long account gets the opposite of the funding of the long tags
+				p.funding += share * p.size() / sz
+			}
+		}
+	}
+}
+
+// Positions returns the reports of the account
+func (t *Tracker) Positions() []*Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.reports(t.account)
+}
+
+// Tags returns the reports keyed by the Tag of the fills, the fills without a tag are under the empty tag
+func (t *Tracker) Tags() map[string][]*Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make(map[string][]*Report, len(t.tags))
+	for tag, l := range t.tags {
+		res[tag] = t.reports(l)
+	}
+	return res
+}
+
+// Prefixes returns the reports keyed by the ClOrdID prefix of the fills, the fills matching none are under the empty
+// prefix
+func (t *Tracker) Prefixes() map[string][]*Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make(map[string][]*Report, len(t.groups))
+	for prefix, l := range t.groups {
+		res[prefix] = t.reports(l)
+	}
+	return res
+}
+
+// Check compares the tracked positions with the ones of the exchange and returns those that differ in size, or in
+// unrealized PnL by more than tolerance.
+//
+// The unrealized PnL is computed at average cost whatever the method of the tracker, like the exchange does, but at
+// the last mark price given to the tracker, so the tolerance must cover the moves of the mark price in between.
+func (t *Tracker) Check(positions []*account.Position, tolerance float64) []*Mismatch {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []*Mismatch
+	known := make(map[key]bool)
+	for _, ep := range positions {
+		k := key{instID: ep.InstID, posSide: ep.PosSide}
+		known[k] = true
+		m := &Mismatch{InstID: ep.InstID, PosSide: ep.PosSide, ExchangePos: float64(ep.Pos), ExchangeUpl: float64(ep.Upl)}
+		if p, ok := t.check.positions[k]; ok {
+			m.Pos = p.size()
+			m.Unrealized = p.unrealized(t.contract(k.instID), t.marks[k.instID])
+		}
+		// the exchange reports the size of the long and short sides as positive in the long/short mode
+		if ep.PosSide != okex.PositionNetSide {
+			m.Pos = math.Abs(m.Pos)
+		}
+		if math.Abs(m.Pos-m.ExchangePos) > epsilon || math.Abs(m.Unrealized-m.ExchangeUpl) > tolerance {
+			res = append(res, m)
+		}
+	}
+	for k, p := range t.check.positions {
+		if sz := p.size(); !known[k] && math.Abs(sz) > epsilon {
+			res = append(res, &Mismatch{InstID: k.instID, PosSide: k.posSide, Pos: sz,
+				Unrealized: p.unrealized(t.contract(k.instID), t.marks[k.instID])})
+		}
+	}
+	return res
+}
+
+// epsilon absorbs the float errors of the sizes
+const epsilon = 1e-9
+
+// seenWindow is how many of the last fills of an instrument, and of the last bills, are remembered to skip the ones
+// added twice. The fills and the bills are replayed after a reconnect or a restart, not hours later.
+const seenWindow = 10000
+
+// window remembers the last seenWindow ids added to it
+type window struct {
+	ids  map[string]bool
+	ring []string
+	next int
+}
+
+// add reports whether id is new and remembers it in place of the oldest one once the window is full
+func (w *window) add(id string) bool {
+	if w.ids[id] {
+		return false
+	}
+	if len(w.ring) < seenWindow {
+		w.ring = append(w.ring, id)
+	} else {
+		delete(w.ids, w.ring[w.next])
+		w.ring[w.next] = id
+		w.next = (w.next + 1) % seenWindow
+	}
+	w.ids[id] = true
+	return true
+}
+
+// fill applies a fill to the account and to its attributions, mu must be held
+func (t *Tracker) fill(instID string, posSide okex.PositionSide, side okex.OrderSide, tag, clOrdID string, sz, px float64, feeCcy string, fee float64) {
+	if side == okex.OrderSell {
+		sz = -sz
+	}
+	k := key{instID: instID, posSide: posSide}
+	c := t.contract(instID)
+	for _, l := range []*ledger{t.account, t.check, ledgerOf(t.tags, tag, t.method), ledgerOf(t.groups, t.prefix(clOrdID), t.method)} {
+		l.fill(k, c, sz, px)
+		if feeCcy != "" {
+			l.position(k).fees[feeCcy] -= fee
+		}
+	}
+}
+
+// prefix returns the longest prefix of clOrdID, mu must be held
+func (t *Tracker) prefix(clOrdID string) string {
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(clOrdID, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// dup reports whether the id has been applied already among the last ones of the instrument, or of the bills when
+// instID is empty, and marks it, mu must be held
+func (t *Tracker) dup(instID, id string) bool {
+	w, ok := t.seen[instID]
+	if !ok {
+		w = &window{ids: make(map[string]bool)}
+		t.seen[instID] = w
+	}
+	return !w.add(id)
+}
+
+// fundingKey returns the key of the account the funding of a closed position is booked on, mu must be held
+func (t *Tracker) fundingKey(instID string) key {
+	for _, posSide := range []okex.PositionSide{okex.PositionNetSide, okex.PositionLongSide, okex.PositionShortSide, ""} {
+		k := key{instID: instID, posSide: posSide}
+		if _, ok := t.account.positions[k]; ok {
+			return k
+		}
+	}
+	return key{instID: instID, posSide: okex.PositionNetSide}
+}
+
+func (t *Tracker) ledgers() []*ledger {
+	res := []*ledger{t.account, t.check}
+	for _, l := range t.tags {
+		res = append(res, l)
+	}
+	for _, l := range t.groups {
+		res = append(res, l)
+	}
+	return res
+}
+
+func (t *Tracker) reports(l *ledger) []*Report {
+	res := make([]*Report, 0, len(l.positions))
+	for k, p := range l.positions {
+		c := t.contract(k.instID)
+		r := &Report{
+			InstID:     k.instID,
+			PosSide:    k.posSide,
+			Pos:        p.size(),
+			AvgPx:      p.avgPx(c),
+			MarkPx:     t.marks[k.instID],
+			Realized:   p.realized,
+			Unrealized: p.unrealized(c, t.marks[k.instID]),
+			Funding:    p.funding,
+			Fees:       make(map[string]float64, len(p.fees)),
+		}
+		for ccy, fee := range p.fees {
+			r.Fees[ccy] = fee
+		}
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].InstID != res[j].InstID {
+			return res[i].InstID < res[j].InstID
+		}
+		return res[i].PosSide < res[j].PosSide
+	})
+	return res
+}
+
+// contract returns the value of a contract of the instrument and whether it is an inverse contract
+func (t *Tracker) contract(instID string) contract {
+	if t.insts != nil {
+		if inst, ok := t.insts.Get(instID); ok && inst.CtVal != "" {
+			ctVal := inst.CtVal.Float64()
+			if inst.CtMult != "" {
+				ctVal *= inst.CtMult.Float64()
+			}
+			return contract{ctVal: ctVal, inverse: inst.CtType == okex.ContractInverseType}
+		}
+	}
+	return contract{ctVal: 1}
+}
+
+func ledgerOf(m map[string]*ledger, name string, method Method) *ledger {
+	l, ok := m[name]
+	if !ok {
+		l = newLedger(method)
+		m[name] = l
+	}
+	return l
+}
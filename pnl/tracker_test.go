@@ -0,0 +1,141 @@
+package pnl
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/models/account"
+	"github.com/dimkus/okex/models/trade"
+)
+
+type step struct {
+	sz, px float64
+}
+
+func TestLedger(t *testing.T) {
+	linear := contract{ctVal: 1}
+	inverse := contract{ctVal: 100, inverse: true}
+	tests := []struct {
+		name     string
+		method   Method
+		c        contract
+		fills    []step
+		pos      float64
+		avgPx    float64
+		realized float64
+	}{
+		{"fifo opens", MethodFIFO, linear, []step{{1, 100}, {1, 110}}, 2, 105, 0},
+		{"fifo closes the oldest lot", MethodFIFO, linear, []step{{1, 100}, {1, 110}, {-1, 120}}, 1, 110, 20},
+		{"average closes at the average", MethodAverage, linear, []step{{1, 100}, {1, 110}, {-1, 120}}, 1, 105, 15},
+		{"fifo flips", MethodFIFO, linear, []step{{1, 100}, {1, 110}, {-3, 120}}, -1, 120, 30},
+		{"average flips", MethodAverage, linear, []step{{1, 100}, {1, 110}, {-3, 120}}, -1, 120, 30},
+		{"fifo short", MethodFIFO, linear, []step{{-2, 100}, {1, 90}}, -1, 100, 10},
+		{"average short adds", MethodAverage, linear, []step{{-1, 100}, {-1, 90}, {1, 80}}, -1, 95, 15},
+		{"fifo closes flat", MethodFIFO, linear, []step{{1, 100}, {-1, 90}}, 0, 0, -10},
+		{"inverse", MethodFIFO, inverse, []step{{1, 100}, {-1, 200}}, 0, 0, 0.5},
+		{"inverse average is harmonic", MethodAverage, inverse, []step{{1, 100}, {1, 200}}, 2, 400.0 / 3, 0},
+		{"fifo closes flat in parts", MethodFIFO, linear, []step{{0.3, 100}, {-0.1, 110}, {-0.2, 110}}, 0, 0, 3},
+		{"average closes flat in parts", MethodAverage, linear, []step{{0.3, 100}, {-0.1, 110}, {-0.2, 110}}, 0, 0, 3},
+	}
+	for _, tt := range tests {
+		l := newLedger(tt.method)
+		k := key{instID: "BTC-USDT-SWAP", posSide: okex.PositionNetSide}
+		for _, f := range tt.fills {
+			l.fill(k, tt.c, f.sz, f.px)
+		}
+		p := l.position(k)
+		if !near(p.size(), tt.pos) || !near(p.avgPx(tt.c), tt.avgPx) || !near(p.realized, tt.realized) {
+			t.Errorf("%s: got pos %v avgPx %v realized %v, want %v %v %v", tt.name, p.size(), p.avgPx(tt.c), p.realized, tt.pos, tt.avgPx, tt.realized)
+		}
+		if tt.pos == 0 && len(p.lots) != 0 {
+			t.Errorf("%s: got lots %+v left on a flat position", tt.name, p.lots)
+		}
+	}
+}
+
+func TestFundingOfHedgedPositions(t *testing.T) {
+	tr := NewTracker(MethodFIFO, nil)
+	fill := func(id string, posSide okex.PositionSide, side okex.OrderSide, tag string, sz string) {
+		tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT-SWAP", TradeID: id, PosSide: posSide, Side: side, Tag: tag, FillSz: okex.Decimal(sz), FillPx: "100"})
+	}
+	// the long side is three times the short one, the sizes would net to 2 and give the short tag a negative share
+	fill("1", okex.PositionLongSide, okex.OrderBuy, "a", "3")
+	fill("2", okex.PositionShortSide, okex.OrderSell, "b", "1")
+	tr.AddBill(&account.Bill{BillID: "f1", InstID: "BTC-USDT-SWAP", Type: okex.BillFundingFeeType, BalChg: -4})
+
+	want := map[string]float64{"a": -3, "b": -1}
+	for tag, reports := range tr.Tags() {
+		if len(reports) != 1 || !near(reports[0].Funding, want[tag]) {
+			t.Errorf("tag %s: got %+v, want funding %v", tag, reports[0], want[tag])
+		}
+	}
+	total := 0.0
+	for _, r := range tr.Positions() {
+		total += r.Funding
+	}
+	if !near(total, -4) {
+		t.Fatalf("got %v booked on the account, want -4", total)
+	}
+}
+
+func TestFundingOfNetPosition(t *testing.T) {
+	tr := NewTracker(MethodFIFO, nil)
+	tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT-SWAP", TradeID: "1", PosSide: okex.PositionNetSide, Side: okex.OrderBuy, Tag: "a", FillSz: "2", FillPx: "100"})
+	tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT-SWAP", TradeID: "2", PosSide: okex.PositionNetSide, Side: okex.OrderSell, Tag: "b", FillSz: "3", FillPx: "100"})
+	tr.AddBill(&account.Bill{BillID: "f1", InstID: "BTC-USDT-SWAP", Type: okex.BillFundingFeeType, BalChg: 1})
+	tr.AddBill(&account.Bill{BillID: "f1", InstID: "BTC-USDT-SWAP", Type: okex.BillFundingFeeType, BalChg: 1})
+
+	// the account is short 1 and receives 1, the long tag pays what the short one receives beyond it
+	want := map[string]float64{"a": -2, "b": 3}
+	for tag, reports := range tr.Tags() {
+		if !near(reports[0].Funding, want[tag]) {
+			t.Errorf("tag %s: got %v, want %v", tag, reports[0].Funding, want[tag])
+		}
+	}
+}
+
+func TestCheckHedgedPositions(t *testing.T) {
+	tr := NewTracker(MethodFIFO, nil)
+	tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT-SWAP", TradeID: "1", PosSide: okex.PositionLongSide, Side: okex.OrderBuy, FillSz: "3", FillPx: "100"})
+	tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT-SWAP", TradeID: "2", PosSide: okex.PositionShortSide, Side: okex.OrderSell, FillSz: "2", FillPx: "100"})
+	tr.AddFill(&trade.TransactionDetail{InstID: "ETH-USDT-SWAP", TradeID: "3", PosSide: okex.PositionNetSide, Side: okex.OrderSell, FillSz: "1", FillPx: "100"})
+
+	// the sides of the long/short mode are positive, the net position is signed
+	positions := []*account.Position{
+		{InstID: "BTC-USDT-SWAP", PosSide: okex.PositionLongSide, Pos: 3},
+		{InstID: "BTC-USDT-SWAP", PosSide: okex.PositionShortSide, Pos: 2},
+		{InstID: "ETH-USDT-SWAP", PosSide: okex.PositionNetSide, Pos: -1},
+	}
+	if res := tr.Check(positions, 1); len(res) != 0 {
+		t.Fatalf("got mismatches %+v", res[0])
+	}
+	positions[1].Pos = 1
+	res := tr.Check(positions, 1)
+	if len(res) != 1 || res[0].PosSide != okex.PositionShortSide || res[0].Pos != 2 {
+		t.Fatalf("got %+v, want the short side", res)
+	}
+}
+
+func TestSeenIsBounded(t *testing.T) {
+	tr := NewTracker(MethodFIFO, nil)
+	add := func(id int) {
+		tr.AddFill(&trade.TransactionDetail{InstID: "BTC-USDT", TradeID: strconv.Itoa(id), PosSide: okex.PositionNetSide, Side: okex.OrderBuy, FillSz: "1", FillPx: "1"})
+	}
+	for i := 0; i < seenWindow+10; i++ {
+		add(i)
+	}
+	if w := tr.seen["BTC-USDT"]; len(w.ids) != seenWindow || len(w.ring) != seenWindow {
+		t.Fatalf("got %d ids, want %d", len(w.ids), seenWindow)
+	}
+	// the recent fills are still skipped
+	add(seenWindow + 5)
+	if pos := tr.Positions()[0].Pos; pos != seenWindow+10 {
+		t.Fatalf("got %v, want %v", pos, seenWindow+10)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,267 @@
+// Package ratelimit throttles requests on the client side according to the documented endpoint limits
+//
+// https://www.okx.com/docs-v5/en/#overview-rate-limits
+package ratelimit
+
+import (
+	"context"
+	"github.com/dimkus/okex"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Scope describes what the limit is counted against
+	Scope uint8
+
+	// Policy decides what happens when the limit is reached
+	Policy uint8
+
+	// Rule is a limit of Limit requests per Interval
+	Rule struct {
+		Limit    int
+		Interval time.Duration
+		Scope    Scope
+	}
+
+	// Usage is a snapshot of a single bucket
+	Usage struct {
+		Endpoint string
+		InstID   string
+		Limit    int
+		Interval time.Duration
+		Used     int
+		Waits    uint64
+		Rejects  uint64
+	}
+
+	// Registry keeps a token bucket per endpoint, and per instrument for instrument scoped rules
+	Registry struct {
+		policy  Policy
+		rules   map[string]Rule
+		buckets map[string]*bucket
+		mu      sync.Mutex
+	}
+
+	bucket struct {
+		endpoint string
+		instID   string
+		rule     Rule
+		tokens   float64
+		last     time.Time
+		waits    uint64
+		rejects  uint64
+		mu       sync.Mutex
+	}
+
+	// claim is the number of tokens a request takes from a bucket
+	claim struct {
+		b *bucket
+		n int
+	}
+)
+
+const (
+	ScopeIP Scope = iota
+	ScopeUserID
+	ScopeInstrument
+)
+
+const (
+	// PolicyWait blocks the caller until the request fits the limit or the context is done
+	PolicyWait Policy = iota
+	// PolicyFailFast returns a rate limit error immediately
+	PolicyFailFast
+)
+
+const (
+	// RateLimitCode is the error code returned by the server when the limit is reached
+	RateLimitCode = 50011
+)
+
+// NewRegistry returns a pointer to a fresh Registry filled with DefaultRules
+func NewRegistry(policy Policy) *Registry {
+	r := &Registry{
+		policy:  policy,
+		rules:   make(map[string]Rule, len(DefaultRules)),
+		buckets: make(map[string]*bucket),
+	}
+	for endpoint, rule := range DefaultRules {
+		r.rules[endpoint] = rule
+	}
+	return r
+}
+
+// SetRule overrides the rule of the given endpoint, a zero Limit removes it
+func (r *Registry) SetRule(endpoint string, rule Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rule.Limit == 0 {
+		delete(r.rules, endpoint)
+	} else {
+		r.rules[endpoint] = rule
+	}
+	for k, b := range r.buckets {
+		if b.endpoint == endpoint {
+			delete(r.buckets, k)
+		}
+	}
+}
+
+// SetPolicy changes what happens when the limit is reached
+func (r *Registry) SetPolicy(policy Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// Wait takes a token for the given endpoint.
+//
+// Instrument scoped rules take one token per given instrument, so a batch request consumes one per order. The
+// tokens of a batch are taken from all of its buckets at once or not at all, a rejected request consumes nothing.
+func (r *Registry) Wait(ctx context.Context, endpoint string, instIDs ...string) error {
+	r.mu.Lock()
+	rule, ok := r.rules[endpoint]
+	policy := r.policy
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	counts := map[string]int{"": 1}
+	if rule.Scope == ScopeInstrument && len(instIDs) > 0 {
+		counts = make(map[string]int, len(instIDs))
+		for _, instID := range instIDs {
+			counts[instID]++
+		}
+	}
+	// the buckets are locked in the order of their instruments, so that concurrent batches never deadlock
+	keys := make([]string, 0, len(counts))
+	for instID := range counts {
+		keys = append(keys, instID)
+	}
+	sort.Strings(keys)
+	claims := make([]claim, len(keys))
+	for i, instID := range keys {
+		claims[i] = claim{b: r.bucket(endpoint, instID, rule), n: counts[instID]}
+	}
+	for {
+		wait, short := take(claims, time.Now())
+		if wait == 0 {
+			return nil
+		}
+		if policy == PolicyFailFast {
+			short.mu.Lock()
+			short.rejects++
+			short.mu.Unlock()
+			return &okex.APIError{Code: RateLimitCode, Msg: "client side rate limit reached", Endpoint: endpoint}
+		}
+		short.mu.Lock()
+		short.waits++
+		short.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Metrics returns the current usage of every bucket
+func (r *Registry) Metrics() []Usage {
+	r.mu.Lock()
+	buckets := make([]*bucket, 0, len(r.buckets))
+	for _, b := range r.buckets {
+		buckets = append(buckets, b)
+	}
+	r.mu.Unlock()
+
+	now := time.Now()
+	res := make([]Usage, len(buckets))
+	for i, b := range buckets {
+		b.mu.Lock()
+		b.refill(now)
+		res[i] = Usage{
+			Endpoint: b.endpoint,
+			InstID:   b.instID,
+			Limit:    b.rule.Limit,
+			Interval: b.rule.Interval,
+			Used:     b.rule.Limit - int(b.tokens),
+			Waits:    b.waits,
+			Rejects:  b.rejects,
+		}
+		b.mu.Unlock()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Endpoint == res[j].Endpoint {
+			return res[i].InstID < res[j].InstID
+		}
+		return res[i].Endpoint < res[j].Endpoint
+	})
+	return res
+}
+
+func (r *Registry) bucket(endpoint, instID string, rule Rule) *bucket {
+	k := endpoint + "|" + instID
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.buckets[k]
+	if !ok {
+		b = &bucket{endpoint: endpoint, instID: instID, rule: rule, tokens: float64(rule.Limit), last: time.Now()}
+		r.buckets[k] = b
+	}
+	return b
+}
+
+// take consumes the tokens of every claim and returns zero, or consumes none and returns how long to wait until they
+// are all available along with the bucket that is the longest to refill
+func take(claims []claim, now time.Time) (time.Duration, *bucket) {
+	for _, c := range claims {
+		c.b.mu.Lock()
+		defer c.b.mu.Unlock()
+	}
+	var (
+		wait  time.Duration
+		short *bucket
+	)
+	for _, c := range claims {
+		c.b.refill(now)
+		if w := c.b.wait(c.n); w > wait {
+			wait, short = w, c.b
+		}
+	}
+	if wait > 0 {
+		return wait, short
+	}
+	for _, c := range claims {
+		c.b.tokens -= c.b.need(c.n)
+	}
+	return 0, nil
+}
+
+// need returns the tokens n requests take, a batch larger than the limit takes the whole bucket
+func (b *bucket) need(n int) float64 {
+	return float64(min(n, b.rule.Limit))
+}
+
+// wait returns how long until the bucket holds the tokens of n requests, the lock must be held
+func (b *bucket) wait(n int) time.Duration {
+	need := b.need(n)
+	if b.tokens >= need {
+		return 0
+	}
+	rate := float64(b.rule.Limit) / float64(b.rule.Interval)
+	return time.Duration((need-b.tokens)/rate) + time.Millisecond
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.last = now
+	b.tokens = min(float64(b.rule.Limit), b.tokens+float64(elapsed)*float64(b.rule.Limit)/float64(b.rule.Interval))
+}
@@ -0,0 +1,106 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimkus/okex"
+	"github.com/dimkus/okex/ratelimit"
+)
+
+const endpoint = "/api/v5/test"
+
+func used(r *ratelimit.Registry) map[string]int {
+	res := make(map[string]int)
+	for _, u := range r.Metrics() {
+		res[u.InstID] = u.Used
+	}
+	return res
+}
+
+func TestRefill(t *testing.T) {
+	r := ratelimit.NewRegistry(ratelimit.PolicyFailFast)
+	r.SetRule(endpoint, ratelimit.Rule{Limit: 2, Interval: 200 * time.Millisecond, Scope: ratelimit.ScopeUserID})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := r.Wait(ctx, endpoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Wait(ctx, endpoint); !okex.IsRateLimited(err) {
+		t.Fatalf("got %v, want the limit reached", err)
+	}
+	// a token is back after half of the interval
+	time.Sleep(120 * time.Millisecond)
+	if err := r.Wait(ctx, endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if m := r.Metrics(); len(m) != 1 || m[0].Rejects != 1 || m[0].Used != 2 {
+		t.Fatalf("got %+v", m)
+	}
+
+	// the waiting policy blocks until the next token instead
+	r.SetPolicy(ratelimit.PolicyWait)
+	start := time.Now()
+	if err := r.Wait(ctx, endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("got a token after %s, want a wait for the refill", d)
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(short, endpoint); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the context error", err)
+	}
+}
+
+func TestFailFastTakesAllOrNone(t *testing.T) {
+	r := ratelimit.NewRegistry(ratelimit.PolicyFailFast)
+	r.SetRule(endpoint, ratelimit.Rule{Limit: 2, Interval: time.Hour, Scope: ratelimit.ScopeInstrument})
+	ctx := context.Background()
+	if err := r.Wait(ctx, endpoint, "B", "B"); err != nil {
+		t.Fatal(err)
+	}
+	// A has room, B doesn't, the batch is rejected as a whole whatever the order of the instruments
+	for _, batch := range [][]string{{"A", "B"}, {"B", "A"}} {
+		if err := r.Wait(ctx, endpoint, batch...); !okex.IsRateLimited(err) {
+			t.Fatalf("%v: got %v, want the limit reached", batch, err)
+		}
+	}
+	if got := used(r); got["A"] != 0 || got["B"] != 2 {
+		t.Fatalf("got %v, want the tokens of A left untouched", got)
+	}
+	if err := r.Wait(ctx, endpoint, "A", "A"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchTakesATokenPerOrder(t *testing.T) {
+	r := ratelimit.NewRegistry(ratelimit.PolicyFailFast)
+	r.SetRule(endpoint, ratelimit.Rule{Limit: 3, Interval: time.Hour, Scope: ratelimit.ScopeInstrument})
+	ctx := context.Background()
+	if err := r.Wait(ctx, endpoint, "A", "A", "B"); err != nil {
+		t.Fatal(err)
+	}
+	if got := used(r); got["A"] != 2 || got["B"] != 1 {
+		t.Fatalf("got %v, want a token per order of each instrument", got)
+	}
+	if err := r.Wait(ctx, endpoint, "A", "A"); !okex.IsRateLimited(err) {
+		t.Fatalf("got %v, want the limit of A reached", err)
+	}
+	if err := r.Wait(ctx, endpoint, "A", "B", "B"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rules scoped to the user count the batch once
+	r.SetRule(endpoint, ratelimit.Rule{Limit: 1, Interval: time.Hour, Scope: ratelimit.ScopeUserID})
+	if err := r.Wait(ctx, endpoint, "A", "B", "C"); err != nil {
+		t.Fatal(err)
+	}
+	if got := used(r); len(got) != 1 || got[""] != 1 {
+		t.Fatalf("got %v", got)
+	}
+}
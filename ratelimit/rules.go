@@ -0,0 +1,114 @@
+package ratelimit
+
+import "time"
+
+const twoSeconds = 2 * time.Second
+
+// DefaultRules are the documented limits of the endpoints, keyed by the endpoint path
+var DefaultRules = map[string]Rule{
+	// Trade
+	"/api/v5/trade/order":                  {60, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/batch-orders":           {300, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/cancel-order":           {60, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/cancel-batch-orders":    {300, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/amend-order":            {60, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/amend-batch-orders":     {300, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/close-position":         {20, twoSeconds, ScopeInstrument},
+	"/api/v5/trade/cancel-all-after":       {1, time.Second, ScopeUserID},
+	"/api/v5/trade/mass-cancel":            {5, twoSeconds, ScopeUserID},
+	"/api/v5/trade/order-precheck":         {5, twoSeconds, ScopeUserID},
+	"/api/v5/trade/orders-pending":         {60, twoSeconds, ScopeUserID},
+	"/api/v5/trade/orders-history":         {40, twoSeconds, ScopeUserID},
+	"/api/v5/trade/orders-history-archive": {20, twoSeconds, ScopeUserID},
+	"/api/v5/trade/fills":                  {60, twoSeconds, ScopeUserID},
+	"/api/v5/trade/fills-history":          {10, twoSeconds, ScopeUserID},
+	"/api/v5/trade/order-algo":             {20, twoSeconds, ScopeUserID},
+	"/api/v5/trade/cancel-algos":           {20, twoSeconds, ScopeUserID},
+	"/api/v5/trade/cancel-advance-algos":   {20, twoSeconds, ScopeUserID},
+	"/api/v5/trade/orders-algo-pending":    {20, twoSeconds, ScopeUserID},
+	"/api/v5/trade/orders-algo-history":    {20, twoSeconds, ScopeUserID},
+
+	// Account
+	"/api/v5/account/balance":                 {10, twoSeconds, ScopeUserID},
+	"/api/v5/account/positions":               {10, twoSeconds, ScopeUserID},
+	"/api/v5/account/account-position-risk":   {10, twoSeconds, ScopeUserID},
+	"/api/v5/account/bills":                   {5, time.Second, ScopeUserID},
+	"/api/v5/account/bills-archive":           {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/config":                  {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/set-position-mode":       {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/set-leverage":            {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/max-size":                {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/max-avail-size":          {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/position/margin-balance": {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/leverage-info":           {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/max-loan":                {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/trade-fee":               {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/interest-accrued":        {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/interest-rate":           {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/set-greeks":              {5, twoSeconds, ScopeUserID},
+	"/api/v5/account/max-withdrawal":          {20, twoSeconds, ScopeUserID},
+	"/api/v5/account/subaccount/balances":     {2, twoSeconds, ScopeUserID},
+	"/api/v5/account/subaccount/bills":        {6, time.Second, ScopeUserID},
+	"/api/v5/account/subaccount/transfer":     {1, time.Second, ScopeUserID},
+
+	// Sub-account
+	"/api/v5/users/subaccount/list":          {2, twoSeconds, ScopeUserID},
+	"/api/v5/users/subaccount/apikey":        {1, time.Second, ScopeUserID},
+	"/api/v5/users/subaccount/modify-apikey": {1, time.Second, ScopeUserID},
+	"/api/v5/users/subaccount/delete-apikey": {1, time.Second, ScopeUserID},
+
+	// Funding
+	"/api/v5/asset/currencies":         {6, time.Second, ScopeUserID},
+	"/api/v5/asset/balances":           {6, time.Second, ScopeUserID},
+	"/api/v5/asset/transfer":           {1, time.Second, ScopeUserID},
+	"/api/v5/asset/bills":              {6, time.Second, ScopeUserID},
+	"/api/v5/asset/deposit-address":    {6, time.Second, ScopeUserID},
+	"/api/v5/asset/deposit-history":    {6, time.Second, ScopeUserID},
+	"/api/v5/asset/withdrawal":         {6, time.Second, ScopeUserID},
+	"/api/v5/asset/withdrawal-history": {6, time.Second, ScopeUserID},
+	"/api/v5/asset/purchase_redempt":   {6, time.Second, ScopeUserID},
+	"/api/v5/asset/piggy-balance":      {6, time.Second, ScopeUserID},
+
+	// Market data
+	"/api/v5/market/tickers":            {20, twoSeconds, ScopeIP},
+	"/api/v5/market/ticker":             {20, twoSeconds, ScopeIP},
+	"/api/v5/market/index-tickers":      {20, twoSeconds, ScopeIP},
+	"/api/v5/market/books":              {40, twoSeconds, ScopeIP},
+	"/api/v5/market/candles":            {40, twoSeconds, ScopeIP},
+	"/api/v5/market/history-candles":    {20, twoSeconds, ScopeIP},
+	"/api/v5/market/index-candles":      {20, twoSeconds, ScopeIP},
+	"/api/v5/market/mark-price-candles": {20, twoSeconds, ScopeIP},
+	"/api/v5/market/trades":             {100, twoSeconds, ScopeIP},
+	"/api/v5/market/platform-24-volume": {2, twoSeconds, ScopeIP},
+	"/api/v5/market/index-components":   {20, twoSeconds, ScopeIP},
+
+	// Public data
+	"/api/v5/public/instruments":                       {20, twoSeconds, ScopeIP},
+	"/api/v5/public/delivery-exercise-history":         {40, twoSeconds, ScopeIP},
+	"/api/v5/public/open-interest":                     {20, twoSeconds, ScopeIP},
+	"/api/v5/public/funding-rate":                      {20, twoSeconds, ScopeIP},
+	"/api/v5/public/funding-rate-history":              {10, twoSeconds, ScopeIP},
+	"/api/v5/public/price-limit":                       {20, twoSeconds, ScopeIP},
+	"/api/v5/public/opt-summary":                       {20, twoSeconds, ScopeIP},
+	"/api/v5/public/estimated-price":                   {10, twoSeconds, ScopeIP},
+	"/api/v5/public/discount-rate-interest-free-quota": {2, twoSeconds, ScopeIP},
+	"/api/v5/public/time":                              {10, twoSeconds, ScopeIP},
+	"/api/v5/public/liquidation-orders":                {40, twoSeconds, ScopeIP},
+	"/api/v5/public/mark-price":                        {10, twoSeconds, ScopeIP},
+	"/api/v5/public/position-tiers":                    {10, twoSeconds, ScopeIP},
+	"/api/v5/public/interest-rate-loan-quota":          {2, twoSeconds, ScopeIP},
+	"/api/v5/public/underlying":                        {20, twoSeconds, ScopeIP},
+	"/api/v5/system/status":                            {1, 5 * time.Second, ScopeIP},
+
+	// Trading data
+	"/api/v5/rubik/stat/trading-data/support-coin":          {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/taker-volume":                       {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/margin/loan-ratio":                  {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/contracts/long-short-account-ratio": {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/contracts/open-interest-volume":     {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/option/open-interest-volume":        {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/option/open-interest-volume-ratio":  {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/option/open-interest-volume-expiry": {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/option/open-interest-volume-strike": {5, twoSeconds, ScopeIP},
+	"/api/v5/rubik/stat/option/taker-block-volume":          {5, twoSeconds, ScopeIP},
+}
@@ -12,8 +12,8 @@ type (
 		ClOrdID    string            `json:"clOrdId,omitempty"`
 		Tag        string            `json:"tag,omitempty"`
 		ReduceOnly bool              `json:"reduceOnly,omitempty"`
-		Sz         float64           `json:"sz,string"`
-		Px         float64           `json:"px,omitempty,string"`
+		Sz         okex.Decimal      `json:"sz"`
+		Px         okex.Decimal      `json:"px,omitempty"`
 		TdMode     okex.TradeMode    `json:"tdMode"`
 		Side       okex.OrderSide    `json:"side"`
 		PosSide    okex.PositionSide `json:"posSide,omitempty"`
@@ -27,14 +27,14 @@ type (
 		ClOrdID string `json:"clOrdId,omitempty"`
 	}
 	AmendOrder struct {
-		ID        string  `json:"-"`
-		InstID    string  `json:"instId"`
-		OrdID     string  `json:"ordId,omitempty"`
-		ClOrdID   string  `json:"clOrdId,omitempty"`
-		ReqID     string  `json:"reqId,omitempty"`
-		NewSz     int64   `json:"newSz,omitempty,string"`
-		NewPx     float64 `json:"newPx,omitempty,string"`
-		CxlOnFail bool    `json:"cxlOnFail,omitempty"`
+		ID        string       `json:"-"`
+		InstID    string       `json:"instId"`
+		OrdID     string       `json:"ordId,omitempty"`
+		ClOrdID   string       `json:"clOrdId,omitempty"`
+		ReqID     string       `json:"reqId,omitempty"`
+		NewSz     okex.Decimal `json:"newSz,omitempty"`
+		NewPx     okex.Decimal `json:"newPx,omitempty"`
+		CxlOnFail bool         `json:"cxlOnFail,omitempty"`
 	}
 	ClosePosition struct {
 		InstID  string            `json:"instId"`
@@ -42,6 +42,28 @@ type (
 		PosSide okex.PositionSide `json:"posSide,omitempty"`
 		MgnMode okex.MarginMode   `json:"mgnMode"`
 	}
+	MassCancel struct {
+		InstType   okex.InstrumentType `json:"instType"`
+		InstFamily string              `json:"instFamily"`
+		// LockInterval is the time in milliseconds the order placement stays locked after the cancellation, 0 to 10000
+		LockInterval int64 `json:"lockInterval,omitempty,string"`
+	}
+	OrderPrecheck struct {
+		InstID     string            `json:"instId"`
+		TdMode     okex.TradeMode    `json:"tdMode"`
+		Side       okex.OrderSide    `json:"side"`
+		PosSide    okex.PositionSide `json:"posSide,omitempty"`
+		OrdType    okex.OrderType    `json:"ordType"`
+		Sz         okex.Decimal      `json:"sz"`
+		Px         okex.Decimal      `json:"px,omitempty"`
+		ReduceOnly bool              `json:"reduceOnly,omitempty"`
+		TgtCcy     okex.QuantityType `json:"tgtCcy,omitempty"`
+	}
+	CancelAllAfter struct {
+		// TimeOut is the countdown in seconds, 0 or between 10 and 120, 0 disables it
+		TimeOut int64  `json:"timeOut,string"`
+		Tag     string `json:"tag,omitempty"`
+	}
 	OrderDetails struct {
 		InstID  string `json:"instId"`
 		OrdID   string `json:"ordId,omitempty"`
@@ -50,8 +72,8 @@ type (
 	OrderList struct {
 		Uly      string              `json:"uly,omitempty"`
 		InstID   string              `json:"instId,omitempty"`
-		After    float64             `json:"after,omitempty,string"`
-		Before   float64             `json:"before,omitempty,string"`
+		After    string              `json:"after,omitempty"`
+		Before   string              `json:"before,omitempty"`
 		Limit    float64             `json:"limit,omitempty,string"`
 		InstType okex.InstrumentType `json:"instType,omitempty"`
 		OrdType  okex.OrderType      `json:"ordType,omitempty"`
@@ -61,8 +83,8 @@ type (
 		Uly      string              `json:"uly,omitempty"`
 		InstID   string              `json:"instId,omitempty"`
 		OrdID    string              `json:"ordId,omitempty"`
-		After    float64             `json:"after,omitempty,string"`
-		Before   float64             `json:"before,omitempty,string"`
+		After    string              `json:"after,omitempty"`
+		Before   string              `json:"before,omitempty"`
 		Limit    float64             `json:"limit,omitempty,string"`
 		InstType okex.InstrumentType `json:"instType,omitempty"`
 	}
@@ -73,7 +95,7 @@ type (
 		Side       okex.OrderSide     `json:"side"`
 		PosSide    okex.PositionSide  `json:"posSide,omitempty"`
 		OrdType    okex.AlgoOrderType `json:"ordType"`
-		Sz         int64              `json:"sz,string"`
+		Sz         okex.Decimal       `json:"sz"`
 		ReduceOnly bool               `json:"reduceOnly,omitempty"`
 		TgtCcy     okex.QuantityType  `json:"tgtCcy,omitempty"`
 		StopOrder
@@ -82,24 +104,24 @@ type (
 		TWAPOrder
 	}
 	StopOrder struct {
-		TpTriggerPx float64 `json:"tpTriggerPx,string,omitempty"`
-		TpOrdPx     float64 `json:"tpOrdPx,string,omitempty"`
-		SlTriggerPx float64 `json:"slTriggerPx,string,omitempty"`
-		SlOrdPx     float64 `json:"slOrdPx,string,omitempty"`
+		TpTriggerPx okex.Decimal `json:"tpTriggerPx,omitempty"`
+		TpOrdPx     okex.Decimal `json:"tpOrdPx,omitempty"`
+		SlTriggerPx okex.Decimal `json:"slTriggerPx,omitempty"`
+		SlOrdPx     okex.Decimal `json:"slOrdPx,omitempty"`
 	}
 	TriggerOrder struct {
-		TriggerPx float64 `json:"triggerPx,string,omitempty"`
-		OrdPx     float64 `json:"ordPx,string,omitempty"`
+		TriggerPx okex.Decimal `json:"triggerPx,omitempty"`
+		OrdPx     okex.Decimal `json:"ordPx,omitempty"`
 	}
 	IcebergOrder struct {
-		PxVar    float64 `json:"pxVar,string,omitempty"`
-		PxSpread float64 `json:"pxSpread,string,omitempty"`
-		SzLimit  int64   `json:"szLimit,string"`
-		PxLimit  float64 `json:"pxLimit,string"`
+		PxVar    okex.Decimal `json:"pxVar,omitempty"`
+		PxSpread okex.Decimal `json:"pxSpread,omitempty"`
+		SzLimit  okex.Decimal `json:"szLimit,omitempty"`
+		PxLimit  okex.Decimal `json:"pxLimit,omitempty"`
 	}
 	TWAPOrder struct {
 		IcebergOrder
-		TimeInterval string `json:"timeInterval"`
+		TimeInterval string `json:"timeInterval,omitempty"`
 	}
 	CancelAlgoOrder struct {
 		InstID string `json:"instId"`
@@ -109,8 +131,8 @@ type (
 		InstType okex.InstrumentType `json:"instType,omitempty"`
 		Uly      string              `json:"uly,omitempty"`
 		InstID   string              `json:"instId,omitempty"`
-		After    float64             `json:"after,omitempty,string"`
-		Before   float64             `json:"before,omitempty,string"`
+		After    string              `json:"after,omitempty"`
+		Before   string              `json:"before,omitempty"`
 		Limit    float64             `json:"limit,omitempty,string"`
 		OrdType  okex.AlgoOrderType  `json:"ordType,omitempty"`
 		State    okex.OrderState     `json:"state,omitempty"`
@@ -21,4 +21,33 @@ type (
 		InstID   string              `json:"instId,omitempty"`
 		InstType okex.InstrumentType `json:"instType"`
 	}
+	LiquidationWarning struct {
+		InstFamily string              `json:"instFamily,omitempty"`
+		InstID     string              `json:"instId,omitempty"`
+		InstType   okex.InstrumentType `json:"instType"`
+	}
+	// PositionRiskWarning subscribes to the liquidation-warning channel
+	PositionRiskWarning = LiquidationWarning
+
+	AccountGreeks struct {
+		Ccy string `json:"ccy,omitempty"`
+	}
+	DepositInfo struct {
+		Ccy string `json:"ccy,omitempty"`
+	}
+	WithdrawalInfo struct {
+		Ccy string `json:"ccy,omitempty"`
+	}
+	GridOrder struct {
+		// AlgoOrdType selects the channel of the spot, contract or moon grids, it defaults to the spot grids
+		AlgoOrdType okex.AlgoOrderType  `json:"-"`
+		InstID      string              `json:"instId,omitempty"`
+		AlgoID      string              `json:"algoId,omitempty"`
+		InstType    okex.InstrumentType `json:"instType"`
+	}
+	AdvanceAlgoOrder struct {
+		InstID   string              `json:"instId,omitempty"`
+		AlgoID   string              `json:"algoId,omitempty"`
+		InstType okex.InstrumentType `json:"instType"`
+	}
 )
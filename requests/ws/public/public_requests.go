@@ -52,4 +52,21 @@ type (
 	IndexTickers struct {
 		InstID string `json:"instId"`
 	}
+	LiquidationOrders struct {
+		InstType okex.InstrumentType `json:"instType"`
+	}
+	BlockTrades struct {
+		InstID string `json:"instId"`
+	}
+	BlockTickers struct {
+		InstID string `json:"instId"`
+	}
+	OptionTrades struct {
+		InstID     string              `json:"instId,omitempty"`
+		InstFamily string              `json:"instFamily,omitempty"`
+		InstType   okex.InstrumentType `json:"instType"`
+	}
+	CallAuctionDetails struct {
+		InstID string `json:"instId"`
+	}
 )
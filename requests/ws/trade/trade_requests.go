@@ -10,8 +10,8 @@ type (
 		ClOrdID    string            `json:"clOrdId,omitempty"`
 		Tag        string            `json:"tag,omitempty"`
 		ReduceOnly bool              `json:"reduceOnly,omitempty"`
-		Sz         float64           `json:"sz,string"`
-		Px         float64           `json:"px,omitempty,string"`
+		Sz         okex.Decimal      `json:"sz"`
+		Px         okex.Decimal      `json:"px,omitempty"`
 		TdMode     okex.TradeMode    `json:"tdMode"`
 		Side       okex.OrderSide    `json:"side"`
 		PosSide    okex.PositionSide `json:"posSide,omitempty"`
@@ -25,13 +25,20 @@ type (
 		ClOrdID string `json:"clOrdId,omitempty"`
 	}
 	AmendOrder struct {
-		ID        string  `json:"-"`
-		InstID    string  `json:"instId"`
-		OrdID     string  `json:"ordId,omitempty"`
-		ClOrdID   string  `json:"clOrdId,omitempty"`
-		ReqID     string  `json:"reqId,omitempty"`
-		NewSz     int64   `json:"newSz,omitempty,string"`
-		NewPx     float64 `json:"newPx,omitempty,string"`
-		CxlOnFail bool    `json:"cxlOnFail,omitempty"`
+		ID        string       `json:"-"`
+		InstID    string       `json:"instId"`
+		OrdID     string       `json:"ordId,omitempty"`
+		ClOrdID   string       `json:"clOrdId,omitempty"`
+		ReqID     string       `json:"reqId,omitempty"`
+		NewSz     okex.Decimal `json:"newSz,omitempty"`
+		NewPx     okex.Decimal `json:"newPx,omitempty"`
+		CxlOnFail bool         `json:"cxlOnFail,omitempty"`
+	}
+	MassCancel struct {
+		ID         string              `json:"-"`
+		InstType   okex.InstrumentType `json:"instType"`
+		InstFamily string              `json:"instFamily"`
+		// LockInterval is the time in milliseconds the order placement stays locked after the cancellation, 0 to 10000
+		LockInterval int64 `json:"lockInterval,omitempty,string"`
 	}
 )
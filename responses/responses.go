@@ -1,11 +1,18 @@
 package responses
 
+import "github.com/dimkus/okex"
+
 type (
 	BasicI interface {
 		GetCode() int
 		GetMsg() string
 	}
 
+	// BatchI is implemented by responses whose items carry their own status code
+	BatchI interface {
+		GetSubErrors() []*okex.SubError
+	}
+
 	Basic struct {
 		Code int    `json:"code,string"`
 		Msg  string `json:"msg,omitempty"`
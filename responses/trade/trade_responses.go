@@ -1,6 +1,7 @@
 package trade
 
 import (
+	"github.com/dimkus/okex"
 	"github.com/dimkus/okex/models/trade"
 	"github.com/dimkus/okex/responses"
 )
@@ -22,6 +23,18 @@ type (
 		responses.Basic
 		ClosePositions []*trade.ClosePosition `json:"data"`
 	}
+	MassCancel struct {
+		responses.Basic
+		MassCancels []*trade.MassCancel `json:"data"`
+	}
+	OrderPrecheck struct {
+		responses.Basic
+		OrderPrechecks []*trade.OrderPrecheck `json:"data"`
+	}
+	CancelAllAfter struct {
+		responses.Basic
+		CancelAllAfters []*trade.CancelAllAfter `json:"data"`
+	}
 	OrderList struct {
 		responses.Basic
 		Orders []*trade.Order `json:"data"`
@@ -43,3 +56,48 @@ type (
 		AlgoOrders []*trade.AlgoOrder `json:"data"`
 	}
 )
+
+func (r *PlaceOrder) GetSubErrors() (res []*okex.SubError) {
+	for i, o := range r.PlaceOrders {
+		if o.SCode != 0 {
+			res = append(res, &okex.SubError{Index: i, Code: int(o.SCode), Msg: o.SMsg, ID: o.OrdID, ClOrdID: o.ClOrdID})
+		}
+	}
+	return
+}
+
+func (r *CancelOrder) GetSubErrors() (res []*okex.SubError) {
+	for i, o := range r.CancelOrders {
+		if o.SCode != 0 {
+			res = append(res, &okex.SubError{Index: i, Code: int(o.SCode), Msg: o.SMsg, ID: o.OrdID, ClOrdID: o.ClOrdID})
+		}
+	}
+	return
+}
+
+func (r *AmendOrder) GetSubErrors() (res []*okex.SubError) {
+	for i, o := range r.AmendOrders {
+		if o.SCode != 0 {
+			res = append(res, &okex.SubError{Index: i, Code: int(o.SCode), Msg: o.SMsg, ID: o.OrdID, ClOrdID: o.ClOrdID})
+		}
+	}
+	return
+}
+
+func (r *PlaceAlgoOrder) GetSubErrors() (res []*okex.SubError) {
+	for i, o := range r.PlaceAlgoOrders {
+		if o.SCode != 0 {
+			res = append(res, &okex.SubError{Index: i, Code: int(o.SCode), Msg: o.SMsg, ID: o.AlgoID})
+		}
+	}
+	return
+}
+
+func (r *CancelAlgoOrder) GetSubErrors() (res []*okex.SubError) {
+	for i, o := range r.CancelAlgoOrders {
+		if o.SCode != 0 {
+			res = append(res, &okex.SubError{Index: i, Code: int(o.SCode), Msg: o.SMsg, ID: o.AlgoID})
+		}
+	}
+	return
+}